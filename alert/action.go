@@ -0,0 +1,111 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Action kinds
+const (
+	ActionPrint = "print"
+	ActionExit  = "exit"
+	ActionExec  = "exec"
+	ActionPost  = "post"
+)
+
+// webhookTimeout bounds how long a post action waits for the webhook
+const webhookTimeout = 5 * time.Second
+
+// exitTimeout bounds how long an exit action waits for commands and webhooks
+// which are still running
+const exitTimeout = 10 * time.Second
+
+// Action is taken when a rule fires
+type Action struct {
+	Kind string
+	// Arg is the shell command for exec and the url for post
+	Arg string
+}
+
+func parseAction(text string) (Action, error) {
+	text = strings.TrimSpace(text)
+	kind := text
+	arg := ""
+	if i := strings.IndexAny(text, " \t"); i >= 0 {
+		kind = text[:i]
+		arg = strings.TrimSpace(text[i:])
+	}
+	if len(arg) >= 2 && (arg[0] == '"' || arg[0] == '\'') &&
+		strings.IndexByte(arg[1:], arg[0]) == len(arg)-2 {
+		arg = arg[1 : len(arg)-1]
+	}
+
+	a := Action{Kind: strings.ToLower(kind), Arg: arg}
+	switch a.Kind {
+	case ActionPrint, ActionExit:
+		if arg != "" {
+			return a, fmt.Errorf("%s takes no argument", a.Kind)
+		}
+	case ActionExec, ActionPost:
+		if arg == "" {
+			return a, fmt.Errorf("%s requires an argument", a.Kind)
+		}
+	default:
+		return a, fmt.Errorf("unknown action %q", kind)
+	}
+
+	return a, nil
+}
+
+// Alert describes a rule firing
+type Alert struct {
+	Rule   string    `json:"rule"`
+	Device string    `json:"device"`
+	Value  float64   `json:"value"`
+	Time   time.Time `json:"time"`
+
+	// rule is the index of the rule in the engine
+	rule int
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("ALERT %s %s: %s (%g)", a.Time.Format("15:04:05"), a.Device, a.Rule, a.Value)
+}
+
+// run performs the exec and post actions, print and exit are left to the
+// caller which knows where output should go
+func (act Action) run(a Alert) error {
+	switch act.Kind {
+	case ActionExec:
+		cmd := exec.Command("sh", "-c", act.Arg)
+		cmd.Env = append(os.Environ(),
+			"ALERT_RULE="+a.Rule,
+			"ALERT_DEVICE="+a.Device,
+			fmt.Sprintf("ALERT_VALUE=%g", a.Value),
+			"ALERT_TIME="+a.Time.Format(time.RFC3339))
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	case ActionPost:
+		body, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		client := http.Client{Timeout: webhookTimeout}
+		resp, err := client.Post(act.Arg, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("webhook %s returned %s", act.Arg, resp.Status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package alert
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/recording"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text  string
+		check func(r Rule) bool
+	}{
+		{"voltage < 3.4", func(r Rule) bool {
+			return r.Field == "voltage" && r.Op == "<" && r.Value == 3.4 &&
+				len(r.Actions) == 1 && r.Actions[0].Kind == ActionPrint
+		}},
+		{"cpu_temperature>=70 => exit", func(r Rule) bool {
+			return r.Field == "cpu_temperature" && r.Op == ">=" && r.Value == 70 &&
+				r.Actions[0].Kind == ActionExit
+		}},
+		{"no status for 60s => print; post http://localhost:8080/hook", func(r Rule) bool {
+			return r.Silence == time.Minute && len(r.Actions) == 2 &&
+				r.Actions[1].Kind == ActionPost && r.Actions[1].Arg == "http://localhost:8080/hook"
+		}},
+		{"log_entries approaching capacity => exec echo $ALERT_DEVICE", func(r Rule) bool {
+			return r.Capacity && r.Actions[0].Kind == ActionExec && r.Actions[0].Arg == "echo $ALERT_DEVICE"
+		}},
+		// Semicolons in quotes belong to the command
+		{`voltage < 3.4 => exec "date; uptime"; exit`, func(r Rule) bool {
+			return len(r.Actions) == 2 && r.Actions[0].Arg == "date; uptime" &&
+				r.Actions[1].Kind == ActionExit
+		}},
+		{`voltage < 3.4 => exec echo 'low; replace'`, func(r Rule) bool {
+			return len(r.Actions) == 1 && r.Actions[0].Arg == "echo 'low; replace'"
+		}},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.text)
+		if err != nil {
+			t.Errorf("Parse(%q): %s", tt.text, err)
+			continue
+		}
+		if !tt.check(r) {
+			t.Errorf("Parse(%q) = %+v", tt.text, r)
+		}
+	}
+
+	for _, text := range []string{
+		"battery < 3.4",
+		"voltage < low",
+		"no status for ever",
+		"lux approaching capacity",
+		"voltage < 3.4 => shout",
+		"voltage < 3.4 => exec",
+		"voltage < 3.4 => exit 1",
+		`voltage < 3.4 => exec "date; uptime`,
+	} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("Parse(%q) succeeded", text)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	rules, err := ParseFile("# battery\nvoltage < 3.4\n\nno status for 1m => exit\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("%d rules, want 2", len(rules))
+	}
+
+	_, err = ParseFile("voltage < 3.4\nvoltage is low\n")
+	if err == nil || err.Error()[:7] != "line 2:" {
+		t.Errorf("ParseFile() error = %v", err)
+	}
+}
+
+func mustParse(t *testing.T, texts ...string) []Rule {
+	var rules []Rule
+	for _, text := range texts {
+		r, err := Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func TestEvaluate(t *testing.T) {
+	e := NewEngine(mustParse(t, "voltage < 3.4", "current > 1", "log_entries approaching capacity"), nil)
+	e.LogCapacity = 100
+	now := time.Now()
+
+	sample := func(board string, voltage float32, current float32, entries uint16) recording.Sample {
+		return recording.Sample{HostTime: now, Device: "dev", Board: board,
+			Voltage: voltage, Current: current, LogEntries: entries}
+	}
+
+	steps := []struct {
+		s    recording.Sample
+		want []string
+	}{
+		{sample(recording.BoardMotion, 3.6, 0, 10), nil},
+		{sample(recording.BoardMotion, 3.3, 2, 10), []string{"voltage < 3.4"}},
+		// Still low, already fired
+		{sample(recording.BoardMotion, 3.3, 0, 10), nil},
+		{sample(recording.BoardMotion, 3.5, 0, 10), nil},
+		{sample(recording.BoardMotion, 3.3, 0, 95), []string{"voltage < 3.4", "log_entries approaching capacity"}},
+		{sample(recording.BoardLight, 3.5, 2, 0), []string{"current > 1"}},
+	}
+	for i, step := range steps {
+		alerts := e.Evaluate(step.s)
+		if len(alerts) != len(step.want) {
+			t.Errorf("step %d: alerts %v, want %v", i, alerts, step.want)
+			continue
+		}
+		for j, a := range alerts {
+			if a.Rule != step.want[j] {
+				t.Errorf("step %d: alert %q, want %q", i, a.Rule, step.want[j])
+			}
+		}
+	}
+}
+
+func TestLogCapacityRequired(t *testing.T) {
+	e := NewEngine(mustParse(t, "log_entries approaching capacity"), nil)
+	if err := e.Check(); err == nil {
+		t.Errorf("Check() succeeded without a log capacity")
+	}
+
+	// Not evaluated rather than firing on every status
+	s := recording.Sample{HostTime: time.Now(), Device: "dev", Board: recording.BoardMotion}
+	if alerts := e.Evaluate(s); len(alerts) != 0 {
+		t.Errorf("alerts without a log capacity: %v", alerts)
+	}
+
+	e.LogCapacity = 100
+	if err := e.Check(); err != nil {
+		t.Errorf("Check() = %s", err)
+	}
+}
+
+func TestCheckSilence(t *testing.T) {
+	e := NewEngine(mustParse(t, "no status for 60s => exit"), func(string) {})
+	start := time.Now()
+	e.Watch("dev", start)
+
+	if alerts := e.CheckSilence(start.Add(30 * time.Second)); len(alerts) != 0 {
+		t.Errorf("alerts after 30s: %v", alerts)
+	}
+
+	alerts := e.CheckSilence(start.Add(61 * time.Second))
+	if len(alerts) != 1 || alerts[0].Device != "dev" {
+		t.Fatalf("alerts after 61s: %v", alerts)
+	}
+	if alerts := e.CheckSilence(start.Add(90 * time.Second)); len(alerts) != 0 {
+		t.Errorf("alert repeated: %v", alerts)
+	}
+
+	e.Dispatch(alerts)
+	select {
+	case <-e.Exit():
+	case <-time.After(time.Second):
+		t.Errorf("exit action did not close Exit()")
+	}
+
+	// A status re-arms the rule
+	e.Evaluate(recording.Sample{HostTime: start.Add(100 * time.Second), Device: "dev"})
+	if alerts := e.CheckSilence(start.Add(200 * time.Second)); len(alerts) != 1 {
+		t.Errorf("alerts after status and silence: %v", alerts)
+	}
+}
+
+func TestDispatchWaitsForActions(t *testing.T) {
+	release := make(chan struct{})
+	var posted atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		posted.Store(true)
+	}))
+	defer srv.Close()
+
+	e := NewEngine(mustParse(t, "no status for 60s => post "+srv.URL+"; exit"), func(string) {})
+	start := time.Now()
+	e.Watch("dev", start)
+	e.Dispatch(e.CheckSilence(start.Add(61 * time.Second)))
+
+	select {
+	case <-e.Exit():
+		t.Fatalf("Exit() closed before the webhook finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-e.Exit():
+	case <-time.After(time.Second):
+		t.Fatalf("Exit() not closed after the webhook finished")
+	}
+	if !posted.Load() {
+		t.Errorf("webhook not delivered before exit")
+	}
+}
+
+func TestDispatchDuplicateRules(t *testing.T) {
+	var printed []string
+	e := NewEngine(mustParse(t, "voltage < 3.4", "voltage < 3.4 => exit"), func(msg string) {
+		printed = append(printed, msg)
+	})
+
+	s := recording.Sample{HostTime: time.Now(), Device: "dev", Board: recording.BoardMotion, Voltage: 3.3}
+	alerts := e.Evaluate(s)
+	if len(alerts) != 2 {
+		t.Fatalf("alerts: %v", alerts)
+	}
+	e.Dispatch(alerts)
+
+	select {
+	case <-e.Exit():
+	case <-time.After(time.Second):
+		t.Errorf("exit action of the second rule did not run")
+	}
+	if len(printed) != 2 {
+		t.Errorf("printed %q", printed)
+	}
+}
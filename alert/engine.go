@@ -0,0 +1,202 @@
+package alert
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/recording"
+)
+
+// Engine evaluates rules against status samples. A rule fires once when its
+// condition becomes true for a device and again only after the condition has
+// cleared.
+type Engine struct {
+	Rules []Rule
+	// LogCapacity is the number of log entries the device can hold. The
+	// device does not report it, so it must be set for capacity rules.
+	LogCapacity int
+	// Print receives alerts for print actions and errors from other actions
+	Print func(msg string)
+
+	mutex      sync.Mutex
+	active     map[string]bool
+	lastStatus map[string]time.Time
+	exit       chan struct{}
+	exited     bool
+	pending    sync.WaitGroup
+}
+
+// NewEngine creates an engine for rules which prints with print
+func NewEngine(rules []Rule, print func(msg string)) *Engine {
+	return &Engine{
+		Rules:      rules,
+		Print:      print,
+		active:     make(map[string]bool),
+		lastStatus: make(map[string]time.Time),
+		exit:       make(chan struct{}),
+	}
+}
+
+// Check returns an error if a rule cannot be evaluated with the engine's
+// settings
+func (e *Engine) Check() error {
+	for _, r := range e.Rules {
+		if r.Capacity && e.LogCapacity <= 0 {
+			return fmt.Errorf("%q requires the log capacity of the device", r.Text)
+		}
+	}
+	return nil
+}
+
+// Exit is closed when a rule with an exit action fires and its commands and
+// webhooks have finished
+func (e *Engine) Exit() <-chan struct{} {
+	return e.exit
+}
+
+// Watch starts the silence timers for device as if a status had been
+// received at now
+func (e *Engine) Watch(device string, now time.Time) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if _, ok := e.lastStatus[device]; !ok {
+		e.lastStatus[device] = now
+	}
+}
+
+// update records whether rule i holds for device and reports whether it has
+// just started to hold
+func (e *Engine) update(i int, device string, holds bool) bool {
+	key := fmt.Sprintf("%d/%s", i, device)
+	fired := holds && !e.active[key]
+	e.active[key] = holds
+	return fired
+}
+
+// Evaluate checks the rules against a status sample and returns the alerts
+// which have just fired
+func (e *Engine) Evaluate(s recording.Sample) []Alert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.lastStatus[s.Device] = s.HostTime
+
+	var alerts []Alert
+	for i, r := range e.Rules {
+		var value float64
+		var holds bool
+
+		switch {
+		case r.Silence > 0:
+			// A status has just arrived
+			holds = false
+		case r.Capacity:
+			if e.LogCapacity <= 0 {
+				continue
+			}
+			value = float64(s.LogEntries)
+			holds = value >= capacityFraction*float64(e.LogCapacity)
+		default:
+			f := fields[r.Field]
+			if f.board != "" && f.board != s.Board {
+				continue
+			}
+			value = f.value(s)
+			holds = r.compare(value)
+		}
+
+		if e.update(i, s.Device, holds) {
+			alerts = append(alerts, Alert{Rule: r.Text, Device: s.Device, Value: value, Time: s.HostTime, rule: i})
+		}
+	}
+
+	return alerts
+}
+
+// CheckSilence returns alerts for devices which have sent no status for
+// longer than a silence rule allows
+func (e *Engine) CheckSilence(now time.Time) []Alert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var alerts []Alert
+	for device, last := range e.lastStatus {
+		quiet := now.Sub(last)
+		for i, r := range e.Rules {
+			if r.Silence <= 0 || quiet < r.Silence {
+				continue
+			}
+			if e.update(i, device, true) {
+				alerts = append(alerts, Alert{Rule: r.Text, Device: device, Value: quiet.Seconds(), Time: now, rule: i})
+			}
+		}
+	}
+
+	return alerts
+}
+
+// Dispatch performs the actions of the rules behind alerts. Commands and
+// webhooks run in the background so a slow action does not hold up status
+// handling. An exit action closes Exit once they have finished, or after
+// exitTimeout.
+func (e *Engine) Dispatch(alerts []Alert) {
+	exit := false
+	for _, a := range alerts {
+		for _, act := range e.Rules[a.rule].Actions {
+			switch act.Kind {
+			case ActionPrint:
+				e.Print(a.String())
+			case ActionExit:
+				e.Print(a.String())
+				exit = true
+			default:
+				e.start(act, a)
+			}
+		}
+	}
+
+	if exit {
+		e.mutex.Lock()
+		if !e.exited {
+			e.exited = true
+			go e.finish()
+		}
+		e.mutex.Unlock()
+	}
+}
+
+// start runs act in the background unless the engine is exiting
+func (e *Engine) start(act Action, a Alert) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.exited {
+		return
+	}
+	e.pending.Add(1)
+	go func() {
+		defer e.pending.Done()
+		err := act.run(a)
+		if err != nil {
+			e.Print(fmt.Sprintf("%s %s: %s", act.Kind, act.Arg, err))
+		}
+	}()
+}
+
+// finish closes exit once the background actions have finished
+func (e *Engine) finish() {
+	done := make(chan struct{})
+	go func() {
+		e.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(exitTimeout):
+		e.Print("exiting with alert actions still running")
+	}
+	close(e.exit)
+}
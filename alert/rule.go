@@ -0,0 +1,216 @@
+package alert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/recording"
+)
+
+// capacityFraction of the log capacity at which the log is considered to be
+// approaching capacity
+const capacityFraction = 0.9
+
+// field reads a numeric value from a status sample. board restricts the
+// field to one board type, empty when every board reports it.
+type field struct {
+	board string
+	value func(s recording.Sample) float64
+}
+
+var fields = map[string]field{
+	"voltage":           {"", func(s recording.Sample) float64 { return float64(s.Voltage) }},
+	"cpu_temperature":   {"", func(s recording.Sample) float64 { return float64(s.Temperature) }},
+	"temperature":       {"", func(s recording.Sample) float64 { return float64(s.Temperature) }},
+	"log_entries":       {"", func(s recording.Sample) float64 { return float64(s.LogEntries) }},
+	"motion":            {recording.BoardMotion, func(s recording.Sample) float64 { return float64(s.Motion) }},
+	"motion_threshold":  {recording.BoardMotion, func(s recording.Sample) float64 { return float64(s.MotionThreshold) }},
+	"lux":               {recording.BoardMotion, func(s recording.Sample) float64 { return float64(s.Lux) }},
+	"level":             {recording.BoardLight, func(s recording.Sample) float64 { return float64(s.Level) }},
+	"current":           {recording.BoardLight, func(s recording.Sample) float64 { return float64(s.Current) }},
+	"light_temperature": {recording.BoardLight, func(s recording.Sample) float64 { return float64(s.LightTemperature) }},
+}
+
+// Rule is a single alert condition and the actions taken when it becomes
+// true
+type Rule struct {
+	// Text is the rule as written by the user
+	Text string
+
+	// Field, Op and Value describe a comparison against a status field
+	Field string
+	Op    string
+	Value float64
+
+	// Silence is set for rules which fire when no status has been received
+	// for this long
+	Silence time.Duration
+
+	// Capacity is set for rules which fire when the log is nearly full
+	Capacity bool
+
+	Actions []Action
+}
+
+// Parse reads a rule of the form
+//
+//	CONDITION [=> ACTION [; ACTION]...]
+//
+// where CONDITION is one of
+//
+//	FIELD OP NUMBER            e.g. voltage < 3.4
+//	no status for DURATION     e.g. no status for 60s
+//	log_entries approaching capacity
+//
+// and ACTION is one of print, exit, exec COMMAND or post URL. Rules without
+// actions print. Actions are split at semicolons outside quotes, and a
+// command wholly in quotes is unquoted, so a command of several statements
+// can be written exec "date; uptime".
+func Parse(text string) (Rule, error) {
+	r := Rule{Text: strings.TrimSpace(text)}
+
+	condition := r.Text
+	var actions string
+	if i := strings.Index(condition, "=>"); i >= 0 {
+		actions = condition[i+2:]
+		condition = strings.TrimSpace(condition[:i])
+	}
+
+	err := r.parseCondition(condition)
+	if err != nil {
+		return r, fmt.Errorf("%q: %s", r.Text, err)
+	}
+
+	parts, err := splitActions(actions)
+	if err != nil {
+		return r, fmt.Errorf("%q: %s", r.Text, err)
+	}
+	for _, a := range parts {
+		if strings.TrimSpace(a) == "" {
+			continue
+		}
+		action, err := parseAction(a)
+		if err != nil {
+			return r, fmt.Errorf("%q: %s", r.Text, err)
+		}
+		r.Actions = append(r.Actions, action)
+	}
+	if len(r.Actions) == 0 {
+		r.Actions = []Action{{Kind: ActionPrint}}
+	}
+
+	return r, nil
+}
+
+// splitActions splits text at the semicolons which are not quoted
+func splitActions(text string) ([]string, error) {
+	var parts []string
+	var quote rune
+	start := 0
+	for i, c := range text {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ';':
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c", quote)
+	}
+	return append(parts, text[start:]), nil
+}
+
+func (r *Rule) parseCondition(condition string) error {
+	words := strings.Fields(strings.ToLower(condition))
+
+	switch {
+	case len(words) == 4 && words[0] == "no" && words[1] == "status" && words[2] == "for":
+		d, err := time.ParseDuration(words[3])
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return fmt.Errorf("silence duration must be positive")
+		}
+		r.Silence = d
+		return nil
+	case len(words) == 3 && words[1] == "approaching" && words[2] == "capacity":
+		if words[0] != "log_entries" && words[0] != "log" {
+			return fmt.Errorf("only log_entries has a capacity")
+		}
+		r.Capacity = true
+		return nil
+	}
+
+	// Allow comparisons written without spaces, e.g. voltage<3.4
+	for _, op := range []string{"<=", ">=", "==", "!=", "<", ">"} {
+		i := strings.Index(condition, op)
+		if i < 0 {
+			continue
+		}
+
+		name := strings.ToLower(strings.TrimSpace(condition[:i]))
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("unknown field %q", name)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(condition[i+len(op):]), 64)
+		if err != nil {
+			return err
+		}
+
+		r.Field = name
+		r.Op = op
+		r.Value = value
+		return nil
+	}
+
+	return fmt.Errorf("unrecognised condition")
+}
+
+// compare reports whether value satisfies the rule's comparison
+func (r *Rule) compare(value float64) bool {
+	switch r.Op {
+	case "<":
+		return value < r.Value
+	case "<=":
+		return value <= r.Value
+	case ">":
+		return value > r.Value
+	case ">=":
+		return value >= r.Value
+	case "==":
+		return value == r.Value
+	case "!=":
+		return value != r.Value
+	}
+	return false
+}
+
+// ParseFile reads one rule per line, skipping blank lines and lines starting
+// with #
+func ParseFile(contents string) ([]Rule, error) {
+	var rules []Rule
+
+	for n, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r, err := Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n+1, err)
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if s.Count != 8 || s.Mean != 5 || s.Min != 2 || s.Max != 9 {
+		t.Errorf("Summarize() = %+v", s)
+	}
+	if math.Abs(s.StdDev-2.138) > 0.001 {
+		t.Errorf("StdDev = %f, want 2.138", s.StdDev)
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3}
+	tests := []struct {
+		q    float64
+		want float64
+	}{
+		{0, 1},
+		{0.5, 3},
+		{0.75, 4},
+		{0.875, 4.5},
+		{1, 5},
+	}
+	for _, tt := range tests {
+		if got := Quantile(values, tt.q); got != tt.want {
+			t.Errorf("Quantile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestNormalQuantile(t *testing.T) {
+	// 2.5% of a standard normal lies above 1.96
+	if got := NormalQuantile(0, 1, 0.025); math.Abs(got-1.96) > 0.001 {
+		t.Errorf("NormalQuantile() = %f, want 1.96", got)
+	}
+}
+
+func TestRecommendThreshold(t *testing.T) {
+	samples := make([]float64, 3600)
+	for i := range samples {
+		samples[i] = float64(i%100) / 1000
+	}
+
+	// One sample a second, 36 exceedances an hour is 1% of samples
+	r, err := RecommendThreshold(samples, 1, 36)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Extrapolated {
+		t.Errorf("Extrapolated with sufficient samples")
+	}
+	if got := TriggersPerHour(samples, 1, r.Threshold); got > 36 {
+		t.Errorf("threshold %f gives %f triggers/hour, want <= 36", r.Threshold, got)
+	}
+
+	// A rate below one in the window must extrapolate beyond the peak
+	r, err = RecommendThreshold(samples, 1, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Extrapolated || r.Threshold < r.Noise.Max {
+		t.Errorf("RecommendThreshold() = %+v, want extrapolated above max", r)
+	}
+}
+
+func TestEstimateDischarge(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	var times []time.Time
+	var volts []float64
+	for hour := 0; hour <= 10*24; hour++ {
+		times = append(times, start.Add(time.Duration(hour)*time.Hour))
+		volts = append(volts, 4.0-0.02*float64(hour)/24)
+	}
+
+	d, err := EstimateDischarge(times, volts, 3.4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(d.VoltsPerDay+0.02) > 1e-9 || math.Abs(d.Fit.R2-1) > 1e-9 {
+		t.Errorf("EstimateDischarge() fit = %+v, want slope -0.02", d.Fit)
+	}
+	// 3.8 V after 10 days, 0.4 V left at 0.02 V/day
+	if math.Abs(d.DaysRemaining-20) > 1e-6 {
+		t.Errorf("DaysRemaining = %f, want 20", d.DaysRemaining)
+	}
+
+	d, err = EstimateDischarge(times[:2], []float64{3.7, 3.8}, 3.4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsInf(d.DaysRemaining, 1) {
+		t.Errorf("DaysRemaining = %f for rising voltage, want +Inf", d.DaysRemaining)
+	}
+}
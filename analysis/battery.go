@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// LinearFit is a least squares fit of y = Intercept + Slope*x
+type LinearFit struct {
+	Intercept float64
+	Slope     float64
+	// R2 is the coefficient of determination, 1 is a perfect fit
+	R2 float64
+}
+
+// FitLinear fits a straight line to the points (x, y)
+func FitLinear(x, y []float64) (LinearFit, error) {
+	var fit LinearFit
+
+	if len(x) != len(y) {
+		return fit, fmt.Errorf("mismatched lengths %d and %d", len(x), len(y))
+	}
+	if len(x) < 2 {
+		return fit, fmt.Errorf("at least 2 points required, have %d", len(x))
+	}
+
+	mx := Summarize(x).Mean
+	my := Summarize(y).Mean
+
+	var sxx, sxy, syy float64
+	for i := range x {
+		sxx += (x[i] - mx) * (x[i] - mx)
+		sxy += (x[i] - mx) * (y[i] - my)
+		syy += (y[i] - my) * (y[i] - my)
+	}
+	if sxx == 0 {
+		return fit, fmt.Errorf("all points share the same x")
+	}
+
+	fit.Slope = sxy / sxx
+	fit.Intercept = my - fit.Slope*mx
+	if syy > 0 {
+		fit.R2 = sxy * sxy / (sxx * syy)
+	} else {
+		fit.R2 = 1
+	}
+
+	return fit, nil
+}
+
+// Discharge is a battery voltage trend
+type Discharge struct {
+	Fit LinearFit
+	// Start is the time of the first sample, the fit x axis is days since
+	Start time.Time
+	Days  float64
+	// Voltage is the fitted voltage at the last sample
+	Voltage     float64
+	VoltsPerDay float64
+	// DaysRemaining until the fitted voltage reaches the cutoff, +Inf if the
+	// voltage is not falling
+	DaysRemaining float64
+}
+
+// EstimateDischarge fits the voltage trend and projects when it will fall
+// to cutoff. Battery voltage is not linear over a full discharge so the
+// estimate is best made from a recent window of the history.
+func EstimateDischarge(times []time.Time, volts []float64, cutoff float64) (Discharge, error) {
+	var d Discharge
+
+	if len(times) != len(volts) {
+		return d, fmt.Errorf("mismatched lengths %d and %d", len(times), len(volts))
+	}
+	if len(times) < 2 {
+		return d, fmt.Errorf("at least 2 samples required, have %d", len(times))
+	}
+
+	d.Start = times[0]
+	days := make([]float64, len(times))
+	for i, t := range times {
+		days[i] = t.Sub(d.Start).Hours() / 24
+	}
+	d.Days = days[len(days)-1]
+
+	fit, err := FitLinear(days, volts)
+	if err != nil {
+		return d, err
+	}
+
+	d.Fit = fit
+	d.VoltsPerDay = fit.Slope
+	d.Voltage = fit.Intercept + fit.Slope*d.Days
+
+	if fit.Slope >= 0 {
+		d.DaysRemaining = math.Inf(1)
+	} else {
+		d.DaysRemaining = math.Max(0, (cutoff-d.Voltage)/fit.Slope)
+	}
+
+	return d, nil
+}
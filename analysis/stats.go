@@ -0,0 +1,92 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// Stats summarises a set of values
+type Stats struct {
+	Count  int
+	Mean   float64
+	StdDev float64
+	Min    float64
+	Max    float64
+}
+
+// Summarize computes the statistics of values
+func Summarize(values []float64) Stats {
+	s := Stats{Count: len(values)}
+	if len(values) == 0 {
+		return s
+	}
+
+	s.Min = values[0]
+	s.Max = values[0]
+	for _, v := range values {
+		s.Mean += v
+		if v < s.Min {
+			s.Min = v
+		}
+		if v > s.Max {
+			s.Max = v
+		}
+	}
+	s.Mean /= float64(len(values))
+
+	if len(values) > 1 {
+		for _, v := range values {
+			s.StdDev += (v - s.Mean) * (v - s.Mean)
+		}
+		s.StdDev = math.Sqrt(s.StdDev / float64(len(values)-1))
+	}
+
+	return s
+}
+
+// Quantile returns the q quantile (0 - 1) of values using linear
+// interpolation between the closest ranks
+func Quantile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	if q <= 0 {
+		return sorted[0]
+	}
+	if q >= 1 {
+		return sorted[len(sorted)-1]
+	}
+
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	frac := pos - float64(lower)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
+}
+
+// NormalQuantile returns the value exceeded with probability p by a normal
+// distribution with the given mean and standard deviation
+func NormalQuantile(mean, stddev, p float64) float64 {
+	return mean + stddev*math.Sqrt2*math.Erfinv(1-2*p)
+}
+
+// ExceedanceFraction returns the fraction of values above threshold
+func ExceedanceFraction(values []float64, threshold float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	n := 0
+	for _, v := range values {
+		if v > threshold {
+			n++
+		}
+	}
+	return float64(n) / float64(len(values))
+}
@@ -0,0 +1,61 @@
+package analysis
+
+import "fmt"
+
+// ThresholdRecommendation is a motion threshold chosen from noise samples
+type ThresholdRecommendation struct {
+	Noise Stats
+	// SampleRate is the number of samples per second
+	SampleRate float64
+	// Probability is the per sample exceedance probability which gives the
+	// target false trigger rate
+	Probability float64
+	// Extrapolated is set when the quiet window was too short to observe the
+	// target rate directly and a normal fit of the noise was used instead
+	Extrapolated bool
+	Threshold    float64
+}
+
+// RecommendThreshold picks a threshold which noise alone would exceed at
+// most targetPerHour times an hour. samples are motion values recorded over a
+// quiet window, sampleRate is the number of samples per second.
+func RecommendThreshold(samples []float64, sampleRate float64, targetPerHour float64) (ThresholdRecommendation, error) {
+	r := ThresholdRecommendation{
+		Noise:      Summarize(samples),
+		SampleRate: sampleRate,
+	}
+
+	if len(samples) < 2 {
+		return r, fmt.Errorf("at least 2 samples required, have %d", len(samples))
+	}
+	if sampleRate <= 0 {
+		return r, fmt.Errorf("invalid sample rate %f", sampleRate)
+	}
+	if targetPerHour <= 0 {
+		return r, fmt.Errorf("target rate must be positive")
+	}
+
+	r.Probability = targetPerHour / (sampleRate * 3600)
+	if r.Probability >= 1 {
+		r.Probability = 1
+	}
+
+	if r.Probability >= 1/float64(len(samples)) {
+		r.Threshold = Quantile(samples, 1-r.Probability)
+	} else {
+		// The window holds too few samples to see an exceedance this rare,
+		// assume the noise is normal and never go below the observed peak
+		r.Extrapolated = true
+		r.Threshold = NormalQuantile(r.Noise.Mean, r.Noise.StdDev, r.Probability)
+		if r.Threshold < r.Noise.Max {
+			r.Threshold = r.Noise.Max
+		}
+	}
+
+	return r, nil
+}
+
+// TriggersPerHour estimates how often noise alone exceeds threshold
+func TriggersPerHour(samples []float64, sampleRate float64, threshold float64) float64 {
+	return ExceedanceFraction(samples, threshold) * sampleRate * 3600
+}
@@ -0,0 +1,208 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+const (
+	logFileName   = "log.jsonl"
+	stateFileName = "state.json"
+)
+
+// Entry is a single archived device log entry
+type Entry struct {
+	// Epoch counts the device log resets observed by this archive
+	Epoch     int       `json:"epoch"`
+	Index     uint16    `json:"index"`
+	Timestamp string    `json:"timestamp"`
+	LogType   uint8     `json:"log_type"`
+	Payload   string    `json:"payload"`
+	Fetched   time.Time `json:"fetched"`
+}
+
+// NewEntry converts a log response into an archive entry
+func NewEntry(msg messages.LogResponseMessage, epoch int, fetched time.Time) Entry {
+	return Entry{
+		Epoch:     epoch,
+		Index:     msg.Index,
+		Timestamp: msg.Timestamp.String(),
+		LogType:   msg.LogType,
+		Payload:   hex.EncodeToString(msg.Payload[:]),
+		Fetched:   fetched,
+	}
+}
+
+// Matches reports whether the log response has the same content as the entry
+func (e Entry) Matches(msg messages.LogResponseMessage) bool {
+	other := NewEntry(msg, e.Epoch, e.Fetched)
+	return e.Index == other.Index &&
+		e.Timestamp == other.Timestamp &&
+		e.LogType == other.LogType &&
+		e.Payload == other.Payload
+}
+
+// State tracks the progress of the archive against the device log
+type State struct {
+	Device string `json:"device"`
+	Epoch  int    `json:"epoch"`
+	// NextIndex is the next device log index to fetch in the current epoch
+	NextIndex uint16 `json:"next_index"`
+	// LastEntry is the most recently archived entry, used to detect resets
+	LastEntry *Entry    `json:"last_entry,omitempty"`
+	LastSync  time.Time `json:"last_sync"`
+}
+
+// Store is the local log archive of a single device
+type Store struct {
+	dir   string
+	State State
+}
+
+// DefaultDir returns the default archive location, ~/.camera-trigger
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".camera-trigger"), nil
+}
+
+// Open the archive for device under root, creating it if needed
+func Open(root string, device string) (*Store, error) {
+	if device == "" {
+		return nil, fmt.Errorf("device name required")
+	}
+
+	name := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(device)
+	s := &Store{
+		dir:   filepath.Join(root, name),
+		State: State{Device: device},
+	}
+
+	err := os.MkdirAll(s.dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := ioutil.ReadFile(filepath.Join(s.dir, stateFileName))
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(buf, &s.State)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", stateFileName, err)
+	}
+
+	return s, nil
+}
+
+// Dir returns the directory holding this archive
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// NewEpoch records that the device log was reset, subsequent entries are
+// fetched from index 0 again
+func (s *Store) NewEpoch() {
+	s.State.Epoch++
+	s.State.NextIndex = 0
+	s.State.LastEntry = nil
+}
+
+// Append archives consecutive log entries starting at State.NextIndex. Entries
+// which would leave a gap are rejected so the archive never skips an index.
+func (s *Store) Append(entries []messages.LogResponseMessage) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, logFileName),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	enc := json.NewEncoder(f)
+	for _, msg := range entries {
+		if msg.Index != s.State.NextIndex {
+			f.Close()
+			return fmt.Errorf("entry %d does not follow archived index %d",
+				msg.Index, s.State.NextIndex)
+		}
+
+		entry := NewEntry(msg, s.State.Epoch, now)
+		err = enc.Encode(entry)
+		if err != nil {
+			f.Close()
+			return err
+		}
+
+		s.State.LastEntry = &entry
+		s.State.NextIndex++
+	}
+
+	return f.Close()
+}
+
+// Save the archive state
+func (s *Store) Save() error {
+	s.State.LastSync = time.Now()
+
+	buf, err := json.MarshalIndent(s.State, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so an interrupted save cannot corrupt the state
+	path := filepath.Join(s.dir, stateFileName)
+	err = ioutil.WriteFile(path+".tmp", buf, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Entries returns every archived entry in the order it was fetched
+func (s *Store) Entries() ([]Entry, error) {
+	var entries []Entry
+
+	f, err := os.Open(filepath.Join(s.dir, logFileName))
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var entry Entry
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", logFileName, line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
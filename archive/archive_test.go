@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func logEntry(index uint16, seconds uint8) messages.LogResponseMessage {
+	return messages.LogResponseMessage{
+		Index:     index,
+		Timestamp: messages.Calendar{Seconds: seconds, DayOfMonth: 1, Month: 1, Year: 20},
+		LogType:   1,
+	}
+}
+
+func TestStoreAppend(t *testing.T) {
+	root, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	s, err := Open(root, "camera-trigger-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Append([]messages.LogResponseMessage{logEntry(0, 1), logEntry(1, 2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Append([]messages.LogResponseMessage{logEntry(3, 3)}); err == nil {
+		t.Errorf("Append() accepted entry leaving a gap")
+	}
+
+	if err = s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen and confirm state survives
+	s, err = Open(root, "camera-trigger-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State.NextIndex != 2 {
+		t.Errorf("NextIndex = %d, want 2", s.State.NextIndex)
+	}
+	if s.State.LastEntry == nil || !s.State.LastEntry.Matches(logEntry(1, 2)) {
+		t.Errorf("LastEntry = %+v, want index 1", s.State.LastEntry)
+	}
+	if s.State.LastEntry.Matches(logEntry(1, 5)) {
+		t.Errorf("LastEntry matched entry with a different timestamp")
+	}
+
+	s.NewEpoch()
+	err = s.Append([]messages.LogResponseMessage{logEntry(0, 9)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Entries() returned %d entries, want 3", len(entries))
+	}
+	if entries[2].Epoch != 1 || entries[2].Index != 0 {
+		t.Errorf("entry after reset = %+v, want epoch 1 index 0", entries[2])
+	}
+}
@@ -0,0 +1,81 @@
+package archive
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteCSV writes entries as csv with a header row
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"epoch", "index", "timestamp", "log_type", "payload", "fetched"})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		err = cw.Write([]string{
+			strconv.Itoa(e.Epoch),
+			strconv.Itoa(int(e.Index)),
+			e.Timestamp,
+			strconv.Itoa(int(e.LogType)),
+			e.Payload,
+			e.Fetched.Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes entries as an indented json array
+func WriteJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// WriteFile writes entries to path in the given format, csv or json. If
+// format is empty it is taken from the file extension.
+func WriteFile(path string, format string, entries []Entry) error {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	var write func(io.Writer, []Entry) error
+	switch strings.ToLower(format) {
+	case "csv":
+		write = WriteCSV
+	case "json":
+		write = WriteJSON
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = write(f, entries)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
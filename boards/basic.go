@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/phelpsw/camera-trigger-bt-cli/connection"
@@ -25,16 +27,31 @@ type Generic interface {
 }
 
 type Basic struct {
-	name              string
-	conn              *connection.Connection
-	observedType      interface{}
-	logCount          uint16
-	statusCallback    func(interface{}) error
-	getUint16Callback func(interface{}) error
-	setUint16Callback func(interface{}) error
-	getFloatCallback  func(interface{}) error
-	setFloatCallback  func(interface{}) error
-	logCallback       func(*Basic) error
+	name           string
+	conn           connection.Transport
+	connectTimeout time.Duration
+	counters       Counters
+	decoder        messages.Decoder
+
+	// mutex guards the fields below, which are written on the connection's
+	// callback goroutine and read by callers
+	mutex               sync.Mutex
+	observedType        interface{}
+	logCount            uint16
+	statusCount         uint32
+	statusTimestamp     messages.Calendar
+	statusTime          time.Time
+	lastStatus          interface{}
+	statusCallback      func(interface{}) error
+	getUint16Callback   func(interface{}) error
+	setUint16Callback   func(interface{}) error
+	getFloatCallback    func(interface{}) error
+	setFloatCallback    func(interface{}) error
+	logResponseCallback func(interface{}) error
+	messageCallback     func(interface{}) error
+	logCallback         func(*Basic) error
+	// handoff is the handler of the board which took over the connection
+	handoff func([]byte) error
 
 	logMessages []messages.LogResponseMessage
 }
@@ -48,97 +65,167 @@ func floatEquals(a, b float32) bool {
 	return false
 }
 
+// NewTransport creates the transport of boards which have not been given
+// one with SetTransport. It is replaced to replay captured traffic.
+var NewTransport = func() connection.Transport {
+	return &connection.Connection{}
+}
+
 func (m *Basic) Scan() (map[string]connection.Device, error) {
-	if m.conn == nil {
-		m.conn = &connection.Connection{}
-	}
-	_, err := m.conn.Scan(1 * time.Second)
+	var conn connection.Connection
+	_, err := conn.Scan(1 * time.Second)
 	time.Sleep(5 * time.Second)
-	m.conn.StopScan()
-	return m.conn.ListDevices(), err
+	conn.StopScan()
+	return conn.ListDevices(), err
+}
+
+// SetTransport sets the transport used by Init in place of NewTransport
+func (m *Basic) SetTransport(t connection.Transport) {
+	m.conn = t
+	m.SetConnectTimeout(m.connectTimeout)
 }
 
 func (m *Basic) Init(name string, debug bool) error {
 	m.name = name
+	m.decoder = messages.Decoder{}
+	m.mutex.Lock()
 	m.observedType = nil
+	m.statusCount = 0
+	m.lastStatus = nil
+	m.handoff = nil
+	m.mutex.Unlock()
 
 	if m.conn == nil {
-		m.conn = &connection.Connection{}
+		m.SetTransport(NewTransport())
 	}
 	err := m.conn.Init(name, m.handleBytes, debug)
 	if err != nil {
 		return err
 	}
+	atomic.AddUint64(&m.counters.Connects, 1)
 
 	return nil
 }
 
 func (m *Basic) GetType() interface{} {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 	return m.observedType
 }
 
-func (m *Basic) GetConnection() *connection.Connection {
+func (m *Basic) GetConnection() connection.Transport {
 	return m.conn
 }
 
+// handOff passes the connection to handler, which continues with the
+// messages buffered by the board
+func (m *Basic) handOff(handler func([]byte) error) {
+	m.mutex.Lock()
+	m.handoff = handler
+	m.mutex.Unlock()
+	m.conn.Callback(handler)
+}
+
+// handleBytes decodes every message completed by a chunk received from the
+// device. Once a motion or light board has taken over the connection the
+// rest of the chunk is left to it.
 func (m *Basic) handleBytes(b []byte) error {
-	msg, err := messages.ReadMessage(b)
-	if err != nil {
-		return err
-	}
+	var first error
+	for {
+		msg, err := m.decoder.ReadMessage(b)
+		b = nil
+		if err != nil {
+			atomic.AddUint64(&m.counters.ParseErrors, 1)
+			return err
+		}
 
-	// A full message was not found
-	if msg == nil {
-		return nil
+		// A full message was not found
+		if msg == nil {
+			return first
+		}
+		atomic.AddUint64(&m.counters.Frames, 1)
+
+		err = m.handleMessage(msg)
+		if err != nil && first == nil {
+			first = err
+		}
+
+		m.mutex.Lock()
+		handoff := m.handoff
+		m.mutex.Unlock()
+		if handoff != nil {
+			err = handoff(nil)
+			if first == nil {
+				first = err
+			}
+			return first
+		}
 	}
+}
+
+func (m *Basic) handleMessage(msg interface{}) error {
+	var err error
+
+	// Callbacks are called without the mutex held so they can use the board
+	m.mutex.Lock()
+	messageCallback := m.messageCallback
+	statusCallback := m.statusCallback
+	var callbacks []func(interface{}) error
 
 	switch msg.(type) {
 	case messages.MotionSensorStatusMessage:
 		m.observedType = reflect.TypeOf(Motion{})
+		m.logCount = msg.(messages.MotionSensorStatusMessage).LogEntries
+		m.statusTimestamp = msg.(messages.MotionSensorStatusMessage).Timestamp
+		m.statusTime = time.Now()
+		m.lastStatus = msg
+		m.statusCount++
 	case messages.LightStatusMessage:
 		m.observedType = reflect.TypeOf(Light{})
+		m.logCount = msg.(messages.LightStatusMessage).Payload.LogEntries
+		m.statusTimestamp = msg.(messages.LightStatusMessage).Timestamp
+		m.statusTime = time.Now()
+		m.lastStatus = msg
+		m.statusCount++
 	case messages.LogResponseMessage:
-		fmt.Printf("%+v\n", msg.(messages.LogResponseMessage))
 		m.logMessages = append(m.logMessages, msg.(messages.LogResponseMessage))
-
+		callbacks = append(callbacks, m.logResponseCallback)
 		if m.logCallback != nil {
-			err = m.logCallback(m)
-			if err != nil {
-				return err
-			}
+			logCallback := m.logCallback
+			callbacks = append(callbacks, func(interface{}) error {
+				return logCallback(m)
+			})
 		}
 	case messages.GetUint16Response:
-		if m.getUint16Callback != nil {
-			err = m.getUint16Callback(msg)
-			if err != nil {
-				return err
-			}
-		}
+		callbacks = append(callbacks, m.getUint16Callback)
 	case messages.SetUint16Response:
-		if m.setUint16Callback != nil {
-			err = m.setUint16Callback(msg)
-			if err != nil {
-				return err
-			}
-		}
+		callbacks = append(callbacks, m.setUint16Callback)
 	case messages.GetFloatResponse:
-		if m.getFloatCallback != nil {
-			err = m.getFloatCallback(msg)
-			if err != nil {
-				return err
-			}
-		}
+		callbacks = append(callbacks, m.getFloatCallback)
 	case messages.SetFloatResponse:
-		if m.setFloatCallback != nil {
-			err = m.setFloatCallback(msg)
-			if err != nil {
-				return err
-			}
+		callbacks = append(callbacks, m.setFloatCallback)
+	}
+	m.mutex.Unlock()
+
+	if messageCallback != nil {
+		err = messageCallback(msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, callback := range callbacks {
+		if callback == nil {
+			continue
+		}
+		err = callback(msg)
+		if err != nil {
+			return err
 		}
 	}
 
-	if m.statusCallback != nil {
-		err = m.statusCallback(m)
+	if statusCallback != nil {
+		err = statusCallback(m)
 		if err != nil {
 			return err
 		}
@@ -148,15 +235,103 @@ func (m *Basic) handleBytes(b []byte) error {
 }
 
 func (m *Basic) IsConnected() bool {
-	return m.conn.IsConnected()
+	return m.conn != nil && m.conn.IsConnected()
+}
+
+// SetConnectTimeout limits how long Init waits for the device to be found,
+// zero waits indefinitely
+func (m *Basic) SetConnectTimeout(timeout time.Duration) {
+	m.connectTimeout = timeout
+	if conn, ok := m.conn.(connection.Searcher); ok {
+		conn.SetConnectTimeout(timeout)
+	}
+}
+
+// Close disconnects from the device so another can be connected
+func (m *Basic) Close() error {
+	return closeTransport(m.conn)
+}
+
+// closeTransport stops conn and waits for it to disconnect
+func closeTransport(conn connection.Transport) error {
+	if conn == nil || !conn.IsConnected() {
+		return nil
+	}
+
+	conn.Stop()
+
+	attempts := 0
+	for conn.IsConnected() {
+		attempts++
+		if attempts > 500 {
+			return fmt.Errorf("Close: timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return nil
 }
 
+// LogEntries returns the log entry count reported by the most recent status
+// message. It is only meaningful once a status message has been received.
 func (m *Basic) LogEntries() uint16 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 	return m.logCount
 }
 
+// Timestamp returns the device clock reported by the most recent status
+// message
+func (m *Basic) Timestamp() messages.Calendar {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.statusTimestamp
+}
+
+// Status returns the most recent status message, a
+// messages.MotionSensorStatusMessage or messages.LightStatusMessage, or nil
+// if none has been received
+func (m *Basic) Status() interface{} {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.lastStatus
+}
+
+// WaitForStatus blocks until a status message has been received from the
+// device or the timeout expires.
+func (m *Basic) WaitForStatus(timeout time.Duration) error {
+	return m.waitForStatusCount(1, timeout)
+}
+
+// WaitForNextStatus blocks until a status message newer than any received so
+// far arrives or the timeout expires.
+func (m *Basic) WaitForNextStatus(timeout time.Duration) error {
+	return m.waitForStatusCount(m.StatusCount()+1, timeout)
+}
+
+// StatusCount returns the number of status messages received since Init
+func (m *Basic) StatusCount() uint32 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.statusCount
+}
+
+func (m *Basic) waitForStatusCount(count uint32, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for m.StatusCount() < count {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("WaitForStatus: timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return nil
+}
+
 func (m *Basic) Log() []messages.LogResponseMessage {
-	return m.logMessages
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]messages.LogResponseMessage(nil), m.logMessages...)
 }
 
 func (m *Basic) GetLog(index uint16) error {
@@ -219,175 +394,119 @@ func (m *Basic) SetTime(cal messages.Calendar) error {
 	return nil
 }
 
-func (m *Basic) SetUpdateCallback(callback func(interface{}) error) {
-	m.statusCallback = callback
-}
-
-func (m *Basic) SetLogCallback(callback func(*Basic) error) {
-	m.logCallback = callback
-}
-
-func (m *Basic) GetUint16(id uint16, persist uint8) (messages.GetUint16Response, error) {
-	var message messages.GetUint16Response
-	msg := messages.NewGetUint16Request(id, persist)
-
+// Send writes a message to the device without waiting for a response
+func (m *Basic) Send(msg messages.Message) error {
 	if !m.conn.IsConnected() {
-		return message, fmt.Errorf("not connected")
+		return fmt.Errorf("not connected")
 	}
 
 	buf, err := messages.WriteMessage(msg)
 	if err != nil {
-		return message, err
-	}
-
-	response := false
-	m.getUint16Callback = func(b interface{}) error {
-		message = b.(messages.GetUint16Response)
-		response = true
-		return nil
-	}
-
-	err = m.conn.WriteBytes(buf)
-	if err != nil {
-		m.getUint16Callback = nil
-		return message, err
+		return err
 	}
 
-	attempts := 0
-	for !response {
-		attempts++
-		if attempts > 500 {
-			m.getUint16Callback = nil
-			return message, fmt.Errorf("GetUint16: timeout")
-		}
-		time.Sleep(10 * time.Millisecond)
-	}
+	return m.conn.WriteBytes(buf)
+}
 
-	m.getUint16Callback = nil
-	return message, nil
+func (m *Basic) SetUpdateCallback(callback func(interface{}) error) {
+	m.mutex.Lock()
+	m.statusCallback = callback
+	m.mutex.Unlock()
 }
 
-func (m *Basic) SetUint16(id uint16, persist uint8, value uint16) (messages.SetUint16Response, error) {
+// SetMessageCallback sets a callback which receives every message decoded
+// from the device before it is handled
+func (m *Basic) SetMessageCallback(callback func(interface{}) error) {
+	m.mutex.Lock()
+	m.messageCallback = callback
+	m.mutex.Unlock()
+}
 
-	var message messages.SetUint16Response
-	msg := messages.NewSetUint16Request(id, persist, value)
+func (m *Basic) SetLogCallback(callback func(*Basic) error) {
+	m.mutex.Lock()
+	m.logCallback = callback
+	m.mutex.Unlock()
+}
 
+// request writes msg and waits for the response passed to the callback held
+// in slot, counting a timeout in timeouts
+func (m *Basic) request(name string, msg messages.Message, slot *func(interface{}) error,
+	timeouts *uint64) (interface{}, error) {
 	if !m.conn.IsConnected() {
-		return message, fmt.Errorf("not connected")
+		return nil, fmt.Errorf("not connected")
 	}
 
 	buf, err := messages.WriteMessage(msg)
 	if err != nil {
-		return message, err
+		return nil, err
 	}
 
-	response := false
-	m.setUint16Callback = func(b interface{}) error {
-		message = b.(messages.SetUint16Response)
-		response = true
+	responses := make(chan interface{}, 1)
+	m.mutex.Lock()
+	*slot = func(b interface{}) error {
+		select {
+		case responses <- b:
+		default:
+		}
 		return nil
 	}
+	m.mutex.Unlock()
+	defer func() {
+		m.mutex.Lock()
+		*slot = nil
+		m.mutex.Unlock()
+	}()
 
 	err = m.conn.WriteBytes(buf)
 	if err != nil {
-		m.setUint16Callback = nil
-		return message, err
+		return nil, err
 	}
 
-	attempts := 0
-	for !response {
-		attempts++
-		if attempts > 500 {
-			m.setUint16Callback = nil
-			return message, fmt.Errorf("SetUint16: timeout")
-		}
-		time.Sleep(10 * time.Millisecond)
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	select {
+	case response := <-responses:
+		return response, nil
+	case <-timer.C:
+		atomic.AddUint64(timeouts, 1)
+		return nil, fmt.Errorf("%s: timeout", name)
 	}
-
-	m.setUint16Callback = nil
-	return message, nil
 }
 
-func (m *Basic) GetFloat(id uint16, persist uint8) (messages.GetFloatResponse, error) {
-
-	var message messages.GetFloatResponse
-	msg := messages.NewGetFloatRequest(id, persist)
-
-	if !m.conn.IsConnected() {
-		return message, fmt.Errorf("not connected")
-	}
-
-	buf, err := messages.WriteMessage(msg)
+func (m *Basic) GetUint16(id uint16, persist uint8) (messages.GetUint16Response, error) {
+	response, err := m.request("GetUint16", messages.NewGetUint16Request(id, persist),
+		&m.getUint16Callback, &m.counters.GetUint16Timeouts)
 	if err != nil {
-		return message, err
-	}
-
-	response := false
-	m.getFloatCallback = func(b interface{}) error {
-		message = b.(messages.GetFloatResponse)
-		response = true
-		return nil
+		return messages.GetUint16Response{}, err
 	}
+	return response.(messages.GetUint16Response), nil
+}
 
-	err = m.conn.WriteBytes(buf)
+func (m *Basic) SetUint16(id uint16, persist uint8, value uint16) (messages.SetUint16Response, error) {
+	response, err := m.request("SetUint16", messages.NewSetUint16Request(id, persist, value),
+		&m.setUint16Callback, &m.counters.SetUint16Timeouts)
 	if err != nil {
-		m.getFloatCallback = nil
-		return message, err
+		return messages.SetUint16Response{}, err
 	}
-
-	attempts := 0
-	for !response {
-		attempts++
-		if attempts > 500 {
-			m.getFloatCallback = nil
-			return message, fmt.Errorf("GetFloat: timeout")
-		}
-		time.Sleep(10 * time.Millisecond)
-	}
-
-	m.getFloatCallback = nil
-	return message, nil
+	return response.(messages.SetUint16Response), nil
 }
 
-func (m *Basic) SetFloat(id uint16, persist uint8, value float32) (messages.SetFloatResponse, error) {
-
-	var message messages.SetFloatResponse
-	msg := messages.NewSetFloatRequest(id, persist, value)
-
-	if !m.conn.IsConnected() {
-		return message, fmt.Errorf("not connected")
-	}
-
-	buf, err := messages.WriteMessage(msg)
+func (m *Basic) GetFloat(id uint16, persist uint8) (messages.GetFloatResponse, error) {
+	response, err := m.request("GetFloat", messages.NewGetFloatRequest(id, persist),
+		&m.getFloatCallback, &m.counters.GetFloatTimeouts)
 	if err != nil {
-		return message, err
-	}
-
-	response := false
-	m.setFloatCallback = func(b interface{}) error {
-		message = b.(messages.SetFloatResponse)
-		response = true
-		return nil
+		return messages.GetFloatResponse{}, err
 	}
+	return response.(messages.GetFloatResponse), nil
+}
 
-	err = m.conn.WriteBytes(buf)
+func (m *Basic) SetFloat(id uint16, persist uint8, value float32) (messages.SetFloatResponse, error) {
+	response, err := m.request("SetFloat", messages.NewSetFloatRequest(id, persist, value),
+		&m.setFloatCallback, &m.counters.SetFloatTimeouts)
 	if err != nil {
-		m.setFloatCallback = nil
-		return message, err
-	}
-
-	attempts := 0
-	for !response {
-		attempts++
-		if attempts > 500 {
-			m.setFloatCallback = nil
-			return message, fmt.Errorf("SetFloat: timeout")
-		}
-		time.Sleep(10 * time.Millisecond)
+		return messages.SetFloatResponse{}, err
 	}
-
-	m.setFloatCallback = nil
-	return message, nil
+	return response.(messages.SetFloatResponse), nil
 }
 
 func (m *Basic) Trigger(lux float32) error {
@@ -0,0 +1,104 @@
+package boards
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func motionStatus(motion float32) messages.MotionSensorStatusMessage {
+	return messages.MotionSensorStatusMessage{
+		Type:   0x11,
+		Length: uint8(binary.Size(messages.MotionSensorStatusMessage{})),
+		Motion: motion,
+	}
+}
+
+// chunk joins the frames of msgs as one notification from the device
+func chunk(t *testing.T, msgs ...interface{}) []byte {
+	var b bytes.Buffer
+	for _, msg := range msgs {
+		buf, err := messages.WriteMessage(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(buf.Bytes())
+	}
+	return b.Bytes()
+}
+
+func TestTwoFramesInOneChunk(t *testing.T) {
+	fake := &connection.Fake{}
+	var m Basic
+	m.SetTransport(fake)
+	err := m.Init("camera", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	fake.Receive(chunk(t, motionStatus(0.1), motionStatus(0.2)))
+
+	err = m.WaitForStatus(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both frames are handled as the chunk arrives, not when the next does
+	deadline := time.Now().Add(time.Second)
+	for m.StatusCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if m.StatusCount() != 2 {
+		t.Fatalf("StatusCount() = %d, want 2", m.StatusCount())
+	}
+	if got := m.Status().(messages.MotionSensorStatusMessage).Motion; got != 0.2 {
+		t.Errorf("last status motion %v, want 0.2", got)
+	}
+	if c := m.Counters(); c.Frames != 2 {
+		t.Errorf("Frames = %d, want 2", c.Frames)
+	}
+}
+
+func TestHandOffWithinChunk(t *testing.T) {
+	fake := &connection.Fake{}
+	var m Basic
+	m.SetTransport(fake)
+	err := m.Init("camera", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// As monitor does, the motion board takes over on the first status and
+	// must see the second frame of the same chunk
+	var motion Motion
+	updates := make(chan float32, 2)
+	m.SetUpdateCallback(func(b interface{}) error {
+		if b, ok := b.(*Basic); ok && b.GetType() != nil {
+			motion.InitFromBasic(b)
+			motion.SetUpdateCallback(func(b interface{}) error {
+				updates <- b.(*Motion).Motion()
+				return nil
+			})
+		}
+		return nil
+	})
+
+	fake.Receive(chunk(t, motionStatus(0.1), motionStatus(0.2)))
+
+	select {
+	case got := <-updates:
+		if got != 0.2 {
+			t.Errorf("motion board got %v, want 0.2", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second frame not passed to the motion board")
+	}
+	if m.StatusCount() != 1 {
+		t.Errorf("basic board handled %d statuses, want 1", m.StatusCount())
+	}
+}
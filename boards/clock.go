@@ -0,0 +1,67 @@
+package boards
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+// ClockOffset returns how far the device clock, interpreted in loc, was ahead
+// of the host clock when the most recent status message was sent. latency is
+// the one way delay from device to host, see MeasureLatency. The device clock
+// counts whole seconds so the result is only accurate to +/- 0.5s.
+func (m *Basic) ClockOffset(loc *time.Location, latency time.Duration) (time.Duration, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.statusCount == 0 {
+		return 0, fmt.Errorf("no status received")
+	}
+	if !m.statusTimestamp.IsSet() {
+		return 0, fmt.Errorf("device clock not set")
+	}
+
+	// The device reports the start of the current second, on average the
+	// true device time is half a second later
+	device := m.statusTimestamp.Time(loc).Add(500 * time.Millisecond)
+	sent := m.statusTime.Add(-latency)
+	return device.Sub(sent), nil
+}
+
+// MeasureLatency estimates the one way delay to the device as half of the
+// shortest round trip of several parameter requests
+func (m *Basic) MeasureLatency(samples int) (time.Duration, error) {
+	var best time.Duration
+
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+
+		// Parameter 0 of the non-persistent uint16 table is version_major,
+		// which every firmware answers
+		_, err := m.GetUint16(0, 0)
+		if err != nil {
+			return 0, err
+		}
+
+		rtt := time.Since(start)
+		if i == 0 || rtt < best {
+			best = rtt
+		}
+	}
+
+	return best / 2, nil
+}
+
+// SyncTime sets the device clock to the host clock in loc. The message is
+// held back until it will arrive at the device on a whole second boundary,
+// given the one way latency, so the device clock starts the second on time.
+func (m *Basic) SyncTime(loc *time.Location, latency time.Duration) (messages.Calendar, error) {
+	var cal messages.Calendar
+
+	arrival := time.Now().Add(latency).Truncate(time.Second).Add(time.Second)
+	time.Sleep(time.Until(arrival.Add(-latency)))
+
+	cal.FromTime(arrival.In(loc))
+	return cal, m.SetTime(cal)
+}
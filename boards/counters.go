@@ -0,0 +1,48 @@
+package boards
+
+import "sync/atomic"
+
+// Counters tallies traffic and failures on a board's connection
+type Counters struct {
+	// Frames is the number of messages decoded
+	Frames uint64
+	// ParseErrors is the number of errors returned while decoding
+	ParseErrors uint64
+
+	GetFloatTimeouts  uint64
+	SetFloatTimeouts  uint64
+	GetUint16Timeouts uint64
+	SetUint16Timeouts uint64
+
+	// Connects is the number of successful calls to Init
+	Connects uint64
+}
+
+// Reconnects is the number of connections after the first
+func (c Counters) Reconnects() uint64 {
+	if c.Connects == 0 {
+		return 0
+	}
+	return c.Connects - 1
+}
+
+// Counters returns a snapshot of the board's counters
+func (m *Basic) Counters() Counters {
+	return Counters{
+		Frames:            atomic.LoadUint64(&m.counters.Frames),
+		ParseErrors:       atomic.LoadUint64(&m.counters.ParseErrors),
+		GetFloatTimeouts:  atomic.LoadUint64(&m.counters.GetFloatTimeouts),
+		SetFloatTimeouts:  atomic.LoadUint64(&m.counters.SetFloatTimeouts),
+		GetUint16Timeouts: atomic.LoadUint64(&m.counters.GetUint16Timeouts),
+		SetUint16Timeouts: atomic.LoadUint64(&m.counters.SetUint16Timeouts),
+		Connects:          atomic.LoadUint64(&m.counters.Connects),
+	}
+}
+
+// RSSI reads the signal strength of the connection in dBm
+func (m *Basic) RSSI() int {
+	if m.conn == nil {
+		return 0
+	}
+	return m.conn.RSSI()
+}
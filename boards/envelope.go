@@ -0,0 +1,63 @@
+package boards
+
+// Envelope is the brightness curve a light controller follows after a
+// trigger. It waits Delay seconds, ramps up to Level over Attack seconds,
+// holds for Sustain seconds then ramps down to off over Release seconds.
+type Envelope struct {
+	Level   float32
+	Delay   float32
+	Attack  float32
+	Sustain float32
+	Release float32
+}
+
+// Envelope returns the envelope configured on the light controller
+func (m *Light) Envelope() Envelope {
+	return Envelope{
+		Level:   m.Level(),
+		Delay:   m.Delay(),
+		Attack:  m.Attack(),
+		Sustain: m.Sustain(),
+		Release: m.Release(),
+	}
+}
+
+// Brightness returns the light level t seconds after the trigger
+func (e Envelope) Brightness(t float32) float32 {
+	if t < e.Delay {
+		return 0
+	}
+	t -= e.Delay
+
+	if t < e.Attack {
+		return e.Level * t / e.Attack
+	}
+	t -= e.Attack
+
+	if t < e.Sustain {
+		return e.Level
+	}
+	t -= e.Sustain
+
+	if t < e.Release {
+		return e.Level * (1 - t/e.Release)
+	}
+
+	return 0
+}
+
+// Duration returns the seconds from trigger until the light is off again
+func (e Envelope) Duration() float32 {
+	return e.Delay + e.Attack + e.Sustain + e.Release
+}
+
+// OnTime returns the seconds the light is lit for each trigger
+func (e Envelope) OnTime() float32 {
+	return e.Attack + e.Sustain + e.Release
+}
+
+// Energy returns the light output of each trigger in seconds at full
+// brightness, which battery drain is roughly proportional to
+func (e Envelope) Energy() float32 {
+	return e.Level * (e.Attack/2 + e.Sustain + e.Release/2)
+}
@@ -0,0 +1,36 @@
+package boards
+
+import "testing"
+
+func TestEnvelope(t *testing.T) {
+	e := Envelope{Level: 0.8, Delay: 1, Attack: 2, Sustain: 10, Release: 4}
+
+	tests := []struct {
+		t    float32
+		want float32
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 0.4},
+		{3, 0.8},
+		{12.9, 0.8},
+		{15, 0.4},
+		{17, 0},
+		{20, 0},
+	}
+	for _, tt := range tests {
+		if got := e.Brightness(tt.t); !floatEquals(got, tt.want) {
+			t.Errorf("Brightness(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+
+	if got := e.Duration(); got != 17 {
+		t.Errorf("Duration() = %v, want 17", got)
+	}
+	if got := e.OnTime(); got != 16 {
+		t.Errorf("OnTime() = %v, want 16", got)
+	}
+	if got := e.Energy(); !floatEquals(got, 10.4) {
+		t.Errorf("Energy() = %v, want 10.4", got)
+	}
+}
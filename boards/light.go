@@ -2,31 +2,38 @@ package boards
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/phelpsw/camera-trigger-bt-cli/connection"
 	"github.com/phelpsw/camera-trigger-bt-cli/messages"
 )
 
 type Light struct {
-	name     string
-	conn     *connection.Connection
+	name    string
+	conn    connection.Transport
+	decoder *messages.Decoder
+
+	// mutex guards the fields below, which are written on the connection's
+	// callback goroutine and read by callers
+	mutex    sync.Mutex
 	last     messages.LightStatus
+	lastTime messages.Calendar
 	desired  messages.LightStatus
 	callback func(interface{}) error
-}
 
-var (
-	levelPending   bool = false
-	delayPending   bool = false
-	attackPending  bool = false
-	sustainPending bool = false
-	releasePending bool = false
-)
+	// Settings changed which the device has not yet reported
+	levelPending   bool
+	delayPending   bool
+	attackPending  bool
+	sustainPending bool
+	releasePending bool
+}
 
 func (m *Light) Init(name string, debug bool) error {
 	m.name = name
 
-	m.conn = &connection.Connection{}
+	m.conn = NewTransport()
+	m.decoder = &messages.Decoder{}
 	err := m.conn.Init(name, m.handleBytes, debug)
 	if err != nil {
 		return err
@@ -37,48 +44,70 @@ func (m *Light) Init(name string, debug bool) error {
 
 func (m *Light) InitFromBasic(b *Basic) error {
 	m.conn = b.GetConnection()
-	m.conn.Callback(m.handleBytes)
+	// Continue with any partial message the basic board has buffered
+	m.decoder = &b.decoder
+	b.handOff(m.handleBytes)
 
 	return nil
 }
 
 func (m *Light) handleBytes(b []byte) error {
-	msg, err := messages.ReadMessage(b)
-	if err != nil {
-		return err
-	}
+	// A chunk may complete several messages
+	var first error
+	for {
+		msg, err := m.decoder.ReadMessage(b)
+		b = nil
+		if err != nil {
+			return err
+		}
 
-	// A full message was not found
-	if msg == nil {
-		return nil
+		// A full message was not found
+		if msg == nil {
+			return first
+		}
+
+		err = m.handleMessage(msg)
+		if err != nil && first == nil {
+			first = err
+		}
 	}
+}
 
+func (m *Light) handleMessage(msg interface{}) error {
+	var err error
+
+	m.mutex.Lock()
 	switch msg.(type) {
 	case messages.LightStatusMessage:
 		m.last = msg.(messages.LightStatusMessage).Payload
+		m.lastTime = msg.(messages.LightStatusMessage).Timestamp
 	default:
+		m.mutex.Unlock()
 		fmt.Println("Unknown")
 		return fmt.Errorf("unexpected message type %+v", msg)
 	}
 
-	if levelPending && floatEquals(m.last.Level, m.desired.Level) {
-		levelPending = false
+	if m.levelPending && floatEquals(m.last.Level, m.desired.Level) {
+		m.levelPending = false
 	}
-	if delayPending && floatEquals(m.last.Delay, m.desired.Delay) {
-		delayPending = false
+	if m.delayPending && floatEquals(m.last.Delay, m.desired.Delay) {
+		m.delayPending = false
 	}
-	if attackPending && floatEquals(m.last.Attack, m.desired.Attack) {
-		attackPending = false
+	if m.attackPending && floatEquals(m.last.Attack, m.desired.Attack) {
+		m.attackPending = false
 	}
-	if sustainPending && floatEquals(m.last.Sustain, m.desired.Sustain) {
-		sustainPending = false
+	if m.sustainPending && floatEquals(m.last.Sustain, m.desired.Sustain) {
+		m.sustainPending = false
 	}
-	if releasePending && floatEquals(m.last.Release, m.desired.Release) {
-		releasePending = false
+	if m.releasePending && floatEquals(m.last.Release, m.desired.Release) {
+		m.releasePending = false
 	}
 
-	if m.callback != nil {
-		err = m.callback(m)
+	callback := m.callback
+	m.mutex.Unlock()
+
+	if callback != nil {
+		err = callback(m)
 		if err != nil {
 			return err
 		}
@@ -87,50 +116,80 @@ func (m *Light) handleBytes(b []byte) error {
 	return nil
 }
 
+// Close disconnects from the device
+func (m *Light) Close() error {
+	return closeTransport(m.conn)
+}
+
 func (m *Light) SetUpdateCallback(callback func(interface{}) error) {
+	m.mutex.Lock()
 	m.callback = callback
+	m.mutex.Unlock()
+}
+
+// status returns the payload of the most recent status message
+func (m *Light) status() messages.LightStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.last
+}
+
+func (m *Light) Timestamp() messages.Calendar {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.lastTime
 }
 
 func (m *Light) Temperature() float32 {
-	return m.last.Temperature
+	return m.status().Temperature
 }
 
 func (m *Light) Voltage() float32 {
-	return m.last.Voltage
+	return m.status().Voltage
 }
 
 func (m *Light) Level() float32 {
-	return m.last.Level
+	return m.status().Level
 }
 
 func (m *Light) Delay() float32 {
-	return m.last.Delay
+	return m.status().Delay
 }
 
 func (m *Light) Attack() float32 {
-	return m.last.Attack
+	return m.status().Attack
 }
 
 func (m *Light) Sustain() float32 {
-	return m.last.Sustain
+	return m.status().Sustain
 }
 
 func (m *Light) Release() float32 {
-	return m.last.Release
+	return m.status().Release
+}
+
+func (m *Light) LightTemperature() float32 {
+	return m.status().LightTemperature
+}
+
+func (m *Light) Current() float32 {
+	return m.status().Current
 }
 
 // TODO: Enumerate this properly
 func (m *Light) LedModes() uint8 {
-	return m.last.LedModes
+	return m.status().LedModes
 }
 
 func (m *Light) LogEntries() uint16 {
-	return m.last.LogEntries
+	return m.status().LogEntries
 }
 
 func (m *Light) SetLevel(val float32, sync bool) error {
+	m.mutex.Lock()
 	m.desired.Level = val
-	levelPending = true
+	m.levelPending = true
+	m.mutex.Unlock()
 
 	if sync {
 		m.Sync()
@@ -140,8 +199,10 @@ func (m *Light) SetLevel(val float32, sync bool) error {
 }
 
 func (m *Light) SetDelay(val float32, sync bool) error {
+	m.mutex.Lock()
 	m.desired.Delay = val
-	delayPending = true
+	m.delayPending = true
+	m.mutex.Unlock()
 
 	if sync {
 		m.Sync()
@@ -151,8 +212,10 @@ func (m *Light) SetDelay(val float32, sync bool) error {
 }
 
 func (m *Light) SetAttack(val float32, sync bool) error {
+	m.mutex.Lock()
 	m.desired.Attack = val
-	attackPending = true
+	m.attackPending = true
+	m.mutex.Unlock()
 
 	if sync {
 		m.Sync()
@@ -162,8 +225,10 @@ func (m *Light) SetAttack(val float32, sync bool) error {
 }
 
 func (m *Light) SetSustain(val float32, sync bool) error {
+	m.mutex.Lock()
 	m.desired.Sustain = val
-	sustainPending = true
+	m.sustainPending = true
+	m.mutex.Unlock()
 
 	if sync {
 		m.Sync()
@@ -173,8 +238,10 @@ func (m *Light) SetSustain(val float32, sync bool) error {
 }
 
 func (m *Light) SetRelease(val float32, sync bool) error {
+	m.mutex.Lock()
 	m.desired.Release = val
-	releasePending = true
+	m.releasePending = true
+	m.mutex.Unlock()
 
 	if sync {
 		m.Sync()
@@ -184,38 +251,46 @@ func (m *Light) SetRelease(val float32, sync bool) error {
 }
 
 func (m *Light) IsSynced() bool {
-	if !levelPending &&
-		!delayPending &&
-		!attackPending &&
-		!sustainPending &&
-		!releasePending {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.synced()
+}
+
+func (m *Light) synced() bool {
+	if !m.levelPending &&
+		!m.delayPending &&
+		!m.attackPending &&
+		!m.sustainPending &&
+		!m.releasePending {
 		return true
 	}
 	return false
 }
 
 func (m *Light) Sync() error {
-	if m.IsSynced() {
+	m.mutex.Lock()
+	if m.synced() {
+		m.mutex.Unlock()
 		return nil
 	}
 
-	if !levelPending {
+	if !m.levelPending {
 		m.desired.Level = m.last.Level
 	}
 
-	if !delayPending {
+	if !m.delayPending {
 		m.desired.Delay = m.last.Delay
 	}
 
-	if !attackPending {
+	if !m.attackPending {
 		m.desired.Attack = m.last.Attack
 	}
 
-	if !sustainPending {
+	if !m.sustainPending {
 		m.desired.Sustain = m.last.Sustain
 	}
 
-	if !releasePending {
+	if !m.releasePending {
 		m.desired.Release = m.last.Release
 	}
 
@@ -224,6 +299,7 @@ func (m *Light) Sync() error {
 		m.desired.Attack,
 		m.desired.Sustain,
 		m.desired.Release)
+	m.mutex.Unlock()
 
 	if !m.conn.IsConnected() {
 		return fmt.Errorf("not connected")
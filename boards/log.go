@@ -0,0 +1,114 @@
+package boards
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+// LogDownload is the result of fetching a range of log entries
+type LogDownload struct {
+	// Entries holds every log entry received, ordered by index
+	Entries []messages.LogResponseMessage
+	// Missing lists indices which were never received despite retries
+	Missing []uint16
+	// Duplicates lists indices which were received more than once
+	Duplicates []uint16
+}
+
+// DownloadLog fetches log entries [start, end) one index at a time. Each
+// request waits up to timeout for a response carrying the matching index and
+// is retried up to retries times before the index is recorded as missing.
+// Responses for other indices in the range are kept rather than re-requested.
+// If progress is non-nil it is called as each index is resolved.
+func (m *Basic) DownloadLog(start, end uint16, timeout time.Duration, retries int,
+	progress func(index uint16, entry *messages.LogResponseMessage)) (LogDownload, error) {
+	var result LogDownload
+
+	if !m.conn.IsConnected() {
+		return result, fmt.Errorf("not connected")
+	}
+
+	responses := make(chan messages.LogResponseMessage, 32)
+	m.mutex.Lock()
+	m.logResponseCallback = func(b interface{}) error {
+		select {
+		case responses <- b.(messages.LogResponseMessage):
+		default:
+			return fmt.Errorf("log response dropped, index %d",
+				b.(messages.LogResponseMessage).Index)
+		}
+		return nil
+	}
+	m.mutex.Unlock()
+	defer func() {
+		m.mutex.Lock()
+		m.logResponseCallback = nil
+		m.mutex.Unlock()
+	}()
+
+	received := make(map[uint16]messages.LogResponseMessage)
+
+	accept := func(msg messages.LogResponseMessage) {
+		if _, ok := received[msg.Index]; ok {
+			result.Duplicates = append(result.Duplicates, msg.Index)
+			return
+		}
+		if msg.Index < start || msg.Index >= end {
+			return
+		}
+		received[msg.Index] = msg
+	}
+
+	for index := start; index < end; index++ {
+		for attempt := 0; attempt <= retries; attempt++ {
+			if _, ok := received[index]; ok {
+				break
+			}
+
+			err := m.GetLog(index)
+			if err != nil {
+				return result, err
+			}
+
+			timer := time.NewTimer(timeout)
+		wait:
+			for {
+				select {
+				case msg := <-responses:
+					accept(msg)
+					if msg.Index == index {
+						break wait
+					}
+				case <-timer.C:
+					break wait
+				}
+			}
+			timer.Stop()
+		}
+
+		if progress != nil {
+			if entry, ok := received[index]; ok {
+				progress(index, &entry)
+			} else {
+				progress(index, nil)
+			}
+		}
+	}
+
+	// Late responses may still be queued
+	for len(responses) > 0 {
+		accept(<-responses)
+	}
+
+	for index := start; index < end; index++ {
+		if entry, ok := received[index]; ok {
+			result.Entries = append(result.Entries, entry)
+		} else {
+			result.Missing = append(result.Missing, index)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,157 @@
+package boards
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func logResponse(index uint16) messages.LogResponseMessage {
+	info, _ := messages.TypeByName("LogResponse")
+	return messages.LogResponseMessage{
+		Type:    info.Code,
+		Length:  uint8(info.Size()),
+		Index:   index,
+		LogType: 1,
+		Payload: [13]byte{byte(index)},
+	}
+}
+
+// logDevice answers log requests by calling reply with the index requested
+// and the number of times it has been requested, sending the entries
+// returned. It records the indices requested.
+type logDevice struct {
+	fake  *connection.Fake
+	reply func(index uint16, attempt int) []uint16
+
+	mutex     sync.Mutex
+	requested []uint16
+}
+
+func newLogDevice(reply func(index uint16, attempt int) []uint16) *logDevice {
+	d := &logDevice{reply: reply}
+	d.fake = &connection.Fake{Respond: d.respond}
+	return d
+}
+
+func (d *logDevice) respond(b []byte) [][]byte {
+	info, ok := messages.TypeByCode(b[0])
+	if !ok {
+		return nil
+	}
+	msg, err := info.Decode(b)
+	if err != nil {
+		return nil
+	}
+	req, ok := msg.(messages.LogRequestMessage)
+	if !ok {
+		return nil
+	}
+
+	d.mutex.Lock()
+	attempt := 0
+	for _, index := range d.requested {
+		if index == req.Index {
+			attempt++
+		}
+	}
+	d.requested = append(d.requested, req.Index)
+	d.mutex.Unlock()
+
+	var chunks [][]byte
+	for _, index := range d.reply(req.Index, attempt) {
+		buf, err := messages.WriteMessage(logResponse(index))
+		if err != nil {
+			panic(err)
+		}
+		chunks = append(chunks, buf.Bytes())
+	}
+	return chunks
+}
+
+func (d *logDevice) download(t *testing.T, end uint16, retries int) LogDownload {
+	var m Basic
+	m.SetTransport(d.fake)
+	err := m.Init("camera", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	dl, err := m.DownloadLog(0, end, 50*time.Millisecond, retries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dl
+}
+
+func indices(entries []messages.LogResponseMessage) []uint16 {
+	var list []uint16
+	for _, entry := range entries {
+		list = append(list, entry.Index)
+	}
+	return list
+}
+
+func TestDownloadLogGapsAndDuplicates(t *testing.T) {
+	d := newLogDevice(func(index uint16, attempt int) []uint16 {
+		switch {
+		case index == 1 && attempt == 0:
+			// The first response is lost
+			return nil
+		case index == 2:
+			// Sent twice, with the entry after it ahead of its turn
+			return []uint16{3, 2, 2}
+		}
+		return []uint16{index}
+	})
+
+	dl := d.download(t, 5, 3)
+
+	if got, want := indices(dl.Entries), []uint16{0, 1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entries %v, want %v", got, want)
+	}
+	if len(dl.Missing) != 0 {
+		t.Errorf("missing %v, want none", dl.Missing)
+	}
+	if want := []uint16{2}; !reflect.DeepEqual(dl.Duplicates, want) {
+		t.Errorf("duplicates %v, want %v", dl.Duplicates, want)
+	}
+	for _, entry := range dl.Entries {
+		if entry.Payload[0] != byte(entry.Index) {
+			t.Errorf("entry %d has the payload of %d", entry.Index, entry.Payload[0])
+		}
+	}
+
+	// The lost entry is requested again, the one received early is not
+	if want := []uint16{0, 1, 1, 2, 4}; !reflect.DeepEqual(d.requested, want) {
+		t.Errorf("requested %v, want %v", d.requested, want)
+	}
+}
+
+func TestDownloadLogRetriesExhausted(t *testing.T) {
+	d := newLogDevice(func(index uint16, attempt int) []uint16 {
+		if index == 1 {
+			return nil
+		}
+		return []uint16{index}
+	})
+
+	dl := d.download(t, 3, 2)
+
+	if got, want := indices(dl.Entries), []uint16{0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entries %v, want %v", got, want)
+	}
+	if want := []uint16{1}; !reflect.DeepEqual(dl.Missing, want) {
+		t.Errorf("missing %v, want %v", dl.Missing, want)
+	}
+
+	// Requested once and then once for each retry before moving on
+	if want := []uint16{0, 1, 1, 1, 2}; !reflect.DeepEqual(d.requested, want) {
+		t.Errorf("requested %v, want %v", d.requested, want)
+	}
+}
@@ -2,30 +2,36 @@ package boards
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/phelpsw/camera-trigger-bt-cli/connection"
 	"github.com/phelpsw/camera-trigger-bt-cli/messages"
 )
 
 type Motion struct {
-	name     string
-	conn     *connection.Connection
+	name    string
+	conn    connection.Transport
+	decoder *messages.Decoder
+
+	// mutex guards the fields below, which are written on the connection's
+	// callback goroutine and read by callers
+	mutex    sync.Mutex
 	last     messages.MotionSensorStatusMessage
 	desired  messages.MotionSensorConfigMessage
 	callback func(interface{}) error
-}
 
-var (
-	threshPending   bool = false
-	luxLowPending   bool = false
-	luxHighPending  bool = false
-	cooldownPending bool = false
-)
+	// Settings changed which the device has not yet reported
+	threshPending   bool
+	luxLowPending   bool
+	luxHighPending  bool
+	cooldownPending bool
+}
 
 func (m *Motion) Init(name string, debug bool) error {
 	m.name = name
 
-	m.conn = &connection.Connection{}
+	m.conn = NewTransport()
+	m.decoder = &messages.Decoder{}
 	err := m.conn.Init(name, m.handleBytes, debug)
 	if err != nil {
 		return err
@@ -36,45 +42,66 @@ func (m *Motion) Init(name string, debug bool) error {
 
 func (m *Motion) InitFromBasic(b *Basic) error {
 	m.conn = b.GetConnection()
-	m.conn.Callback(m.handleBytes)
+	// Continue with any partial message the basic board has buffered
+	m.decoder = &b.decoder
+	b.handOff(m.handleBytes)
 
 	return nil
 }
 
 func (m *Motion) handleBytes(b []byte) error {
-	msg, err := messages.ReadMessage(b)
-	if err != nil {
-		return err
-	}
+	// A chunk may complete several messages
+	var first error
+	for {
+		msg, err := m.decoder.ReadMessage(b)
+		b = nil
+		if err != nil {
+			return err
+		}
 
-	// A full message was not found
-	if msg == nil {
-		return nil
+		// A full message was not found
+		if msg == nil {
+			return first
+		}
+
+		err = m.handleMessage(msg)
+		if err != nil && first == nil {
+			first = err
+		}
 	}
+}
+
+func (m *Motion) handleMessage(msg interface{}) error {
+	var err error
 
+	m.mutex.Lock()
 	switch msg.(type) {
 	case messages.MotionSensorStatusMessage:
 		m.last = msg.(messages.MotionSensorStatusMessage)
 	default:
+		m.mutex.Unlock()
 		fmt.Println("Unknown")
 		return fmt.Errorf("unexpected message type %+v", msg)
 	}
 
-	if threshPending && floatEquals(m.last.MotionThreshold, m.desired.MotionThreshold) {
-		threshPending = false
+	if m.threshPending && floatEquals(m.last.MotionThreshold, m.desired.MotionThreshold) {
+		m.threshPending = false
 	}
-	if luxLowPending && floatEquals(m.last.LuxLowThreshold, m.desired.LuxLowThreshold) {
-		luxLowPending = false
+	if m.luxLowPending && floatEquals(m.last.LuxLowThreshold, m.desired.LuxLowThreshold) {
+		m.luxLowPending = false
 	}
-	if luxHighPending && floatEquals(m.last.LuxHighThreshold, m.desired.LuxHighThreshold) {
-		luxHighPending = false
+	if m.luxHighPending && floatEquals(m.last.LuxHighThreshold, m.desired.LuxHighThreshold) {
+		m.luxHighPending = false
 	}
-	if cooldownPending && floatEquals(m.last.Cooldown, m.desired.Cooldown) {
-		cooldownPending = false
+	if m.cooldownPending && floatEquals(m.last.Cooldown, m.desired.Cooldown) {
+		m.cooldownPending = false
 	}
 
-	if m.callback != nil {
-		err = m.callback(m)
+	callback := m.callback
+	m.mutex.Unlock()
+
+	if callback != nil {
+		err = callback(m)
 		if err != nil {
 			return err
 		}
@@ -83,59 +110,79 @@ func (m *Motion) handleBytes(b []byte) error {
 	return nil
 }
 
+// Close disconnects from the device
+func (m *Motion) Close() error {
+	return closeTransport(m.conn)
+}
+
 func (m *Motion) SetUpdateCallback(callback func(interface{}) error) {
+	m.mutex.Lock()
 	m.callback = callback
+	m.mutex.Unlock()
+}
+
+// status returns the most recent status message
+func (m *Motion) status() messages.MotionSensorStatusMessage {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.last
+}
+
+func (m *Motion) Timestamp() messages.Calendar {
+	return m.status().Timestamp
 }
 
 func (m *Motion) Temperature() float32 {
-	return m.last.Temperature
+	return m.status().Temperature
 }
 
 func (m *Motion) Voltage() float32 {
-	return m.last.Voltage
+	return m.status().Voltage
 }
 
 func (m *Motion) Motion() float32 {
-	return m.last.Motion
+	return m.status().Motion
 }
 
 func (m *Motion) MotionThreshold() float32 {
-	return m.last.MotionThreshold
+	return m.status().MotionThreshold
 }
 
 func (m *Motion) Lux() float32 {
-	return m.last.Lux
+	return m.status().Lux
 }
 
 func (m *Motion) LuxLowThreshold() float32 {
-	return m.last.LuxLowThreshold
+	return m.status().LuxLowThreshold
 }
 
 func (m *Motion) LuxHighThreshold() float32 {
-	return m.last.LuxHighThreshold
+	return m.status().LuxHighThreshold
 }
 
 func (m *Motion) Cooldown() float32 {
-	return m.last.Cooldown
+	return m.status().Cooldown
 }
 
 // TODO: Enumerate this properly
 func (m *Motion) MotionSensorType() uint8 {
-	return m.last.MotionSensorType
+	return m.status().MotionSensorType
 }
 
 // TODO: Enumerate this properly
 func (m *Motion) LedModes() uint8 {
-	return m.last.LedModes
+	return m.status().LedModes
 }
 
 func (m *Motion) LogEntries() uint16 {
-	return m.last.LogEntries
+	return m.status().LogEntries
 }
 
 func (m *Motion) SetMotionThreshold(thresh float32, sync bool) error {
+	m.mutex.Lock()
 	m.desired.MotionThreshold = thresh
-	threshPending = true
+	m.threshPending = true
+	m.mutex.Unlock()
 
 	if sync {
 		m.Sync()
@@ -145,8 +192,10 @@ func (m *Motion) SetMotionThreshold(thresh float32, sync bool) error {
 }
 
 func (m *Motion) SetLuxLowThreshold(thresh float32, sync bool) error {
+	m.mutex.Lock()
 	m.desired.LuxLowThreshold = thresh
-	luxLowPending = true
+	m.luxLowPending = true
+	m.mutex.Unlock()
 
 	if sync {
 		m.Sync()
@@ -156,8 +205,10 @@ func (m *Motion) SetLuxLowThreshold(thresh float32, sync bool) error {
 }
 
 func (m *Motion) SetLuxHighThreshold(thresh float32, sync bool) error {
+	m.mutex.Lock()
 	m.desired.LuxHighThreshold = thresh
-	luxHighPending = true
+	m.luxHighPending = true
+	m.mutex.Unlock()
 
 	if sync {
 		m.Sync()
@@ -167,8 +218,10 @@ func (m *Motion) SetLuxHighThreshold(thresh float32, sync bool) error {
 }
 
 func (m *Motion) SetCooldown(thresh float32, sync bool) error {
+	m.mutex.Lock()
 	m.desired.Cooldown = thresh
-	cooldownPending = true
+	m.cooldownPending = true
+	m.mutex.Unlock()
 
 	if sync {
 		m.Sync()
@@ -178,27 +231,35 @@ func (m *Motion) SetCooldown(thresh float32, sync bool) error {
 }
 
 func (m *Motion) IsSynced() bool {
-	if !threshPending && !luxLowPending && !luxHighPending && !cooldownPending {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.synced()
+}
+
+func (m *Motion) synced() bool {
+	if !m.threshPending && !m.luxLowPending && !m.luxHighPending && !m.cooldownPending {
 		return true
 	}
 	return false
 }
 
 func (m *Motion) Sync() error {
-	if m.IsSynced() {
+	m.mutex.Lock()
+	if m.synced() {
+		m.mutex.Unlock()
 		return nil
 	}
 
-	if !threshPending {
+	if !m.threshPending {
 		m.desired.MotionThreshold = m.last.MotionThreshold
 	}
-	if !luxLowPending {
+	if !m.luxLowPending {
 		m.desired.LuxLowThreshold = m.last.LuxLowThreshold
 	}
-	if !luxHighPending {
+	if !m.luxHighPending {
 		m.desired.LuxHighThreshold = m.last.LuxHighThreshold
 	}
-	if !cooldownPending {
+	if !m.cooldownPending {
 		m.desired.Cooldown = m.last.Cooldown
 	}
 
@@ -206,6 +267,7 @@ func (m *Motion) Sync() error {
 		m.desired.LuxLowThreshold,
 		m.desired.LuxHighThreshold,
 		m.desired.Cooldown)
+	m.mutex.Unlock()
 
 	if !m.conn.IsConnected() {
 		return fmt.Errorf("not connected")
@@ -0,0 +1,122 @@
+package boards
+
+import "fmt"
+
+// Param is a named device parameter
+type Param struct {
+	Name        string
+	Description string
+}
+
+// Uint16Persist lists the uint16 parameters stored in flash, by index
+var Uint16Persist = []Param{
+	{"device_id", "Device ID"},
+	{"device_group", "Device Group ID"},
+	{"sony_sleep_mode", "Sony camera sleep mode, 0 - Off, 1 - Idle"},
+	{"led_on_record", "1 - red led, 2 - green led, 0 - disabled"},
+	{"motion_blink_on_detect", "1 - red led, 2 - green led, 0 - disabled"},
+	{"motion_transmit_on_detect", "1 - transmit enable, 0 - disabled"},
+}
+
+// Uint16Temp lists the uint16 runtime values, by index
+var Uint16Temp = []Param{
+	{"version_major", "Major version number"},
+	{"version_minor", "Minor version number"},
+	{"version_patch", "Patch version number"},
+	{"version_dirty", "Bit indicating whether local mods have been made"},
+	{"version_hash1", "Top 16 bits of git version hash"},
+	{"version_hash2", "Lower 16 bits of git version hash"},
+	{"part_number", ""},
+	{"serial_number", ""},
+	{"manufacture_year", ""},
+	{"manufacture_doy", ""},
+	{"device_type", "Device type"},
+	{"led_red_state", "Red LED State, 0 - Off, 1 - On, 2 - Blink Once, 3 - Blink Continuous"},
+	{"led_green_state", "Green LED State, 0 - Off, 1 - On, 2 - Blink Once, 3 - Blink Continuous"},
+	{"motion_state", "Motion sensor state machine state"},
+	{"motion_trigger_count", "Motion sensor trigger count since boot"},
+	{"trigger_state", "State of device trigger, 0 available, 1 - cooldown"},
+	{"runcam_control_state", "Runcam controller state"},
+	{"runcam_state", "Runcam button push state machine"},
+	{"sony_control_state", ""},
+	{"sony_version_major", ""},
+	{"sony_version_minor", ""},
+	{"sony_version_patch", ""},
+	{"sony_version_dirty", ""},
+	{"sony_version_reg1", ""},
+	{"sony_version_reg2", ""},
+	{"sony_version_reg3", ""},
+	{"sony_version_reg4", ""},
+	{"sony_type", ""},
+	{"sony_mode", ""},
+	{"sony_status", ""},
+	{"sony_led", ""},
+}
+
+// FloatPersist lists the float parameters stored in flash, by index
+var FloatPersist = []Param{
+	{"motion_gain", "Motion sensor gain (0.0 - 1.0)"},
+	{"motion_threshold", "Motion sensor trigger threshold (0.0 - 1.0)"},
+	{"motion_cooldown", "Minimum seconds between motion sensor retrigger"},
+	{"lux_interval", "Lux measurement interval"},
+	{"video_duration", "Length of video recording trigger event in seconds"},
+	{"trigger_max_duration", "Cumulative consecutive length of trigger events in seconds"},
+	{"trigger_max_duration_cooldown", "Cooldown period following trigger max duration"},
+	{"led_on_period", "On time of LED blink"},
+	{"led_off_period", "Seconds between continuous LED blinks"},
+	{"light_level2_thresh", "Lux level for light brightness level 2"},
+	{"light_level3_thresh", "Lux level for light brightness level 3"},
+	{"light_delay", "Light delay in seconds before fade up"},
+	{"light_attack", "Light fade up in seconds"},
+	{"light_sustain", "Light on period in seconds"},
+	{"light_release", "Light fade out in seconds"},
+}
+
+// FloatTemp lists the float runtime values, by index
+var FloatTemp = []Param{
+	{"cpu_temperature", "Major version number"},
+	{"battery_voltage", "Minor version number"},
+	{"uptime", "System uptime in seconds"},
+	{"motion_value", "Motion sensor value"},
+	{"lux_value", "Lux measurement"},
+}
+
+func paramIndex(input string, members []Param) (int, error) {
+	for indx, member := range members {
+		if input == member.Name {
+			return indx, nil
+		}
+	}
+
+	return 0, fmt.Errorf("not found")
+}
+
+// Uint16Index returns the id and persist flag of a named uint16 parameter
+func Uint16Index(input string) (uint16, uint8, error) {
+	indx, err := paramIndex(input, Uint16Persist)
+	if err == nil {
+		return uint16(indx), 1, nil
+	}
+
+	indx, err = paramIndex(input, Uint16Temp)
+	if err == nil {
+		return uint16(indx), 0, nil
+	}
+
+	return 0, 0, fmt.Errorf("not found")
+}
+
+// FloatIndex returns the id and persist flag of a named float parameter
+func FloatIndex(input string) (uint16, uint8, error) {
+	indx, err := paramIndex(input, FloatPersist)
+	if err == nil {
+		return uint16(indx), 1, nil
+	}
+
+	indx, err = paramIndex(input, FloatTemp)
+	if err == nil {
+		return uint16(indx), 0, nil
+	}
+
+	return 0, 0, fmt.Errorf("not found")
+}
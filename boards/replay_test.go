@@ -0,0 +1,72 @@
+package boards
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/capture"
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestReplay(t *testing.T) {
+	status := messages.MotionSensorStatusMessage{
+		Type:            0x11,
+		Length:          uint8(binary.Size(messages.MotionSensorStatusMessage{})),
+		Motion:          0.25,
+		MotionThreshold: 0.3,
+		LogEntries:      12,
+	}
+	buf, err := messages.WriteMessage(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := buf.Bytes()
+
+	// A garbage prefix and a frame split across chunks, as seen in the field
+	start := time.Now()
+	chunk := func(ms int, device string, dir capture.Direction, data []byte) capture.Record {
+		return capture.Record{
+			Time:      start.Add(time.Duration(ms) * time.Millisecond),
+			Device:    device,
+			Direction: dir,
+			Data:      data,
+		}
+	}
+	records := []capture.Record{
+		chunk(0, "camera", capture.Received, []byte{0xff, 0xff}),
+		chunk(0, "camera", capture.Sent, []byte{0x01, 0x04, 0x00, 0x00}),
+		chunk(1, "camera", capture.Received, frame[:10]),
+		chunk(2, "camera", capture.Received, frame[10:]),
+		chunk(3, "other", capture.Received, []byte{0x00}),
+	}
+
+	replay := connection.NewReplay(records)
+	replay.Speed = 0
+
+	var m Basic
+	m.SetTransport(replay)
+	err = m.Init("camera", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.WaitForStatus(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-replay.Done()
+
+	if got := m.Status(); got != status {
+		t.Errorf("Status() = %+v, want %+v", got, status)
+	}
+	if m.LogEntries() != 12 {
+		t.Errorf("LogEntries() = %d, want 12", m.LogEntries())
+	}
+	if m.IsConnected() {
+		t.Error("still connected after the replay finished")
+	}
+	if c := m.Counters(); c.Frames != 1 || c.ParseErrors != 0 {
+		t.Errorf("Counters() = %+v", c)
+	}
+}
@@ -0,0 +1,145 @@
+// Package capture records the raw bytes exchanged with devices so traffic
+// seen in the field can be examined and replayed later.
+//
+// A capture file has one json object per line, each a chunk of bytes as it
+// was written to or received from a device:
+//
+//	{"time":"2020-06-01T20:15:00.123Z","device":"camera-trigger-001","dir":"rx","data":"0324..."}
+package capture
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Direction is the direction a chunk of bytes travelled
+type Direction string
+
+const (
+	// Sent chunks were written to the device
+	Sent Direction = "tx"
+	// Received chunks were received from the device
+	Received Direction = "rx"
+)
+
+// Record is one chunk of bytes
+type Record struct {
+	Time      time.Time
+	Device    string
+	Direction Direction
+	Data      []byte
+}
+
+// record is the form of a Record in a capture file
+type record struct {
+	Time      time.Time `json:"time"`
+	Device    string    `json:"device"`
+	Direction Direction `json:"dir"`
+	Data      string    `json:"data"`
+}
+
+func (r Record) MarshalJSON() ([]byte, error) {
+	return json.Marshal(record{r.Time, r.Device, r.Direction, hex.EncodeToString(r.Data)})
+}
+
+func (r *Record) UnmarshalJSON(b []byte) error {
+	var rec record
+	err := json.Unmarshal(b, &rec)
+	if err != nil {
+		return err
+	}
+	if rec.Direction != Sent && rec.Direction != Received {
+		return fmt.Errorf("unknown direction %q", rec.Direction)
+	}
+	data, err := hex.DecodeString(rec.Data)
+	if err != nil {
+		return err
+	}
+
+	*r = Record{rec.Time, rec.Device, rec.Direction, data}
+	return nil
+}
+
+// Writer appends records to a capture. It is safe for concurrent use.
+type Writer struct {
+	mutex sync.Mutex
+	w     io.Writer
+	enc   *json.Encoder
+}
+
+// NewWriter writes records to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, enc: json.NewEncoder(w)}
+}
+
+// Create creates or truncates the capture file at path
+func Create(path string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(f), nil
+}
+
+// Write appends a record. Each record is written straight through so a
+// capture is complete up to the moment the program stops.
+func (w *Writer) Write(r Record) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.enc.Encode(r)
+}
+
+// Close closes the underlying writer if it is closable
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Read reads every record of a capture
+func Read(r io.Reader) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec Record
+		err := json.Unmarshal(scanner.Bytes(), &rec)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		records = append(records, rec)
+	}
+
+	return records, scanner.Err()
+}
+
+// ReadFile reads every record of the capture file at path
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return records, nil
+}
@@ -0,0 +1,138 @@
+package capture
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestRoundTrip(t *testing.T) {
+	start := time.Date(2020, 6, 1, 20, 15, 0, 0, time.UTC)
+	records := []Record{
+		{start, "camera-trigger-001", Sent, []byte{0x03, 0x24, 0x00}},
+		{start.Add(15 * time.Millisecond), "camera-trigger-001", Received, []byte{0x01, 0x02}},
+		{start.Add(time.Second), "light-001", Received, []byte{}},
+	}
+
+	var b bytes.Buffer
+	w := NewWriter(&b)
+	for _, r := range records {
+		err := w.Write(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first := strings.SplitN(b.String(), "\n", 2)[0]
+	want := `{"time":"2020-06-01T20:15:00Z","device":"camera-trigger-001","dir":"tx","data":"032400"}`
+	if first != want {
+		t.Errorf("first line %s, want %s", first, want)
+	}
+
+	got, err := Read(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("Read() = %v, want %v", got, records)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	inputs := []string{
+		`{"time":"2020-06-01T20:15:00Z","dir":"up","data":""}`,
+		`{"time":"2020-06-01T20:15:00Z","dir":"rx","data":"0g"}`,
+		`not json`,
+	}
+	for _, input := range inputs {
+		_, err := Read(strings.NewReader("\n" + input + "\n"))
+		if err == nil || !strings.HasPrefix(err.Error(), "line 2: ") {
+			t.Errorf("Read(%s) error %v", input, err)
+		}
+	}
+}
+
+func TestWritePcap(t *testing.T) {
+	records := []Record{
+		{time.Unix(1591042500, 123456000), "cam", Received, []byte{0x01, 0x02, 0x03}},
+		{time.Unix(1591042501, 0), "", Sent, []byte{0x03, 0x02}},
+	}
+
+	var b bytes.Buffer
+	err := WritePcap(&b, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		// Global header
+		0xd4, 0xc3, 0xb2, 0xa1, 2, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0xff, 0xff, 0, 0, LinkType, 0, 0, 0,
+		// Received by cam
+		0xc4, 0x61, 0xd5, 0x5e, 0x40, 0xe2, 0x01, 0x00, 8, 0, 0, 0, 8, 0, 0, 0,
+		1, 3, 'c', 'a', 'm', 0x01, 0x02, 0x03,
+		// Sent, no device name
+		0xc5, 0x61, 0xd5, 0x5e, 0, 0, 0, 0, 4, 0, 0, 0, 4, 0, 0, 0,
+		0, 0, 0x03, 0x02,
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("WritePcap() =\n% x\nwant\n% x", b.Bytes(), want)
+	}
+}
+
+func TestWriteDissector(t *testing.T) {
+	var b bytes.Buffer
+	err := WriteDissector(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lua := b.String()
+
+	for _, want := range []string{
+		`[0x01] = "LogRequestMessage",`,
+		`[0x47] = "SetUint16Response",`,
+		`[0x21] = 49,`,
+		`f["light_status.payload.current"] = ProtoField.float("camtrig.light_status.payload.current", "Current")`,
+		`		sub_timestamp:add(f["motion_sensor_status.timestamp.year"], tvb(8, 2))`,
+		`	tree:add(f["motion_sensor_status.log_entries"], tvb(44, 2))`,
+		`DissectorTable.get("wtap_encap"):add(wtap.USER0, proto)`,
+	} {
+		if !strings.Contains(lua, want) {
+			t.Errorf("dissector missing %s", want)
+		}
+	}
+	if n := strings.Count(lua, "] = function(tvb, tree)"); n != len(messages.Types) {
+		t.Errorf("%d dissector functions, want %d", n, len(messages.Types))
+	}
+}
+
+func TestParseDump(t *testing.T) {
+	input := `# comment
+2020/06/01 20:15:00 TX 3 bytes: 0x03, 0x02, 0xff,
+RX 2 bytes: 0x45, 0x08,
+
+45 08 0x01,0X00 0200
+`
+	records, err := ParseDump(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Record{
+		{Direction: Sent, Data: []byte{0x03, 0x02, 0xff}},
+		{Direction: Received, Data: []byte{0x45, 0x08}},
+		{Direction: Received, Data: []byte{0x45, 0x08, 0x01, 0x00, 0x02, 0x00}},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("ParseDump() = %v, want %v", records, want)
+	}
+
+	_, err = ParseDump(strings.NewReader("45 08\n45 8\n"))
+	if err == nil || err.Error() != `line 2: invalid hex "8"` {
+		t.Errorf("ParseDump(odd digits) error %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+// dissectorHeader declares the protocol and the fields common to every
+// packet. %s is replaced with the table of message type names.
+const dissectorHeader = `-- Wireshark dissector for camera trigger bluetooth UART traffic exported by
+-- camera-trigger-bt-cli capture pcap. Generated from the message definitions
+-- by camera-trigger-bt-cli capture dissector, regenerate rather than edit.
+--
+-- Install by copying to the Wireshark personal plugins folder, see
+-- Help > About Wireshark > Folders.
+
+local proto = Proto("camtrig", "Camera Trigger UART")
+
+local directions = { [0] = "Host to device", [1] = "Device to host" }
+local types = {
+%s}
+
+local f = {}
+f["direction"] = ProtoField.uint8("camtrig.direction", "Direction", base.DEC, directions)
+f["device"] = ProtoField.string("camtrig.device", "Device")
+f["type"] = ProtoField.uint8("camtrig.type", "Type", base.HEX, types)
+f["length"] = ProtoField.uint8("camtrig.length", "Length", base.DEC)
+f["fragment"] = ProtoField.bytes("camtrig.fragment", "Partial or unrecognised bytes")
+`
+
+// dissectorFooter walks the messages in each chunk
+const dissectorFooter = `
+proto.fields = f
+
+function proto.dissector(tvb, pinfo, tree)
+	pinfo.cols.protocol = "CAMTRIG"
+	local root = tree:add(proto, tvb())
+
+	local dir = tvb(0, 1):uint()
+	root:add(f["direction"], tvb(0, 1))
+	local namelen = tvb(1, 1):uint()
+	local device = "device"
+	if namelen > 0 then
+		root:add(f["device"], tvb(2, namelen))
+		device = tvb(2, namelen):string()
+	end
+	if dir == 0 then
+		pinfo.cols.src = "host"
+		pinfo.cols.dst = device
+	else
+		pinfo.cols.src = device
+		pinfo.cols.dst = "host"
+	end
+
+	local info = {}
+	local offset = 2 + namelen
+	while offset < tvb:len() do
+		local remaining = tvb:len() - offset
+		local code = tvb(offset, 1):uint()
+		local length = lengths[code]
+		if length == nil or remaining < 2 or tvb(offset + 1, 1):uint() ~= length or remaining < length then
+			root:add(f["fragment"], tvb(offset))
+			table.insert(info, "[partial]")
+			break
+		end
+
+		local msg = root:add(tvb(offset, length), types[code])
+		msg:add(f["type"], tvb(offset, 1))
+		msg:add(f["length"], tvb(offset + 1, 1))
+		dissectors[code](tvb(offset, length):tvb(), msg)
+		table.insert(info, types[code])
+		offset = offset + length
+	end
+	pinfo.cols.info = table.concat(info, ", ")
+end
+
+DissectorTable.get("wtap_encap"):add(wtap.USER0, proto)
+`
+
+// snakeCase converts a Go identifier to snake case
+func snakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// dissectorName is the name of a message type in field abbreviations
+func dissectorName(t messages.TypeInfo) string {
+	return snakeCase(strings.TrimSuffix(t.Name, "Message"))
+}
+
+// luaField returns the ProtoField constructor for a field type
+func luaField(t reflect.Type, abbr, label string) (string, error) {
+	switch t.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Int8, reflect.Int16, reflect.Int32:
+		return fmt.Sprintf("ProtoField.%s(%q, %q, base.DEC)", t.Kind(), abbr, label), nil
+	case reflect.Float32:
+		return fmt.Sprintf("ProtoField.float(%q, %q)", abbr, label), nil
+	case reflect.Float64:
+		return fmt.Sprintf("ProtoField.double(%q, %q)", abbr, label), nil
+	case reflect.Array:
+		return fmt.Sprintf("ProtoField.bytes(%q, %q)", abbr, label), nil
+	}
+	return "", fmt.Errorf("%s: unsupported field type %s", abbr, t)
+}
+
+// dissectorWriter accumulates the field declarations and the dissector
+// function bodies
+type dissectorWriter struct {
+	fields strings.Builder
+	body   strings.Builder
+}
+
+// addStruct adds the fields of t found at offset in the tvb to tree. Top
+// level Type and Length fields are dissected for every message so skipped.
+func (d *dissectorWriter) addStruct(t reflect.Type, prefix, tree string, offset int, depth int) error {
+	indent := strings.Repeat("\t", depth)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		size := binary.Size(reflect.New(field.Type).Elem().Interface())
+		top := depth == 1
+
+		switch {
+		case top && (field.Anonymous || field.Name == "Type" || field.Name == "Length"):
+		case field.Type.Kind() == reflect.Struct:
+			sub := "sub_" + snakeCase(field.Name)
+			fmt.Fprintf(&d.body, "%sdo\n", indent)
+			fmt.Fprintf(&d.body, "%s\tlocal %s = %s:add(tvb(%d, %d), %q)\n", indent, sub, tree, offset, size, field.Name)
+			err := d.addStruct(field.Type, prefix+"."+snakeCase(field.Name), sub, offset, depth+1)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&d.body, "%send\n", indent)
+		default:
+			key := prefix + "." + snakeCase(field.Name)
+			decl, err := luaField(field.Type, "camtrig."+key, field.Name)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&d.fields, "f[%q] = %s\n", key, decl)
+			fmt.Fprintf(&d.body, "%s%s:add(f[%q], tvb(%d, %d))\n", indent, tree, key, offset, size)
+		}
+		offset += size
+	}
+	return nil
+}
+
+// WriteDissector writes a Wireshark Lua dissector for pcap files written by
+// WritePcap, generated from the message definitions
+func WriteDissector(w io.Writer) error {
+	var names, lengths strings.Builder
+	d := &dissectorWriter{}
+
+	d.body.WriteString("\nlocal dissectors = {}\n")
+	for _, t := range messages.Types {
+		fmt.Fprintf(&names, "\t[0x%02x] = %q,\n", t.Code, t.Name)
+		fmt.Fprintf(&lengths, "\t[0x%02x] = %d,\n", t.Code, t.Size())
+
+		fmt.Fprintf(&d.body, "dissectors[0x%02x] = function(tvb, tree)\n", t.Code)
+		err := d.addStruct(t.Type, dissectorName(t), "tree", 0, 1)
+		if err != nil {
+			return err
+		}
+		d.body.WriteString("end\n")
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, dissectorHeader, names.String())
+	b.WriteString(d.fields.String())
+	fmt.Fprintf(b, "\nlocal lengths = {\n%s}\n", lengths.String())
+	b.WriteString(d.body.String())
+	b.WriteString(dissectorFooter)
+	return b.Flush()
+}
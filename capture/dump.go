@@ -0,0 +1,66 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// debugLine matches the chunks printed by --debug, possibly after a log
+// prefix, e.g. RX 4 bytes: 0x01, 0x04, 0x00, 0x02,
+var debugLine = regexp.MustCompile(`\b(TX|RX) \d+ bytes:(.*)$`)
+
+// ParseDump reads chunks from a hex dump, one chunk per line. Lines are
+// either in the form printed by --debug, which gives their direction, or
+// plain hex bytes separated by spaces or commas, optionally prefixed with
+// 0x, which are taken as received. Blank lines and lines starting with #
+// are ignored.
+func ParseDump(r io.Reader) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		dir := Received
+		if m := debugLine.FindStringSubmatch(text); m != nil {
+			if m[1] == "TX" {
+				dir = Sent
+			}
+			text = m[2]
+		}
+
+		data, err := parseHex(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		records = append(records, Record{Direction: dir, Data: data})
+	}
+
+	return records, scanner.Err()
+}
+
+func parseHex(text string) ([]byte, error) {
+	var data []byte
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	for _, field := range fields {
+		digits := strings.TrimPrefix(strings.TrimPrefix(field, "0x"), "0X")
+		b, err := hex.DecodeString(digits)
+		if err != nil || len(b) == 0 {
+			return nil, fmt.Errorf("invalid hex %q", field)
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
@@ -0,0 +1,79 @@
+package capture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// LinkType is the pcap link type of exported captures, LINKTYPE_USER0,
+// which the generated dissector registers for
+const LinkType = 147
+
+// Each pcap packet starts with a pseudo header: a direction byte, the length
+// of the device name and the device name, followed by the chunk of bytes
+const (
+	pcapSent     = 0
+	pcapReceived = 1
+)
+
+const pcapSnapLen = 65535
+
+// WritePcap writes records as a pcap file with one packet per chunk
+func WritePcap(w io.Writer, records []Record) error {
+	header := struct {
+		Magic        uint32
+		VersionMajor uint16
+		VersionMinor uint16
+		ThisZone     int32
+		SigFigs      uint32
+		SnapLen      uint32
+		Network      uint32
+	}{0xa1b2c3d4, 2, 4, 0, 0, pcapSnapLen, LinkType}
+
+	err := binary.Write(w, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		if len(r.Device) > 255 {
+			return fmt.Errorf("device name %q too long", r.Device)
+		}
+
+		packet := make([]byte, 0, 2+len(r.Device)+len(r.Data))
+		dir := byte(pcapReceived)
+		if r.Direction == Sent {
+			dir = pcapSent
+		}
+		packet = append(packet, dir, byte(len(r.Device)))
+		packet = append(packet, r.Device...)
+		packet = append(packet, r.Data...)
+
+		if len(packet) > pcapSnapLen {
+			return fmt.Errorf("chunk of %d bytes too long", len(r.Data))
+		}
+
+		record := struct {
+			Seconds      uint32
+			Microseconds uint32
+			InclLen      uint32
+			OrigLen      uint32
+		}{
+			uint32(r.Time.Unix()),
+			uint32(r.Time.Nanosecond() / 1000),
+			uint32(len(packet)),
+			uint32(len(packet)),
+		}
+		err = binary.Write(w, binary.LittleEndian, record)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(packet)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,7 +1,13 @@
 package main
 
-import "github.com/phelpsw/camera-trigger-bt-cli/cmd"
+import (
+	"os"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/cmd"
+)
 
 func main() {
-	cmd.Execute()
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/analysis"
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/recording"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	batteryCmd.Flags().StringSliceVarP(&batteryInputs, "input", "i", nil, "Recorded status files holding the voltage history")
+	batteryCmd.Flags().DurationVar(&batterySince, "since", 0, "Only fit the most recent period of the history, e.g. 168h")
+	batteryCmd.Flags().Float64Var(&batteryCutoff, "cutoff", 3.4, "Voltage at which the battery needs replacing")
+	batteryCmd.Flags().Float64Var(&batteryWarnDays, "warn-days", 14, "Warn when fewer days than this remain")
+	batteryCmd.Flags().Float64Var(&batteryTriggers, "triggers-per-day", -1, "Expected motion triggers per day (default measured with --live)")
+	batteryCmd.Flags().BoolVar(&batteryLive, "live", false, "Read trigger count or light configuration from each device")
+	batteryCmd.Flags().DurationVar(&timeConnectTimeout, "connect-timeout", 30*time.Second, "Time to search for each device with --live")
+	batteryCmd.MarkFlagRequired("input")
+
+	rootCmd.AddCommand(batteryCmd)
+}
+
+var batteryCmd = &cobra.Command{
+	Use:   "battery",
+	Short: "Estimate remaining battery life from recorded voltage",
+	Long: `Estimate remaining battery life from recorded voltage
+
+A straight line is fitted to the voltage history of each device in the
+recordings written by the record command and projected forward to --cutoff.
+Battery voltage is not linear over a whole discharge, so fitting a recent
+period with --since gives a better estimate once the history is long.
+
+With --live each device is queried for its trigger count (motion sensor) or
+light envelope (light controller). A light controller takes the trigger rate
+of the motion sensor paired with it in the inventory unless
+--triggers-per-day is given. Once the triggers, envelope and lit current of
+a light controller are known the estimate is also given at that expected
+load, scaling the trend by how it compares with the light drain while the
+history was recorded.`,
+	Run: battery,
+}
+
+var (
+	batteryInputs   []string
+	batterySince    time.Duration
+	batteryCutoff   float64
+	batteryWarnDays float64
+	batteryTriggers float64
+	batteryLive     bool
+)
+
+// batteryLoad describes how hard the lights of a device are being used
+type batteryLoad struct {
+	// triggersPerDay is negative if unknown
+	triggersPerDay float64
+	envelope       *boards.Envelope
+	// litCurrent is the mean light current seen while lit, in amps
+	litCurrent float64
+	// recordedCurrent is the mean light current over the voltage history, in
+	// amps, the light drain which produced the voltage trend
+	recordedCurrent float64
+}
+
+// expectedCurrent returns the mean light current the triggers and envelope
+// will draw, in amps, or zero if the load is not known
+func (l *batteryLoad) expectedCurrent() float64 {
+	if l.triggersPerDay < 0 || l.envelope == nil || l.envelope.Level <= 0 || l.litCurrent <= 0 {
+		return 0
+	}
+
+	// Current was measured at the configured level, so scale the output back
+	// to seconds at that level
+	lit := l.triggersPerDay * float64(l.envelope.Energy()/l.envelope.Level)
+	return lit * l.litCurrent / (24 * 60 * 60)
+}
+
+// liveLoad reads the trigger rate from a motion sensor or the envelope from
+// a light controller
+func liveLoad(device string, load *batteryLoad) error {
+	b := boards.Basic{}
+	b.SetConnectTimeout(timeConnectTimeout)
+
+	err := b.Init(device, debug)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	err = b.WaitForStatus(10 * time.Second)
+	if err != nil {
+		return err
+	}
+
+	switch b.GetType() {
+	case reflect.TypeOf(boards.Motion{}):
+		indx, persist, err := boards.Uint16Index("motion_trigger_count")
+		if err != nil {
+			return err
+		}
+		count, err := b.GetUint16(indx, persist)
+		if err != nil {
+			return err
+		}
+
+		indx, persist, err = boards.FloatIndex("uptime")
+		if err != nil {
+			return err
+		}
+		uptime, err := b.GetFloat(indx, persist)
+		if err != nil {
+			return err
+		}
+
+		if count.Success != 1 || uptime.Success != 1 || uptime.Value <= 0 {
+			return fmt.Errorf("trigger count not available")
+		}
+
+		days := float64(uptime.Value) / (24 * 60 * 60)
+		fmt.Printf("%s: %d triggers in %.1f days since boot\n", device, count.Value, days)
+		if batteryTriggers < 0 {
+			load.triggersPerDay = float64(count.Value) / days
+		}
+	case reflect.TypeOf(boards.Light{}):
+		l := boards.Light{}
+		err = l.InitFromBasic(&b)
+		if err != nil {
+			return err
+		}
+		err = awaitUpdate(&l, 10*time.Second)
+		if err != nil {
+			return err
+		}
+
+		e := l.Envelope()
+		load.envelope = &e
+		printEnvelope(os.Stdout, device, e)
+	}
+
+	return nil
+}
+
+// pairedTriggers gives a light controller without a trigger rate the rate
+// measured on the motion sensor paired with it in the inventory
+func pairedTriggers(loads map[string]*batteryLoad) {
+	for device, load := range loads {
+		if load.triggersPerDay >= 0 || load.envelope == nil {
+			continue
+		}
+		d, ok := siteInventory.Find(device)
+		if !ok || d.Paired == "" {
+			continue
+		}
+		for name, paired := range loads {
+			if strings.EqualFold(name, d.Paired) && paired.envelope == nil {
+				load.triggersPerDay = paired.triggersPerDay
+			}
+		}
+	}
+}
+
+func battery(cmd *cobra.Command, args []string) {
+	samples, err := readRecordings(batteryInputs)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	// Group samples by device, keeping the order devices first appear
+	var devices []string
+	byDevice := make(map[string][]recording.Sample)
+	for _, s := range samples {
+		if deviceID != "" && s.Device != deviceID {
+			continue
+		}
+		if _, ok := byDevice[s.Device]; !ok {
+			devices = append(devices, s.Device)
+		}
+		byDevice[s.Device] = append(byDevice[s.Device], s)
+	}
+	if len(devices) == 0 {
+		log.Println("no samples found")
+		return
+	}
+
+	loads := make(map[string]*batteryLoad)
+	for _, device := range devices {
+		loads[device] = &batteryLoad{triggersPerDay: batteryTriggers}
+		if batteryLive {
+			err = liveLoad(device, loads[device])
+			if err != nil {
+				fmt.Printf("%s: unable to read load: %s\n", device, err)
+			}
+		}
+	}
+	pairedTriggers(loads)
+
+	for _, device := range devices {
+		history := byDevice[device]
+		load := loads[device]
+
+		if batterySince > 0 {
+			start := history[len(history)-1].HostTime.Add(-batterySince)
+			for len(history) > 0 && history[0].HostTime.Before(start) {
+				history = history[1:]
+			}
+		}
+
+		var times []time.Time
+		var volts []float64
+		var current []float64
+		var lit []float64
+		for _, s := range history {
+			times = append(times, s.HostTime)
+			volts = append(volts, float64(s.Voltage))
+			if s.Board == recording.BoardLight {
+				current = append(current, float64(s.Current))
+				if s.Current > 0.01 {
+					lit = append(lit, float64(s.Current))
+				}
+			}
+		}
+		if len(lit) > 0 {
+			load.litCurrent = analysis.Summarize(lit).Mean
+			load.recordedCurrent = analysis.Summarize(current).Mean
+		}
+
+		fmt.Printf("\n%s (%s)\n", device, history[0].Board)
+		printLoad(load)
+
+		d, err := analysis.EstimateDischarge(times, volts, batteryCutoff)
+		if err != nil {
+			fmt.Printf("  %s\n", err)
+			continue
+		}
+
+		fmt.Printf("  %d samples over %.1f days\n", len(history), d.Days)
+		fmt.Printf("  Voltage %.3f V, trend %+.4f V/day (fit R2 %.2f)\n",
+			d.Voltage, d.VoltsPerDay, d.Fit.R2)
+
+		if math.IsInf(d.DaysRemaining, 1) {
+			fmt.Printf("  Voltage is not falling, no estimate\n")
+			continue
+		}
+
+		fmt.Printf("  %.1f days until %.2f V at the recorded load\n", d.DaysRemaining, batteryCutoff)
+
+		// The lights dominate the drain, so the trend is scaled by how the
+		// expected light drain compares with the drain while recording
+		remaining := d.DaysRemaining
+		if expected := load.expectedCurrent(); expected > 0 && load.recordedCurrent > 0 {
+			remaining *= load.recordedCurrent / expected
+			fmt.Printf("  %.1f days at the expected load, %.0f%% of the recorded light drain\n",
+				remaining, 100*expected/load.recordedCurrent)
+		}
+
+		// The estimate runs from the last sample, which may be long past for
+		// an old recording
+		last := times[len(times)-1]
+		fmt.Printf("  Replace around %s\n",
+			last.Add(time.Duration(remaining*24)*time.Hour).Format("2006-01-02"))
+		if remaining < batteryWarnDays {
+			fmt.Printf("  WARNING: battery needs replacing within %.0f days\n", batteryWarnDays)
+		}
+	}
+
+	log.Println("Done")
+}
+
+// printLoad prints the light usage known for a device
+func printLoad(load *batteryLoad) {
+	if load.triggersPerDay < 0 {
+		return
+	}
+	fmt.Printf("  %.1f triggers/day\n", load.triggersPerDay)
+
+	if load.envelope == nil {
+		return
+	}
+	e := load.envelope
+	fmt.Printf("  Lights on %.1f min/day, %.1f min/day at full brightness\n",
+		load.triggersPerDay*float64(e.OnTime())/60,
+		load.triggersPerDay*float64(e.Energy())/60)
+
+	if expected := load.expectedCurrent(); expected > 0 {
+		fmt.Printf("  Light drain %.3f Ah/day at %.2f A lit current\n", expected*24, load.litCurrent)
+	}
+}
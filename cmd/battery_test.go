@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"github.com/phelpsw/camera-trigger-bt-cli/recording"
+)
+
+// writeVoltageHistory records samples twice a day for ten days, the light
+// voltage falling 0.01 V/day to 3.8 V and lit at 1 A in 3 of its 21 samples
+func writeVoltageHistory(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "status.csv")
+	w, err := recording.NewWriter(path, "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= 20; i++ {
+		now := start.Add(time.Duration(i) * 12 * time.Hour)
+		days := float32(i) / 2
+		samples := []recording.Sample{
+			{HostTime: now, Device: "camera-trigger-001", Board: recording.BoardMotion,
+				Voltage: 3.7 - 0.01*days},
+			{HostTime: now, Device: "camera-trigger-002", Board: recording.BoardLight,
+				Voltage: 3.9 - 0.01*days},
+		}
+		if i%10 == 0 {
+			samples[1].Current = 1
+		}
+		for _, s := range samples {
+			if err = w.Write(s); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return path
+}
+
+func TestBatteryLive(t *testing.T) {
+	history := writeVoltageHistory(t)
+	inv := writeConfig(t, `
+devices:
+  - name: camera-trigger-001
+    role: motion
+    paired: camera-trigger-002
+  - name: camera-trigger-002
+    role: light
+    paired: camera-trigger-001
+`)
+
+	// 100 triggers in 2 days, each lighting 60 s at full brightness
+	motion := newMotionDevice(messages.MotionSensorStatusMessage{})
+	indx, persist, _ := boards.Uint16Index("motion_trigger_count")
+	motion.uint16s[paramKey{indx, persist}] = 100
+	indx, persist, _ = boards.FloatIndex("uptime")
+	motion.floats[paramKey{indx, persist}] = 2 * 24 * 60 * 60
+	light := newLightDevice(messages.LightStatusMessage{
+		Payload: messages.LightStatus{Level: 1, Sustain: 60},
+	})
+	light.statusEvery(t, 20*time.Millisecond)
+	devices := map[string]*fakeDevice{
+		"camera-trigger-001": motion,
+		"camera-trigger-002": light,
+	}
+
+	out := executeSite(t, devices, "--inventory", inv, "battery", "--input", history, "--live")
+
+	for _, want := range []string{
+		"camera-trigger-001: 100 triggers in 2.0 days since boot\n",
+		"\ncamera-trigger-002 (light)\n  50.0 triggers/day\n",
+		"  Light drain 0.833 Ah/day at 1.00 A lit current\n",
+		"  40.0 days until 3.40 V at the recorded load\n",
+		// Recorded light drain 1/7 A against 50*60/86400 A expected
+		"  164.6 days at the expected load, 24% of the recorded light drain\n",
+		// Counted from the last sample on 2026-10-11, not from today
+		"\ncamera-trigger-001 (motion)\n",
+		"  20.0 days until 3.40 V at the recorded load\n  Replace around 2026-10-31\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("battery did not print %q:\n%s", want, out)
+		}
+	}
+
+	// Each device is disconnected once its load is read
+	for name, d := range devices {
+		if d.conn.IsConnected() {
+			t.Errorf("%s left connected", name)
+		}
+	}
+}
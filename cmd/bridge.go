@@ -0,0 +1,403 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	bridgeMQTTCmd.Flags().StringVarP(&mqttBroker, "broker", "b", "tcp://localhost:1883", "MQTT broker, host:port or a tcp://, ssl:// or ws:// url")
+	bridgeMQTTCmd.Flags().StringVar(&mqttPrefix, "prefix", "camera-trigger", "Topic prefix")
+	bridgeMQTTCmd.Flags().StringVar(&mqttClientID, "client-id", "", "MQTT client id (default camera-trigger-bridge-DEVICE)")
+	bridgeMQTTCmd.Flags().StringVarP(&mqttUsername, "username", "u", "", "MQTT username")
+	bridgeMQTTCmd.Flags().StringVarP(&mqttPassword, "password", "p", "", "MQTT password")
+	bridgeMQTTCmd.Flags().DurationVar(&mqttKeepAlive, "keepalive", 30*time.Second, "MQTT keep alive interval")
+
+	bridgeCmd.AddCommand(bridgeMQTTCmd)
+	rootCmd.AddCommand(bridgeCmd)
+}
+
+var bridgeCmd = &cobra.Command{
+	Use:   "bridge",
+	Short: "Bridge the device to other systems",
+	Long:  "Bridge the device to other systems",
+}
+
+var bridgeMQTTCmd = &cobra.Command{
+	Use:   "mqtt",
+	Short: "Bridge the device to an MQTT broker",
+	Long: `Bridge the device to an MQTT broker
+
+Messages from the device are published as json using the field names of the
+bluetooth messages. PREFIX is set with --prefix.
+
+  PREFIX/DEVICE/availability   online or offline (retained)
+  PREFIX/DEVICE/status         every status message (retained)
+  PREFIX/DEVICE/trigger        motion crossing its threshold or the lights
+                               switching on
+  PREFIX/DEVICE/log            log entries received
+  PREFIX/DEVICE/response       the result of each command
+
+Commands are published to
+
+  PREFIX/DEVICE/cmd/get/NAME   read a named parameter
+  PREFIX/DEVICE/cmd/set/NAME   write a named parameter, payload the value
+  PREFIX/DEVICE/cmd/trigger    trigger the device, optional payload lux
+  PREFIX/DEVICE/cmd/config     json of MotionSensorConfigMessage or
+                               LightConfigMessage fields to change, e.g.
+                               {"MotionThreshold": 0.3}
+
+The broker connection is retried if lost.`,
+	Run: bridgeMQTT,
+}
+
+var (
+	mqttBroker    string
+	mqttPrefix    string
+	mqttClientID  string
+	mqttUsername  string
+	mqttPassword  string
+	mqttKeepAlive time.Duration
+)
+
+const (
+	mqttRetryInterval = 5 * time.Second
+	// mqttTimeout bounds the wait for the broker to accept a packet
+	mqttTimeout = 10 * time.Second
+	// mqttCommandQueue is the number of commands waiting for the device
+	// before further commands are refused
+	mqttCommandQueue = 16
+
+	// litCurrent is the light current above which the lights are on, in amps
+	litCurrent = 0.01
+)
+
+// mqttBridge publishes device messages and runs commands from the broker
+type mqttBridge struct {
+	board  *boards.Basic
+	device string
+	topic  string
+
+	client mqtt.Client
+	// commands queues messages from the cmd topic for runCommands, which
+	// sends them to the device one at a time, so the client's delivery
+	// goroutine never waits on the device
+	commands chan mqtt.Message
+
+	// Previous state for trigger detection
+	motionAbove bool
+	lightsOn    bool
+}
+
+// commandResponse is published to the response topic after each command
+type commandResponse struct {
+	Command  string      `json:"command"`
+	Response interface{} `json:"response,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// triggerEvent is published when the device is seen to trigger
+type triggerEvent struct {
+	Time   time.Time `json:"time"`
+	Board  string    `json:"board"`
+	Motion float32   `json:"motion,omitempty"`
+	Lux    float32   `json:"lux,omitempty"`
+	// Current is the light current once lit
+	Current float32 `json:"current,omitempty"`
+}
+
+func newMQTTBridge(board *boards.Basic, device string, clientID string) *mqttBridge {
+	b := &mqttBridge{
+		board:    board,
+		device:   device,
+		topic:    mqttPrefix + "/" + device,
+		commands: make(chan mqtt.Message, mqttCommandQueue),
+	}
+	b.client = mqtt.NewClient(b.clientOptions(clientID))
+	return b
+}
+
+func (b *mqttBridge) publish(topic string, v interface{}, retain bool) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	// Dropped while the broker is unreachable, status is republished with
+	// the next message
+	if !b.client.IsConnected() {
+		return
+	}
+	err = waitToken(b.client.Publish(b.topic+"/"+topic, 0, retain, payload))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// waitToken waits for the broker to accept a packet
+func waitToken(t mqtt.Token) error {
+	if !t.WaitTimeout(mqttTimeout) {
+		return fmt.Errorf("no response from broker")
+	}
+	return t.Error()
+}
+
+// handleMessage publishes status, trigger and log messages from the device
+func (b *mqttBridge) handleMessage(msg interface{}) error {
+	switch s := msg.(type) {
+	case messages.MotionSensorStatusMessage:
+		b.publish("status", s, true)
+
+		above := s.Motion >= s.MotionThreshold && s.MotionThreshold > 0
+		if above && !b.motionAbove {
+			b.publish("trigger", triggerEvent{
+				Time: time.Now(), Board: "motion", Motion: s.Motion, Lux: s.Lux,
+			}, false)
+		}
+		b.motionAbove = above
+	case messages.LightStatusMessage:
+		b.publish("status", s, true)
+
+		on := s.Payload.Current > litCurrent
+		if on && !b.lightsOn {
+			b.publish("trigger", triggerEvent{
+				Time: time.Now(), Board: "light", Current: s.Payload.Current,
+			}, false)
+		}
+		b.lightsOn = on
+	case messages.LogResponseMessage:
+		b.publish("log", s, false)
+	}
+	return nil
+}
+
+// queueCommand passes a message from the cmd topic to runCommands
+func (b *mqttBridge) queueCommand(client mqtt.Client, msg mqtt.Message) {
+	select {
+	case b.commands <- msg:
+	default:
+		command := strings.TrimPrefix(msg.Topic(), b.topic+"/cmd/")
+		b.publish("response", commandResponse{Command: command, Error: "too many commands queued"}, false)
+	}
+}
+
+// runCommands runs the queued commands in the order they arrived until stop
+// is closed
+func (b *mqttBridge) runCommands(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case msg := <-b.commands:
+			b.handleCommand(msg.Topic(), msg.Payload())
+		}
+	}
+}
+
+// handleCommand runs a command published under the cmd topic
+func (b *mqttBridge) handleCommand(topic string, payload []byte) {
+	command := strings.TrimPrefix(topic, b.topic+"/cmd/")
+
+	response, err := b.runCommand(command, strings.TrimSpace(string(payload)))
+
+	r := commandResponse{Command: command, Response: response}
+	if err != nil {
+		r.Error = err.Error()
+		log.Printf("%s: %s\n", command, err)
+	}
+	b.publish("response", r, false)
+}
+
+func (b *mqttBridge) runCommand(command string, payload string) (interface{}, error) {
+	if !b.board.IsConnected() {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	parts := strings.Split(command, "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "get":
+		if indx, persist, err := boards.FloatIndex(parts[1]); err == nil {
+			return b.board.GetFloat(indx, persist)
+		}
+		if indx, persist, err := boards.Uint16Index(parts[1]); err == nil {
+			return b.board.GetUint16(indx, persist)
+		}
+		return nil, fmt.Errorf("unknown parameter %q", parts[1])
+	case len(parts) == 2 && parts[0] == "set":
+		if indx, persist, err := boards.FloatIndex(parts[1]); err == nil {
+			value, err := strconv.ParseFloat(payload, 32)
+			if err != nil {
+				return nil, err
+			}
+			return b.board.SetFloat(indx, persist, float32(value))
+		}
+		if indx, persist, err := boards.Uint16Index(parts[1]); err == nil {
+			value, err := strconv.ParseUint(payload, 10, 16)
+			if err != nil {
+				return nil, err
+			}
+			return b.board.SetUint16(indx, persist, uint16(value))
+		}
+		return nil, fmt.Errorf("unknown parameter %q", parts[1])
+	case command == "trigger":
+		var lux float64
+		if payload != "" {
+			var err error
+			lux, err = strconv.ParseFloat(payload, 32)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err := b.board.Trigger(float32(lux))
+		if err != nil {
+			return nil, err
+		}
+		return messages.NewMotionSensorTriggerMessage(float32(lux)), nil
+	case command == "config":
+		return b.configure([]byte(payload))
+	}
+
+	return nil, fmt.Errorf("unknown command")
+}
+
+// configure sends a config message built from the current status with the
+// fields in payload changed
+func (b *mqttBridge) configure(payload []byte) (interface{}, error) {
+	switch s := b.board.Status().(type) {
+	case messages.MotionSensorStatusMessage:
+		msg := messages.NewMotionSensorConfigMessage(s.MotionThreshold,
+			s.LuxLowThreshold, s.LuxHighThreshold, s.Cooldown).(messages.MotionSensorConfigMessage)
+		header := msg
+		err := json.Unmarshal(payload, &msg)
+		if err != nil {
+			return nil, err
+		}
+		msg.Type, msg.Length = header.Type, header.Length
+		return msg, b.board.Send(msg)
+	case messages.LightStatusMessage:
+		msg := messages.NewLightConfigMessage(s.Payload.Level, s.Payload.Delay,
+			s.Payload.Attack, s.Payload.Sustain, s.Payload.Release).(messages.LightConfigMessage)
+		header := msg
+		err := json.Unmarshal(payload, &msg)
+		if err != nil {
+			return nil, err
+		}
+		msg.Type, msg.Length = header.Type, header.Length
+		return msg, b.board.Send(msg)
+	}
+
+	return nil, fmt.Errorf("no status received, board type unknown")
+}
+
+// onConnect announces the device and subscribes to commands each time the
+// client connects, as the broker forgets the subscription of a client which
+// reconnects with a clean session
+func (b *mqttBridge) onConnect(client mqtt.Client) {
+	log.Printf("Bridging %s to %s under %s\n", b.device, mqttBroker, b.topic)
+
+	err := waitToken(client.Publish(b.topic+"/availability", 0, true, "online"))
+	if err == nil {
+		err = waitToken(client.Subscribe(b.topic+"/cmd/#", 0, b.queueCommand))
+	}
+	if err != nil {
+		log.Printf("%s: %s\n", mqttBroker, err)
+	}
+}
+
+// brokerURL adds the tcp scheme to a host:port broker address
+func brokerURL(broker string) string {
+	if strings.HasPrefix(broker, "mqtt://") {
+		return "tcp://" + strings.TrimPrefix(broker, "mqtt://")
+	}
+	if !strings.Contains(broker, "://") {
+		return "tcp://" + broker
+	}
+	return broker
+}
+
+func (b *mqttBridge) clientOptions(clientID string) *mqtt.ClientOptions {
+	return mqtt.NewClientOptions().
+		AddBroker(brokerURL(mqttBroker)).
+		SetClientID(clientID).
+		SetUsername(mqttUsername).
+		SetPassword(mqttPassword).
+		SetKeepAlive(mqttKeepAlive).
+		SetBinaryWill(b.topic+"/availability", []byte("offline"), 0, true).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(mqttRetryInterval).
+		SetOnConnectHandler(b.onConnect).
+		SetConnectionLostHandler(func(client mqtt.Client, err error) {
+			log.Printf("%s: connection lost, %s\n", mqttBroker, err)
+		})
+}
+
+// run connects to the broker, retrying until it is reached, and bridges the
+// device until interrupted. The client reconnects by itself once connected.
+func (b *mqttBridge) run(interrupt <-chan os.Signal) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go b.runCommands(stop)
+
+	for {
+		err := waitToken(b.client.Connect())
+		if err == nil {
+			break
+		}
+		log.Printf("%s: %s, retrying in %s\n", mqttBroker, err, mqttRetryInterval)
+		select {
+		case <-interrupt:
+			return
+		case <-time.After(mqttRetryInterval):
+		}
+	}
+
+	<-interrupt
+
+	err := waitToken(b.client.Publish(b.topic+"/availability", 0, true, "offline"))
+	if err != nil {
+		log.Println(err)
+	}
+	b.client.Disconnect(250)
+}
+
+func bridgeMQTT(cmd *cobra.Command, args []string) {
+	if deviceID == "" {
+		log.Println("--device is required")
+		return
+	}
+
+	clientID := mqttClientID
+	if clientID == "" {
+		clientID = "camera-trigger-bridge-" + deviceID
+	}
+
+	m := boards.Basic{}
+	b := newMQTTBridge(&m, deviceID, clientID)
+	m.SetMessageCallback(b.handleMessage)
+
+	err := m.Init(deviceID, debug)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer m.Close()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	b.run(interrupt)
+	log.Println("Done")
+}
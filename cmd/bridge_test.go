@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+// fakeBroker accepts MQTT clients, acknowledging their packets and passing
+// on what they publish and subscribe to
+type fakeBroker struct {
+	listener   net.Listener
+	published  chan *packets.PublishPacket
+	subscribed chan string
+
+	mutex sync.Mutex
+	conn  net.Conn
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	b := &fakeBroker{
+		listener:   listener,
+		published:  make(chan *packets.PublishPacket, 64),
+		subscribed: make(chan string, 4),
+	}
+	go b.accept()
+	return b
+}
+
+func (b *fakeBroker) accept() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.serve(conn)
+	}
+}
+
+func (b *fakeBroker) write(conn net.Conn, p packets.ControlPacket) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	_ = p.Write(conn)
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		p, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch p := p.(type) {
+		case *packets.ConnectPacket:
+			b.mutex.Lock()
+			b.conn = conn
+			b.mutex.Unlock()
+			b.write(conn, packets.NewControlPacket(packets.Connack))
+		case *packets.SubscribePacket:
+			ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID = p.MessageID
+			ack.ReturnCodes = p.Qoss
+			b.write(conn, ack)
+			for _, topic := range p.Topics {
+				b.subscribed <- topic
+			}
+		case *packets.PublishPacket:
+			b.published <- p
+		case *packets.PingreqPacket:
+			b.write(conn, packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			return
+		}
+	}
+}
+
+// send publishes to the connected client
+func (b *fakeBroker) send(topic string, payload string) {
+	p := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	p.TopicName = topic
+	p.Payload = []byte(payload)
+
+	b.mutex.Lock()
+	conn := b.conn
+	b.mutex.Unlock()
+	b.write(conn, p)
+}
+
+// expect returns the next packet published to topic
+func (b *fakeBroker) expect(t *testing.T, topic string) *packets.PublishPacket {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case p := <-b.published:
+			if p.TopicName == topic {
+				return p
+			}
+		case <-timeout:
+			t.Fatalf("nothing published to %s", topic)
+		}
+	}
+}
+
+func TestBridgeMQTT(t *testing.T) {
+	broker := newFakeBroker(t)
+	mqttBroker = broker.listener.Addr().String()
+	mqttPrefix = "camera-trigger"
+	mqttKeepAlive = 30 * time.Second
+
+	device := newMotionDevice(messages.MotionSensorStatusMessage{Motion: 0.125, MotionThreshold: 0.5})
+	device.statusEvery(t, 20*time.Millisecond)
+
+	m := &boards.Basic{}
+	b := newMQTTBridge(m, "fake", "test-bridge")
+	m.SetMessageCallback(b.handleMessage)
+	m.SetTransport(device.conn)
+	err := m.Init("fake", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	interrupt := make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		b.run(interrupt)
+		close(done)
+	}()
+
+	p := broker.expect(t, "camera-trigger/fake/availability")
+	if string(p.Payload) != "online" || !p.Retain {
+		t.Errorf("availability %q retained %v", p.Payload, p.Retain)
+	}
+
+	p = broker.expect(t, "camera-trigger/fake/status")
+	var status messages.MotionSensorStatusMessage
+	if err = json.Unmarshal(p.Payload, &status); err != nil || status.Motion != 0.125 || !p.Retain {
+		t.Errorf("status %s retained %v", p.Payload, p.Retain)
+	}
+
+	select {
+	case topic := <-broker.subscribed:
+		if topic != "camera-trigger/fake/cmd/#" {
+			t.Fatalf("subscribed to %s", topic)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no subscription to commands")
+	}
+
+	var response struct {
+		Command  string
+		Response messages.SetFloatResponse
+		Error    string
+	}
+	broker.send("camera-trigger/fake/cmd/set/motion_threshold", "0.35")
+	p = broker.expect(t, "camera-trigger/fake/response")
+	if err = json.Unmarshal(p.Payload, &response); err != nil ||
+		response.Command != "set/motion_threshold" || response.Error != "" || response.Response.Value != 0.35 {
+		t.Errorf("response %s", p.Payload)
+	}
+	indx, persist, _ := boards.FloatIndex("motion_threshold")
+	device.mutex.Lock()
+	if v := device.floats[paramKey{indx, persist}]; v != 0.35 {
+		t.Errorf("device motion_threshold %v, want 0.35", v)
+	}
+	device.mutex.Unlock()
+
+	broker.send("camera-trigger/fake/cmd/reboot", "")
+	p = broker.expect(t, "camera-trigger/fake/response")
+	response.Error = ""
+	if err = json.Unmarshal(p.Payload, &response); err != nil || response.Error != "unknown command" {
+		t.Errorf("response %s", p.Payload)
+	}
+
+	close(interrupt)
+	p = broker.expect(t, "camera-trigger/fake/availability")
+	if string(p.Payload) != "offline" || !p.Retain {
+		t.Errorf("availability %q retained %v on exit", p.Payload, p.Retain)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("bridge did not stop")
+	}
+}
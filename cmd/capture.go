@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/capture"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	captureCmd.AddCommand(capturePcapCmd)
+	captureCmd.AddCommand(captureDissectorCmd)
+	rootCmd.AddCommand(captureCmd)
+}
+
+var captureCmd = &cobra.Command{
+	Use:   "capture",
+	Short: "Convert captures made with --record",
+	Long:  "Convert captures made with --record",
+}
+
+var capturePcapCmd = &cobra.Command{
+	Use:   "pcap CAPTURE OUTPUT",
+	Short: "Export a capture as a pcap file for Wireshark",
+	Long: `Export a capture as a pcap file for Wireshark
+
+Each chunk of bytes becomes a packet of link type USER0 (147) prefixed with
+its direction and device name. Open the file with the dissector written by
+capture dissector installed to see the messages in each packet. With
+--device only the traffic of that device is exported.`,
+	Args: cobra.ExactArgs(2),
+	Run:  capturePcap,
+}
+
+var captureDissectorCmd = &cobra.Command{
+	Use:   "dissector [OUTPUT]",
+	Short: "Write a Wireshark Lua dissector for exported captures",
+	Long: `Write a Wireshark Lua dissector for exported captures
+
+The dissector is generated from the message definitions and decodes every
+message type. Copy it to the Wireshark personal plugins folder. It is
+written to stdout if OUTPUT is not given.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  captureDissector,
+}
+
+func capturePcap(cmd *cobra.Command, args []string) {
+	records, err := capture.ReadFile(args[0])
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if deviceID != "" {
+		var selected []capture.Record
+		for _, r := range records {
+			if strings.EqualFold(r.Device, deviceID) {
+				selected = append(selected, r)
+			}
+		}
+		records = selected
+	}
+
+	f, err := os.Create(args[1])
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer f.Close()
+
+	err = capture.WritePcap(f, records)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("Wrote %d packets to %s\n", len(records), args[1])
+}
+
+func captureDissector(cmd *cobra.Command, args []string) {
+	var w io.Writer = os.Stdout
+	if len(args) == 1 {
+		f, err := os.Create(args[0])
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		defer f.Close()
+		w = f
+	}
+
+	err := capture.WriteDissector(w)
+	if err != nil {
+		log.Println(err)
+	}
+}
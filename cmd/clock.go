@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	clockCmd.Flags().BoolVarP(&utc, "utc", "u", false, "Device clock is kept in UTC rather than local time")
+	clockCmd.Flags().IntVarP(&clockSamples, "samples", "n", 3, "Number of status messages to measure")
+	clockCmd.Flags().DurationVar(&clockMaxDrift, "max-drift", 2*time.Second, "Drift allowed before the clock is considered wrong")
+	clockCmd.Flags().BoolVar(&clockFix, "fix", false, "Set the device clock if drift exceeds --max-drift")
+
+	rootCmd.AddCommand(clockCmd)
+}
+
+var clockCmd = &cobra.Command{
+	Use:   "clock",
+	Short: "Report device clock drift",
+	Long: `Report device clock drift
+
+Compares the timestamp in status messages from the device with the host
+clock. With --fix the device clock is set when the drift exceeds --max-drift.`,
+	Run: clock,
+}
+
+var (
+	clockSamples  int
+	clockMaxDrift time.Duration
+	clockFix      bool
+)
+
+// measureLatency estimates the one way latency to the device, falling back to
+// no compensation if the device does not answer
+func measureLatency(m *boards.Basic) time.Duration {
+	latency, err := m.MeasureLatency(3)
+	if err != nil {
+		fmt.Printf("Unable to measure latency: %s\n", err)
+		return 0
+	}
+
+	fmt.Printf("Latency %v\n", latency.Round(time.Millisecond))
+	return latency
+}
+
+// measureDrift averages the device clock offset over several status messages
+func measureDrift(m *boards.Basic, samples int, latency time.Duration) (time.Duration, error) {
+	var total time.Duration
+
+	for i := 0; i < samples; i++ {
+		err := m.WaitForNextStatus(10 * time.Second)
+		if err != nil {
+			return 0, err
+		}
+
+		offset, err := m.ClockOffset(deviceLocation(), latency)
+		if err != nil {
+			return 0, err
+		}
+
+		fmt.Printf("Device %s, offset %v\n", m.Timestamp(), offset.Round(time.Millisecond))
+		total += offset
+	}
+
+	return total / time.Duration(samples), nil
+}
+
+func clock(cmd *cobra.Command, args []string) {
+	if clockSamples < 1 {
+		log.Println("--samples must be at least 1")
+		return
+	}
+
+	m := boards.Basic{}
+
+	err := m.Init(deviceID, debug)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer m.Close()
+
+	for !m.IsConnected() {
+	}
+
+	latency := measureLatency(&m)
+
+	drift, err := measureDrift(&m, clockSamples, latency)
+	if err != nil && !clockFix {
+		log.Println(err)
+		return
+	}
+
+	if err == nil {
+		fmt.Printf("Host %s, drift %v\n", time.Now().In(deviceLocation()).Format("2006-01-02 15:04:05"),
+			drift.Round(time.Millisecond))
+		if drift <= clockMaxDrift && drift >= -clockMaxDrift {
+			log.Println("Done")
+			return
+		}
+		fmt.Printf("Drift exceeds %v\n", clockMaxDrift)
+	} else {
+		fmt.Printf("Unable to measure drift: %s\n", err)
+	}
+
+	if !clockFix {
+		log.Println("Done")
+		return
+	}
+
+	cal, err := m.SyncTime(deviceLocation(), latency)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	fmt.Printf("Set device clock to %s\n", cal)
+
+	// Skip a status message which may have been sent before the clock was set
+	m.WaitForNextStatus(10 * time.Second)
+
+	drift, err = measureDrift(&m, 1, latency)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	fmt.Printf("Drift after setting %v\n", drift.Round(time.Millisecond))
+
+	log.Println("Done")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+// setTimes counts the SetTime messages sent to the device
+func setTimes(t *testing.T, device *fakeDevice) int {
+	n := 0
+	for _, msg := range device.sent(t) {
+		if _, ok := msg.(messages.SetTimeMessage); ok {
+			n++
+		}
+	}
+	return n
+}
+
+func TestClock(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	device.setClock(0)
+	device.statusEvery(t, 20*time.Millisecond)
+
+	out := execute(t, device, "clock", "-n", "2")
+	if !strings.Contains(out, ", drift ") || strings.Contains(out, "Drift exceeds") {
+		t.Errorf("clock of a device on time printed %s", out)
+	}
+
+	// Drift is only reported without --fix
+	device.setClock(-time.Hour)
+	out = execute(t, device, "clock", "-n", "1")
+	if !strings.Contains(out, "Drift exceeds 2s\n") {
+		t.Errorf("clock of a device an hour slow printed %s", out)
+	}
+	if n := setTimes(t, device); n != 0 {
+		t.Errorf("clock without --fix sent %d SetTime messages", n)
+	}
+
+	out = execute(t, device, "clock", "-n", "1", "--fix")
+	if !strings.Contains(out, "Set device clock to") {
+		t.Errorf("clock --fix printed %s", out)
+	}
+	if n := setTimes(t, device); n != 1 {
+		t.Errorf("clock --fix sent %d SetTime messages, want 1", n)
+	}
+	device.mutex.Lock()
+	if clock := *device.clock; clock < -2*time.Second || clock > 2*time.Second {
+		t.Errorf("clock offset %v after clock --fix", clock)
+	}
+	device.mutex.Unlock()
+}
@@ -0,0 +1,452 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type paramKey struct {
+	id      uint16
+	persist uint8
+}
+
+// fakeDevice models the firmware of a motion or light board. It sends its
+// status on connecting and in reply to configuration and triggers, and
+// answers parameter and log requests from its tables.
+type fakeDevice struct {
+	conn *connection.Fake
+
+	mutex    sync.Mutex
+	motion   *messages.MotionSensorStatusMessage
+	light    *messages.LightStatusMessage
+	floats   map[paramKey]float32
+	uint16s  map[paramKey]uint16
+	triggers []float32
+	// clock is the offset of the device clock from the host, nil until set
+	clock *time.Duration
+	// log holds the device log, its length reported in the status
+	log []messages.LogResponseMessage
+	// onReceive, if set, is called with each message received before it is
+	// handled, the mutex held
+	onReceive func(msg interface{})
+}
+
+func newFakeDevice() *fakeDevice {
+	d := &fakeDevice{
+		floats:  make(map[paramKey]float32),
+		uint16s: make(map[paramKey]uint16),
+	}
+	d.conn = &connection.Fake{Connected: d.status, Respond: d.respond}
+	return d
+}
+
+func newMotionDevice(status messages.MotionSensorStatusMessage) *fakeDevice {
+	info, _ := messages.TypeByName("MotionSensorStatus")
+	status.Type = info.Code
+	status.Length = uint8(info.Size())
+
+	d := newFakeDevice()
+	d.motion = &status
+	return d
+}
+
+func newLightDevice(status messages.LightStatusMessage) *fakeDevice {
+	status.BasicMessage = header("LightStatus")
+
+	d := newFakeDevice()
+	d.light = &status
+	return d
+}
+
+func header(name string) messages.BasicMessage {
+	info, _ := messages.TypeByName(name)
+	return messages.BasicMessage{Type: info.Code, Length: uint8(info.Size())}
+}
+
+func frame(msg interface{}) [][]byte {
+	buf, err := messages.WriteMessage(msg)
+	if err != nil {
+		panic(err)
+	}
+	return [][]byte{buf.Bytes()}
+}
+
+func (d *fakeDevice) status() [][]byte {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.statusLocked()
+}
+
+func (d *fakeDevice) statusLocked() [][]byte {
+	var timestamp messages.Calendar
+	if d.clock != nil {
+		timestamp.FromTime(time.Now().Add(*d.clock))
+	}
+
+	if d.motion != nil {
+		d.motion.Timestamp = timestamp
+		d.motion.LogEntries = uint16(len(d.log))
+		return frame(*d.motion)
+	}
+	d.light.Timestamp = timestamp
+	d.light.Payload.LogEntries = uint16(len(d.log))
+	return frame(*d.light)
+}
+
+// addLog appends n entries to the device log
+func (d *fakeDevice) addLog(n int) {
+	d.mutex.Lock()
+	d.addLogLocked(n)
+	d.mutex.Unlock()
+}
+
+func (d *fakeDevice) addLogLocked(n int) {
+	for i := 0; i < n; i++ {
+		index := uint16(len(d.log))
+		var timestamp messages.Calendar
+		timestamp.FromTime(time.Date(2021, 6, 1, 12, 0, int(index), 0, time.Local))
+		d.log = append(d.log, messages.LogResponseMessage{
+			Type:      header("LogResponse").Type,
+			Length:    header("LogResponse").Length,
+			Index:     index,
+			Timestamp: timestamp,
+			LogType:   1,
+			Payload:   [13]byte{byte(index)},
+		})
+	}
+}
+
+// setClock sets the device clock offset from the host
+func (d *fakeDevice) setClock(offset time.Duration) {
+	d.mutex.Lock()
+	d.clock = &offset
+	d.mutex.Unlock()
+}
+
+// statusEvery sends the status every interval while connected, as the
+// firmware does, until the test ends
+func (d *fakeDevice) statusEvery(t *testing.T, interval time.Duration) {
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				d.conn.Receive(d.status()...)
+			}
+		}
+	}()
+}
+
+func (d *fakeDevice) respond(b []byte) [][]byte {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	info, ok := messages.TypeByCode(b[0])
+	if !ok {
+		return nil
+	}
+	msg, err := info.Decode(b)
+	if err != nil {
+		return nil
+	}
+	if d.onReceive != nil {
+		d.onReceive(msg)
+	}
+
+	switch msg := msg.(type) {
+	case messages.MotionSensorConfigMessage:
+		if d.motion == nil {
+			return nil
+		}
+		d.motion.MotionThreshold = msg.MotionThreshold
+		d.motion.LuxLowThreshold = msg.LuxLowThreshold
+		d.motion.LuxHighThreshold = msg.LuxHighThreshold
+		d.motion.Cooldown = msg.Cooldown
+		return d.statusLocked()
+	case messages.LightConfigMessage:
+		if d.light == nil {
+			return nil
+		}
+		d.light.Payload.Level = msg.Level
+		d.light.Payload.Delay = msg.Delay
+		d.light.Payload.Attack = msg.Attack
+		d.light.Payload.Sustain = msg.Sustain
+		d.light.Payload.Release = msg.Release
+		return d.statusLocked()
+	case messages.SetTimeMessage:
+		offset := msg.Timestamp.Time(time.Local).Sub(time.Now())
+		d.clock = &offset
+		return nil
+	case messages.MotionSensorTriggerMessage:
+		d.triggers = append(d.triggers, msg.Lux)
+		return d.statusLocked()
+	case messages.LogRequestMessage:
+		if int(msg.Index) >= len(d.log) {
+			return nil
+		}
+		return frame(d.log[msg.Index])
+	case messages.LogResetMessage:
+		// The reset is seen in the next periodic status
+		d.log = nil
+		return nil
+	case messages.GetFloatRequest:
+		value, ok := d.floats[paramKey{msg.Id, msg.Persist}]
+		return frame(messages.GetFloatResponse{
+			BasicMessage: header("GetFloatResponse"),
+			Success:      success(ok),
+			Id:           msg.Id,
+			Persist:      msg.Persist,
+			Value:        value,
+		})
+	case messages.SetFloatRequest:
+		d.floats[paramKey{msg.Id, msg.Persist}] = msg.Value
+		return frame(messages.SetFloatResponse{
+			BasicMessage: header("SetFloatResponse"),
+			Success:      1,
+			Id:           msg.Id,
+			Persist:      msg.Persist,
+			Value:        msg.Value,
+		})
+	case messages.GetUint16Request:
+		value, ok := d.uint16s[paramKey{msg.Id, msg.Persist}]
+		return frame(messages.GetUint16Response{
+			BasicMessage: header("GetUint16Response"),
+			Success:      success(ok),
+			Id:           msg.Id,
+			Persist:      msg.Persist,
+			Value:        value,
+		})
+	case messages.SetUint16Request:
+		d.uint16s[paramKey{msg.Id, msg.Persist}] = msg.Value
+		return frame(messages.SetUint16Response{
+			BasicMessage: header("SetUint16Response"),
+			Success:      1,
+			Id:           msg.Id,
+			Persist:      msg.Persist,
+			Value:        msg.Value,
+		})
+	}
+	return nil
+}
+
+func success(ok bool) uint8 {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+// sent decodes the frames written to the device
+func (d *fakeDevice) sent(t *testing.T) []interface{} {
+	var sent []interface{}
+	for _, b := range d.conn.Written() {
+		info, ok := messages.TypeByCode(b[0])
+		if !ok {
+			t.Fatalf("unknown frame written: % x", b)
+		}
+		msg, err := info.Decode(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sent = append(sent, msg)
+	}
+	return sent
+}
+
+// resetFlags returns the flags of cmd and its subcommands to their defaults
+// so each test starts from a fresh command line
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if v, ok := f.Value.(pflag.SliceValue); ok {
+			_ = v.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
+// captureStdout returns what fn prints to stdout. Log output is shown if
+// the test fails.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(&out, r)
+		close(copied)
+	}()
+
+	var logs bytes.Buffer
+	stdout := os.Stdout
+	os.Stdout = w
+	log.SetOutput(&logs)
+	defer func() {
+		os.Stdout = stdout
+		log.SetOutput(os.Stderr)
+		_ = w.Close()
+		<-copied
+		if t.Failed() && logs.Len() > 0 {
+			t.Logf("log:\n%s", logs.String())
+		}
+	}()
+
+	fn()
+
+	_ = w.Close()
+	<-copied
+	return out.String()
+}
+
+// execute runs the command line against device and returns what it printed
+// to stdout
+func execute(t *testing.T, device *fakeDevice, args ...string) string {
+	t.Helper()
+
+	return run(t, func() connection.Transport {
+		return device.conn
+	}, append([]string{"--device", "fake"}, args...)...)
+}
+
+// siteTransport connects to the device of a site with the name given to Init
+type siteTransport struct {
+	*connection.Fake
+	devices map[string]*fakeDevice
+	timeout time.Duration
+}
+
+// SetConnectTimeout limits the search for a device which is not present,
+// which like bluetooth never ends without a timeout
+func (s *siteTransport) SetConnectTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+func (s *siteTransport) Init(device string, callback func(b []byte) error, debug bool) error {
+	d, ok := s.devices[device]
+	if !ok {
+		if s.timeout == 0 {
+			select {}
+		}
+		return fmt.Errorf("%s: not found", device)
+	}
+	s.Fake = d.conn
+	return s.Fake.Init(device, callback, debug)
+}
+
+func (s *siteTransport) IsConnected() bool {
+	return s.Fake != nil && s.Fake.IsConnected()
+}
+
+func (s *siteTransport) Stop() {
+	if s.Fake != nil {
+		s.Fake.Stop()
+	}
+}
+
+// executeSite runs the command line against the named devices, scanning
+// finds them all, and returns what it printed to stdout
+func executeSite(t *testing.T, devices map[string]*fakeDevice, args ...string) string {
+	t.Helper()
+
+	scan := scanDevices
+	scanDevices = func() (map[string]connection.Device, error) {
+		found := make(map[string]connection.Device)
+		for i, name := range sortedNames(devices) {
+			found[name] = connection.Device{
+				Name:    name,
+				Address: fmt.Sprintf("c4:7c:8d:6a:12:%02x", i+1),
+				RSSI:    -60 - i,
+			}
+		}
+		return found, nil
+	}
+	defer func() {
+		scanDevices = scan
+	}()
+
+	return run(t, func() connection.Transport {
+		return &siteTransport{devices: devices}
+	}, args...)
+}
+
+func sortedNames(devices map[string]*fakeDevice) []string {
+	var names []string
+	for name := range devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// run runs the command line with boards connecting through newTransport and
+// returns what it printed to stdout
+func run(t *testing.T, newTransport func() connection.Transport, args ...string) string {
+	t.Helper()
+
+	out, err := runErr(t, newTransport, args...)
+	if err != nil {
+		t.Error(err)
+	}
+	return out
+}
+
+// runErr is run for a command line expected to fail, returning the error
+func runErr(t *testing.T, newTransport func() connection.Transport, args ...string) (string, error) {
+	t.Helper()
+
+	transport := boards.NewTransport
+	boards.NewTransport = newTransport
+	defer func() {
+		boards.NewTransport = transport
+	}()
+
+	resetFlags(rootCmd)
+	rootCmd.SetArgs(args)
+	// The error is returned rather than printed
+	rootCmd.SetErr(ioutil.Discard)
+
+	var err error
+	out := captureStdout(t, func() {
+		done := make(chan error, 1)
+		go func() {
+			done <- rootCmd.Execute()
+		}()
+
+		select {
+		case err = <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%v did not finish", args)
+		}
+	})
+	return out, err
+}
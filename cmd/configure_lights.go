@@ -3,8 +3,9 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"os"
 	"reflect"
-	"time"
+	"sync"
 
 	"github.com/phelpsw/camera-trigger-bt-cli/boards"
 	"github.com/spf13/cobra"
@@ -13,8 +14,13 @@ import (
 var cfgLightsCmd = &cobra.Command{
 	Use:   "cfglights",
 	Short: "Configure Lights",
-	Long:  "Configure Lights",
-	Run:   configLights,
+	Long: `Configure Lights
+
+Use --preview to plot the brightness envelope the current settings and the
+proposed flags produce, along with the on time and light output of each
+trigger, without changing the device.`,
+	RunE:         configLights,
+	SilenceUsage: true,
 }
 
 var trgLightsCmd = &cobra.Command{
@@ -25,8 +31,6 @@ var trgLightsCmd = &cobra.Command{
 }
 
 var (
-	lightsDone = make(chan struct{})
-
 	level         float32
 	levelUpdate   bool = false
 	delay         float32
@@ -38,7 +42,8 @@ var (
 	release       float32
 	releaseUpdate bool = false
 
-	triggered bool = false
+	lightsPreview bool
+	lightsSVG     string
 )
 
 func init() {
@@ -49,119 +54,208 @@ func init() {
 	cfgLightsCmd.Flags().Float32VarP(&attack, "attack", "a", 0, "Light ramp up period in seconds")
 	cfgLightsCmd.Flags().Float32VarP(&sustain, "sustain", "s", 0, "Light on period in seconds")
 	cfgLightsCmd.Flags().Float32VarP(&release, "release", "r", 0, "Light ramp down period in seconds")
+	cfgLightsCmd.Flags().BoolVarP(&lightsPreview, "preview", "p", false, "Show the current and proposed brightness envelope without applying it")
+	cfgLightsCmd.Flags().StringVar(&lightsSVG, "svg", "", "With --preview, also write the envelopes to this svg file")
 
 	rootCmd.AddCommand(trgLightsCmd)
 }
 
-func configLightsHandler(b interface{}) error {
-	switch b.(type) {
-	case *boards.Light:
-		m := b.(*boards.Light)
+// configLightsHandler returns an update callback which applies the flags
+// given and closes done once the device reports them
+func configLightsHandler(done chan struct{}) func(interface{}) error {
+	var once sync.Once
+	return func(b interface{}) error {
+		switch b.(type) {
+		case *boards.Light:
+			m := b.(*boards.Light)
 
-		fmt.Println(m.Level(), m.Delay(), m.Attack(), m.Sustain(), m.Release())
+			fmt.Println(m.Level(), m.Delay(), m.Attack(), m.Sustain(), m.Release())
 
-		if levelUpdate && m.Level() != level {
-			err := m.SetLevel(level, false)
-			if err != nil {
-				return err
+			if levelUpdate && m.Level() != level {
+				err := m.SetLevel(level, false)
+				if err != nil {
+					return err
+				}
 			}
-		}
 
-		if delayUpdate && m.Delay() != delay {
-			err := m.SetDelay(delay, false)
-			if err != nil {
-				return err
+			if delayUpdate && m.Delay() != delay {
+				err := m.SetDelay(delay, false)
+				if err != nil {
+					return err
+				}
 			}
-		}
 
-		if attackUpdate && m.Attack() != attack {
-			err := m.SetAttack(attack, false)
-			if err != nil {
-				return err
+			if attackUpdate && m.Attack() != attack {
+				err := m.SetAttack(attack, false)
+				if err != nil {
+					return err
+				}
 			}
-		}
 
-		if sustainUpdate && m.Sustain() != sustain {
-			err := m.SetSustain(sustain, false)
-			if err != nil {
-				return err
+			if sustainUpdate && m.Sustain() != sustain {
+				err := m.SetSustain(sustain, false)
+				if err != nil {
+					return err
+				}
 			}
-		}
 
-		if releaseUpdate && m.Release() != release {
-			err := m.SetRelease(release, false)
-			if err != nil {
-				return err
+			if releaseUpdate && m.Release() != release {
+				err := m.SetRelease(release, false)
+				if err != nil {
+					return err
+				}
 			}
-		}
 
-		go m.Sync()
+			go m.Sync()
+
+			if m.IsSynced() {
+				once.Do(func() { close(done) })
+			}
 
-		if m.IsSynced() {
-			close(lightsDone)
+		default:
+			return fmt.Errorf("unknown type %+v", reflect.TypeOf(b))
 		}
+		return nil
+	}
+}
 
-	default:
-		return fmt.Errorf("unknown type %+v", reflect.TypeOf(b))
+// proposedEnvelope applies the flags given on the command line to current
+func proposedEnvelope(current boards.Envelope) boards.Envelope {
+	proposed := current
+	if levelUpdate {
+		proposed.Level = level
 	}
-	return nil
+	if delayUpdate {
+		proposed.Delay = delay
+	}
+	if attackUpdate {
+		proposed.Attack = attack
+	}
+	if sustainUpdate {
+		proposed.Sustain = sustain
+	}
+	if releaseUpdate {
+		proposed.Release = release
+	}
+	return proposed
 }
 
-func triggerLightsHandler(b interface{}) error {
-	switch b.(type) {
-	case *boards.Light:
-		if !triggered {
+// previewLightsHandler returns an update callback which prints the current
+// and proposed envelopes once and closes done, setting result if the svg
+// could not be written
+func previewLightsHandler(done chan struct{}, result *error) func(interface{}) error {
+	var once sync.Once
+	return func(b interface{}) error {
+		switch b.(type) {
+		case *boards.Light:
+			// The callback is cleared, so done must be closed on every path
+			defer once.Do(func() { close(done) })
+
 			m := b.(*boards.Light)
+			m.SetUpdateCallback(nil)
 
-			err := m.Trigger(0)
-			triggered = true
-			if err != nil {
-				return err
+			current := m.Envelope()
+			proposed := proposedEnvelope(current)
+			envelopes := []namedEnvelope{
+				{"current", current},
+				{"proposed", proposed},
+			}
+
+			fmt.Println()
+			plotEnvelopes(os.Stdout, envelopes)
+			fmt.Println()
+			printEnvelope(os.Stdout, "Current", current)
+			printEnvelope(os.Stdout, "Proposed", proposed)
+			if current.Energy() > 0 {
+				fmt.Printf("Proposed output is %.0f%% of current\n",
+					100*proposed.Energy()/current.Energy())
 			}
-		} else {
-			time.Sleep(1)
-			close(lightsDone)
-		}
 
-	default:
-		return fmt.Errorf("unknown type %+v", reflect.TypeOf(b))
+			if lightsSVG != "" {
+				err := writeEnvelopeSVG(lightsSVG, envelopes)
+				if err != nil {
+					*result = err
+					return err
+				}
+				fmt.Printf("Wrote %s\n", lightsSVG)
+			}
+		default:
+			return fmt.Errorf("unknown type %+v", reflect.TypeOf(b))
+		}
+		return nil
 	}
+}
 
-	return nil
+// triggerLightsHandler returns an update callback which triggers the lights
+// on the first status and closes done on the next
+func triggerLightsHandler(done chan struct{}) func(interface{}) error {
+	var once sync.Once
+	triggered := false
+	return func(b interface{}) error {
+		switch b.(type) {
+		case *boards.Light:
+			if !triggered {
+				m := b.(*boards.Light)
+
+				err := m.Trigger(0)
+				triggered = true
+				if err != nil {
+					return err
+				}
+			} else {
+				once.Do(func() { close(done) })
+			}
+
+		default:
+			return fmt.Errorf("unknown type %+v", reflect.TypeOf(b))
+		}
+
+		return nil
+	}
 }
 
-func configLights(cmd *cobra.Command, args []string) {
+func configLights(cmd *cobra.Command, args []string) error {
 	levelUpdate = cmd.Flags().Changed("level")
 	delayUpdate = cmd.Flags().Changed("delay")
 	attackUpdate = cmd.Flags().Changed("attack")
 	sustainUpdate = cmd.Flags().Changed("sustain")
 	releaseUpdate = cmd.Flags().Changed("release")
 
+	done := make(chan struct{})
+	var previewErr error
 	m := boards.Light{}
+	if lightsPreview {
+		m.SetUpdateCallback(previewLightsHandler(done, &previewErr))
+	} else {
+		m.SetUpdateCallback(configLightsHandler(done))
+	}
 
 	err := m.Init(deviceID, debug)
 	if err != nil {
-		log.Panicln(err)
-		return
+		return err
 	}
+	defer m.Close()
 
-	m.SetUpdateCallback(configLightsHandler)
-
-	<-lightsDone
+	<-done
+	if previewErr != nil {
+		return previewErr
+	}
 	log.Println("Done")
+	return nil
 }
 
 func triggerLights(cmd *cobra.Command, args []string) {
+	done := make(chan struct{})
 	m := boards.Light{}
+	m.SetUpdateCallback(triggerLightsHandler(done))
 
 	err := m.Init(deviceID, debug)
 	if err != nil {
-		log.Panicln(err)
+		log.Println(err)
 		return
 	}
+	defer m.Close()
 
-	m.SetUpdateCallback(triggerLightsHandler)
-
-	<-lightsDone
+	<-done
 	log.Println("Done")
 }
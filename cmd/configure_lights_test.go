@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestConfigLights(t *testing.T) {
+	device := newLightDevice(messages.LightStatusMessage{
+		Payload: messages.LightStatus{
+			Level:   0.8,
+			Delay:   1,
+			Attack:  2,
+			Sustain: 10,
+			Release: 4,
+		},
+	})
+
+	out := execute(t, device, "cfglights", "--level", "0.5", "--sustain", "5")
+	if want := "0.8 1 2 10 4\n0.5 1 2 5 4\n"; out != want {
+		t.Errorf("printed %q, want %q", out, want)
+	}
+
+	sent := device.sent(t)
+	if len(sent) != 1 {
+		t.Fatalf("sent %+v, want one configuration", sent)
+	}
+	info, _ := messages.TypeByName("LightConfig")
+	want := messages.LightConfigMessage{
+		Type:    info.Code,
+		Length:  uint8(info.Size()),
+		Level:   0.5,
+		Delay:   1,
+		Attack:  2,
+		Sustain: 5,
+		Release: 4,
+	}
+	if sent[0] != want {
+		t.Errorf("sent %+v, want %+v", sent[0], want)
+	}
+}
+
+func TestTriggerLights(t *testing.T) {
+	device := newLightDevice(messages.LightStatusMessage{})
+
+	execute(t, device, "triggerlights")
+
+	sent := device.sent(t)
+	if len(sent) != 1 {
+		t.Fatalf("sent %+v, want one trigger", sent)
+	}
+	if _, ok := sent[0].(messages.MotionSensorTriggerMessage); !ok {
+		t.Errorf("sent %+v, want a trigger", sent[0])
+	}
+
+	// A second run triggers again rather than finishing straight away
+	execute(t, device, "triggerlights")
+	if len(device.triggers) != 2 {
+		t.Errorf("%d triggers, want 2", len(device.triggers))
+	}
+}
+
+func TestConfigLightsPreviewSVGError(t *testing.T) {
+	device := newLightDevice(messages.LightStatusMessage{
+		Payload: messages.LightStatus{Level: 0.8, Attack: 2, Sustain: 10, Release: 4},
+	})
+	svg := filepath.Join(t.TempDir(), "missing", "envelope.svg")
+
+	out, err := executeErr(t, device, "cfglights", "--preview", "--sustain", "5", "--svg", svg)
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("cfglights returned %v, want the svg error", err)
+	}
+	if !strings.Contains(out, "Proposed") {
+		t.Errorf("preview printed %s", out)
+	}
+	if sent := device.sent(t); len(sent) != 0 {
+		t.Errorf("preview sent %+v", sent)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"sync"
 
 	"github.com/phelpsw/camera-trigger-bt-cli/boards"
 	"github.com/spf13/cobra"
@@ -17,8 +18,6 @@ var (
 		Run:   configMotion,
 	}
 
-	motionDone = make(chan struct{})
-
 	thresh         float32
 	threshUpdate   bool = false
 	luxLow         float32
@@ -38,49 +37,54 @@ func init() {
 	rootCmd.AddCommand(cfgMotionCmd)
 }
 
-func configMotionHandler(b interface{}) error {
-	switch b.(type) {
-	case *boards.Motion:
-		m := b.(*boards.Motion)
-
-		if threshUpdate && m.MotionThreshold() != thresh {
-			err := m.SetMotionThreshold(thresh, false)
-			if err != nil {
-				return err
+// configMotionHandler returns an update callback which applies the flags
+// given and closes done once the device reports them
+func configMotionHandler(done chan struct{}) func(interface{}) error {
+	var once sync.Once
+	return func(b interface{}) error {
+		switch b.(type) {
+		case *boards.Motion:
+			m := b.(*boards.Motion)
+
+			if threshUpdate && m.MotionThreshold() != thresh {
+				err := m.SetMotionThreshold(thresh, false)
+				if err != nil {
+					return err
+				}
 			}
-		}
 
-		if luxLowUpdate && m.LuxLowThreshold() != luxLow {
-			err := m.SetLuxLowThreshold(luxLow, false)
-			if err != nil {
-				return err
+			if luxLowUpdate && m.LuxLowThreshold() != luxLow {
+				err := m.SetLuxLowThreshold(luxLow, false)
+				if err != nil {
+					return err
+				}
 			}
-		}
 
-		if luxHighUpdate && m.LuxHighThreshold() != luxHigh {
-			err := m.SetLuxHighThreshold(luxHigh, false)
-			if err != nil {
-				return err
+			if luxHighUpdate && m.LuxHighThreshold() != luxHigh {
+				err := m.SetLuxHighThreshold(luxHigh, false)
+				if err != nil {
+					return err
+				}
 			}
-		}
 
-		if cooldownUpdate && m.Cooldown() != cooldown {
-			err := m.SetCooldown(cooldown, false)
-			if err != nil {
-				return err
+			if cooldownUpdate && m.Cooldown() != cooldown {
+				err := m.SetCooldown(cooldown, false)
+				if err != nil {
+					return err
+				}
 			}
-		}
 
-		// This needs to be done asynchronously otherwise it will deadlock
-		go m.Sync()
+			// This needs to be done asynchronously otherwise it will deadlock
+			go m.Sync()
 
-		if m.IsSynced() {
-			close(motionDone)
+			if m.IsSynced() {
+				once.Do(func() { close(done) })
+			}
+		default:
+			return fmt.Errorf("unknown type %+v", reflect.TypeOf(b))
 		}
-	default:
-		return fmt.Errorf("unknown type %+v", reflect.TypeOf(b))
+		return nil
 	}
-	return nil
 }
 
 func configMotion(cmd *cobra.Command, args []string) {
@@ -89,16 +93,17 @@ func configMotion(cmd *cobra.Command, args []string) {
 	luxHighUpdate = cmd.Flags().Changed("luxhigh")
 	cooldownUpdate = cmd.Flags().Changed("cooldown")
 
+	done := make(chan struct{})
 	m := boards.Motion{}
+	m.SetUpdateCallback(configMotionHandler(done))
 
 	err := m.Init(deviceID, debug)
 	if err != nil {
-		log.Panicln(err)
+		log.Println(err)
 		return
 	}
+	defer m.Close()
 
-	m.SetUpdateCallback(configMotionHandler)
-
-	<-motionDone
+	<-done
 	log.Println("Done")
 }
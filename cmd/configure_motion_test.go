@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestConfigMotion(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{
+		MotionThreshold:  0.5,
+		LuxLowThreshold:  5,
+		LuxHighThreshold: 100,
+		Cooldown:         10,
+	})
+
+	out := execute(t, device, "cfgmotion", "--motion", "0.3", "--cooldown", "20")
+	if out != "" {
+		t.Errorf("printed %q", out)
+	}
+
+	sent := device.sent(t)
+	if len(sent) != 1 {
+		t.Fatalf("sent %+v, want one configuration", sent)
+	}
+	info, _ := messages.TypeByName("MotionSensorConfig")
+	want := messages.MotionSensorConfigMessage{
+		Type:             info.Code,
+		Length:           uint8(info.Size()),
+		MotionThreshold:  0.3,
+		LuxLowThreshold:  5,
+		LuxHighThreshold: 100,
+		Cooldown:         20,
+	}
+	if sent[0] != want {
+		t.Errorf("sent %+v, want %+v", sent[0], want)
+	}
+}
+
+func TestConfigMotionUnchanged(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{MotionThreshold: 0.3})
+
+	execute(t, device, "cfgmotion", "--motion", "0.3")
+	if sent := device.sent(t); len(sent) != 0 {
+		t.Errorf("sent %+v to a device already configured", sent)
+	}
+}
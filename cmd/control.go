@@ -0,0 +1,455 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/control"
+	"github.com/phelpsw/camera-trigger-bt-cli/control/controlpb"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func init() {
+	controlCmd.Flags().StringVarP(&controlListen, "listen", "l", control.DefaultAddress, "Address to serve the control service on")
+	controlCmd.Flags().DurationVar(&controlLogTimeout, "log-timeout", 2*time.Second, "Time to wait for each log entry")
+	controlCmd.Flags().IntVar(&controlLogRetries, "log-retries", 3, "Number of times to re-request a log entry")
+
+	rootCmd.AddCommand(controlCmd)
+}
+
+var controlCmd = &cobra.Command{
+	Use:   "control",
+	Short: "Serve a gRPC service for controlling many devices",
+	Long: `Serve a gRPC service for controlling many devices
+
+The process owns the bluetooth adapter and clients connect, configure and
+monitor any number of devices through it at the same time. The service is
+defined in control/controlpb/control.proto, Go programs use the client in
+the control package.
+
+  Scan          list advertising devices
+  Connect       connect to a device and wait for its first status
+  Disconnect    disconnect from a device
+  List          list connected devices
+  GetParam      read a named parameter
+  SetParam      write a named parameter
+  Trigger       trigger a device
+  StreamStatus  stream the status messages of a device
+  Logs          download log entries
+
+Requests to one device are handled one at a time, requests to different
+devices run concurrently. --device is not used.`,
+	Run: controlServe,
+}
+
+var (
+	controlListen     string
+	controlLogTimeout time.Duration
+	controlLogRetries int
+)
+
+const (
+	controlScanDuration   = 5 * time.Second
+	controlConnectTimeout = 30 * time.Second
+)
+
+// controlDevice is a board connected by the control service
+type controlDevice struct {
+	// mutex serialises requests to the device, the board can only wait for
+	// one response of each type at a time
+	mutex sync.Mutex
+	board *boards.Basic
+
+	statusMutex sync.Mutex
+	status      control.Status
+	// changed is closed and replaced when a status arrives
+	changed chan struct{}
+	// closed is closed when the device is disconnected
+	closed chan struct{}
+}
+
+func newControlDevice(name string) *controlDevice {
+	d := &controlDevice{
+		board:   &boards.Basic{},
+		changed: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	d.status.Device = name
+	d.board.SetMessageCallback(d.handleMessage)
+	return d
+}
+
+// handleMessage keeps the latest status. The sequence number carries on
+// across reconnects so clients streaming status are not confused.
+func (d *controlDevice) handleMessage(msg interface{}) error {
+	d.statusMutex.Lock()
+	defer d.statusMutex.Unlock()
+
+	switch s := msg.(type) {
+	case messages.MotionSensorStatusMessage:
+		d.status.Board, d.status.Motion, d.status.Light = control.BoardMotion, &s, nil
+	case messages.LightStatusMessage:
+		d.status.Board, d.status.Motion, d.status.Light = control.BoardLight, nil, &s
+	default:
+		return nil
+	}
+	d.status.Sequence++
+	d.status.Received = time.Now()
+	close(d.changed)
+	d.changed = make(chan struct{})
+	return nil
+}
+
+// latest returns the latest status and a channel closed when the next one
+// arrives
+func (d *controlDevice) latest() (control.Status, <-chan struct{}) {
+	d.statusMutex.Lock()
+	defer d.statusMutex.Unlock()
+	return d.status, d.changed
+}
+
+// controlService implements the control service
+type controlService struct {
+	controlpb.UnimplementedControlServer
+
+	mutex   sync.Mutex
+	devices map[string]*controlDevice
+
+	// logTimeout and logRetries are passed to DownloadLog for Logs
+	logTimeout time.Duration
+	logRetries int
+}
+
+func newControlService(logTimeout time.Duration, logRetries int) *controlService {
+	return &controlService{
+		devices:    make(map[string]*controlDevice),
+		logTimeout: logTimeout,
+		logRetries: logRetries,
+	}
+}
+
+// device returns a device added by Connect
+func (s *controlService) device(name string) (*controlDevice, error) {
+	s.mutex.Lock()
+	d, ok := s.devices[name]
+	s.mutex.Unlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "%s: not connected", name)
+	}
+	return d, nil
+}
+
+// remove forgets d and ends its status streams, unless it has already been
+// removed
+func (s *controlService) remove(name string, d *controlDevice) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.devices[name] != d {
+		return false
+	}
+	delete(s.devices, name)
+	close(d.closed)
+	return true
+}
+
+// connected returns a device added by Connect with its request mutex held
+func (s *controlService) connected(name string) (*controlDevice, error) {
+	d, err := s.device(name)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mutex.Lock()
+	if !d.board.IsConnected() {
+		d.mutex.Unlock()
+		return nil, status.Errorf(codes.FailedPrecondition, "%s: not connected", name)
+	}
+	return d, nil
+}
+
+// Scan holds the adapter until the scan stops, so scans and connects to
+// other devices wait for each other
+func (s *controlService) Scan(ctx context.Context, req *controlpb.ScanRequest) (*controlpb.ScanResponse, error) {
+	duration := controlScanDuration
+	if req.GetDuration() != nil {
+		duration = req.GetDuration().AsDuration()
+	}
+
+	var conn connection.Connection
+	_, err := conn.Scan(time.Second)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-time.After(duration):
+	case <-ctx.Done():
+	}
+	conn.StopScan()
+
+	var devices []control.Device
+	for _, dev := range conn.ListDevices() {
+		devices = append(devices, control.Device{
+			Name: dev.Name, Address: dev.Address, RSSI: dev.RSSI, Detected: dev.Detected,
+		})
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Name < devices[j].Name
+	})
+
+	resp := &controlpb.ScanResponse{}
+	for _, dev := range devices {
+		resp.Devices = append(resp.Devices, dev.Proto())
+	}
+	return resp, nil
+}
+
+func (s *controlService) Connect(ctx context.Context, req *controlpb.ConnectRequest) (*controlpb.ConnectResponse, error) {
+	name := req.GetDevice()
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "device is required")
+	}
+
+	// The device is added before connecting so concurrent connects to it
+	// wait for each other. A connect which waited for one that failed
+	// starts again with a new device.
+	var d *controlDevice
+	for d == nil {
+		s.mutex.Lock()
+		var ok bool
+		d, ok = s.devices[name]
+		if !ok {
+			d = newControlDevice(name)
+			s.devices[name] = d
+		}
+		s.mutex.Unlock()
+
+		d.mutex.Lock()
+		select {
+		case <-d.closed:
+			d.mutex.Unlock()
+			d = nil
+		default:
+		}
+	}
+	defer d.mutex.Unlock()
+
+	timeout := controlConnectTimeout
+	if req.GetTimeout() != nil {
+		timeout = req.GetTimeout().AsDuration()
+	}
+	d.board.SetConnectTimeout(timeout)
+
+	if !d.board.IsConnected() {
+		log.Printf("Connecting to %s\n", name)
+		err := d.board.Init(name, debug)
+		if err != nil {
+			s.remove(name, d)
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		err = d.board.WaitForStatus(10 * time.Second)
+		if err != nil {
+			s.remove(name, d)
+			d.board.Close()
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+	}
+
+	latest, _ := d.latest()
+	return control.Connected{Device: name, Board: latest.Board}.Proto(), nil
+}
+
+func (s *controlService) Disconnect(ctx context.Context, req *controlpb.DeviceRequest) (*emptypb.Empty, error) {
+	d, err := s.device(req.GetDevice())
+	if err != nil {
+		return nil, err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !s.remove(req.GetDevice(), d) {
+		// Disconnected while waiting for the device
+		return &emptypb.Empty{}, nil
+	}
+
+	log.Printf("Disconnecting from %s\n", req.GetDevice())
+	return &emptypb.Empty{}, d.board.Close()
+}
+
+func (s *controlService) List(ctx context.Context, req *emptypb.Empty) (*controlpb.ListResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var devices []control.Connected
+	for name, d := range s.devices {
+		if !d.board.IsConnected() {
+			continue
+		}
+		latest, _ := d.latest()
+		devices = append(devices, control.Connected{Device: name, Board: latest.Board})
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Device < devices[j].Device
+	})
+
+	resp := &controlpb.ListResponse{}
+	for _, dev := range devices {
+		resp.Devices = append(resp.Devices, dev.Proto())
+	}
+	return resp, nil
+}
+
+func (s *controlService) GetParam(ctx context.Context, req *controlpb.ParamRequest) (*controlpb.Param, error) {
+	d, err := s.connected(req.GetDevice())
+	if err != nil {
+		return nil, err
+	}
+	defer d.mutex.Unlock()
+
+	name := req.GetName()
+	if indx, persist, err := boards.FloatIndex(name); err == nil {
+		r, err := d.board.GetFloat(indx, persist)
+		if err != nil {
+			return nil, err
+		}
+		return control.Param{Name: name, Type: "float", Persist: persist == 1,
+			Success: r.Success == 1, Value: float64(r.Value)}.Proto(), nil
+	}
+
+	if indx, persist, err := boards.Uint16Index(name); err == nil {
+		r, err := d.board.GetUint16(indx, persist)
+		if err != nil {
+			return nil, err
+		}
+		return control.Param{Name: name, Type: "uint16", Persist: persist == 1,
+			Success: r.Success == 1, Value: float64(r.Value)}.Proto(), nil
+	}
+
+	return nil, status.Errorf(codes.InvalidArgument, "unknown parameter %q", name)
+}
+
+func (s *controlService) SetParam(ctx context.Context, req *controlpb.SetParamRequest) (*controlpb.Param, error) {
+	d, err := s.connected(req.GetDevice())
+	if err != nil {
+		return nil, err
+	}
+	defer d.mutex.Unlock()
+
+	name, value := req.GetName(), req.GetValue()
+	if indx, persist, err := boards.FloatIndex(name); err == nil {
+		r, err := d.board.SetFloat(indx, persist, float32(value))
+		if err != nil {
+			return nil, err
+		}
+		return control.Param{Name: name, Type: "float", Persist: persist == 1,
+			Success: r.Success == 1, Value: float64(r.Value)}.Proto(), nil
+	}
+
+	if indx, persist, err := boards.Uint16Index(name); err == nil {
+		if value < 0 || value > math.MaxUint16 || value != math.Trunc(value) {
+			return nil, status.Errorf(codes.InvalidArgument, "%s: %v is not a uint16", name, value)
+		}
+		r, err := d.board.SetUint16(indx, persist, uint16(value))
+		if err != nil {
+			return nil, err
+		}
+		return control.Param{Name: name, Type: "uint16", Persist: persist == 1,
+			Success: r.Success == 1, Value: float64(r.Value)}.Proto(), nil
+	}
+
+	return nil, status.Errorf(codes.InvalidArgument, "unknown parameter %q", name)
+}
+
+func (s *controlService) Trigger(ctx context.Context, req *controlpb.TriggerRequest) (*emptypb.Empty, error) {
+	d, err := s.connected(req.GetDevice())
+	if err != nil {
+		return nil, err
+	}
+	defer d.mutex.Unlock()
+
+	return &emptypb.Empty{}, d.board.Trigger(req.GetLux())
+}
+
+// StreamStatus sends each status as it arrives. It doesn't hold the
+// device's request mutex so other requests to the device are not held up.
+func (s *controlService) StreamStatus(req *controlpb.DeviceRequest, stream grpc.ServerStreamingServer[controlpb.Status]) error {
+	d, err := s.device(req.GetDevice())
+	if err != nil {
+		return err
+	}
+
+	var sent uint64
+	for {
+		latest, changed := d.latest()
+		if latest.Sequence > sent {
+			err := stream.Send(latest.Proto())
+			if err != nil {
+				return err
+			}
+			sent = latest.Sequence
+		}
+
+		select {
+		case <-changed:
+		case <-d.closed:
+			return nil
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func (s *controlService) Logs(ctx context.Context, req *controlpb.LogsRequest) (*controlpb.LogsResponse, error) {
+	d, err := s.connected(req.GetDevice())
+	if err != nil {
+		return nil, err
+	}
+	defer d.mutex.Unlock()
+
+	if req.GetStart() > math.MaxUint16 || req.GetEnd() > math.MaxUint16 {
+		return nil, status.Error(codes.InvalidArgument, "log indices are uint16")
+	}
+	start, end := uint16(req.GetStart()), uint16(req.GetEnd())
+	if end == 0 {
+		end = d.board.LogEntries()
+	}
+	if start > end {
+		return nil, status.Errorf(codes.InvalidArgument, "start %d is past end %d", start, end)
+	}
+
+	dl, err := d.board.DownloadLog(start, end, s.logTimeout, s.logRetries, nil)
+	if err != nil {
+		return nil, err
+	}
+	return control.Logs{Entries: dl.Entries, Missing: dl.Missing}.Proto(), nil
+}
+
+func controlServe(cmd *cobra.Command, args []string) {
+	listener, err := net.Listen("tcp", controlListen)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	server := grpc.NewServer()
+	controlpb.RegisterControlServer(server, newControlService(controlLogTimeout, controlLogRetries))
+
+	log.Printf("Serving control on %s\n", controlListen)
+	err = server.Serve(listener)
+	if err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/control"
+	"github.com/phelpsw/camera-trigger-bt-cli/control/controlpb"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newControlClient serves the control service with boards connecting to
+// the devices given and returns a client of it
+func newControlClient(t *testing.T, devices map[string]*fakeDevice) *control.Client {
+	transport := boards.NewTransport
+	boards.NewTransport = func() connection.Transport {
+		return &siteTransport{devices: devices}
+	}
+	t.Cleanup(func() { boards.NewTransport = transport })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	controlpb.RegisterControlServer(server, newControlService(2*time.Second, 3))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	c, err := control.Dial(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestControl(t *testing.T) {
+	motion := newMotionDevice(messages.MotionSensorStatusMessage{MotionThreshold: 0.5})
+	motion.addLog(2)
+	light := newLightDevice(messages.LightStatusMessage{})
+	for _, d := range []*fakeDevice{motion, light} {
+		d.statusEvery(t, 20*time.Millisecond)
+	}
+	c := newControlClient(t, map[string]*fakeDevice{
+		"camera-trigger-001": motion,
+		"camera-trigger-002": light,
+	})
+	ctx := context.Background()
+
+	_, err := c.GetParam(ctx, "camera-trigger-001", "motion_threshold")
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("GetParam() before connecting error %v", err)
+	}
+	_, err = c.Connect(ctx, "camera-trigger-003", time.Second)
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Connect() to a missing device error %v", err)
+	}
+	// A failed connect leaves nothing behind
+	_, err = c.GetParam(ctx, "camera-trigger-003", "motion_threshold")
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("GetParam() after a failed connect error %v", err)
+	}
+	err = c.StreamStatus(ctx, "camera-trigger-003", func(control.Status) {})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("StreamStatus() after a failed connect error %v", err)
+	}
+
+	for name, board := range map[string]string{
+		"camera-trigger-001": control.BoardMotion,
+		"camera-trigger-002": control.BoardLight,
+	} {
+		connected, err := c.Connect(ctx, name, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if connected.Board != board {
+			t.Errorf("Connect(%s) board %s, want %s", name, connected.Board, board)
+		}
+	}
+	list, err := c.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []control.Connected{
+		{Device: "camera-trigger-001", Board: control.BoardMotion},
+		{Device: "camera-trigger-002", Board: control.BoardLight},
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("List() = %+v, want %+v", list, want)
+	}
+
+	p, err := c.SetParam(ctx, "camera-trigger-001", "motion_threshold", 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Value != 0.25 || !p.Success || p.Type != "float" {
+		t.Errorf("SetParam() = %+v", p)
+	}
+	p, err = c.GetParam(ctx, "camera-trigger-001", "motion_threshold")
+	if err != nil || p.Value != 0.25 {
+		t.Errorf("GetParam() = %+v, %v after setting", p, err)
+	}
+	_, err = c.GetParam(ctx, "camera-trigger-001", "missing")
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetParam(missing) error %v", err)
+	}
+	_, err = c.SetParam(ctx, "camera-trigger-002", "sustain", -1)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("SetParam(sustain, -1) error %v", err)
+	}
+
+	logs, err := c.Logs(ctx, "camera-trigger-001", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs.Entries) != 2 || logs.Entries[1].Index != 1 || len(logs.Missing) != 0 {
+		t.Errorf("Logs() = %+v", logs)
+	}
+
+	// The stream carries on until the device is disconnected
+	statuses := make(chan control.Status, 100)
+	streamed := make(chan error)
+	go func() {
+		streamed <- c.StreamStatus(ctx, "camera-trigger-002", func(s control.Status) {
+			statuses <- s
+		})
+	}()
+	var last uint64
+	for i := 0; i < 3; i++ {
+		select {
+		case s := <-statuses:
+			if s.Sequence <= last || s.Light == nil || s.Board != control.BoardLight {
+				t.Errorf("streamed status %+v after sequence %d", s, last)
+			}
+			last = s.Sequence
+		case <-time.After(5 * time.Second):
+			t.Fatal("no status streamed")
+		}
+	}
+
+	err = c.Disconnect(ctx, "camera-trigger-002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-streamed:
+		if err != nil {
+			t.Errorf("StreamStatus() returned %v on disconnect", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end on disconnect")
+	}
+	list, err = c.List(ctx)
+	if err != nil || len(list) != 1 || list[0].Device != "camera-trigger-001" {
+		t.Errorf("List() = %+v, %v after disconnecting", list, err)
+	}
+}
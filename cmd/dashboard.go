@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+)
+
+const (
+	barWidth      = 40
+	historyLength = 60
+)
+
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// dashboard renders board status in place and handles key bindings
+type dashboard struct {
+	mutex   sync.Mutex
+	out     prompt.ConsoleWriter
+	in      prompt.ConsoleParser
+	basic   *boards.Basic
+	motion  *boards.Motion
+	light   *boards.Light
+	history []float32
+	updated time.Time
+	message string
+	done    chan struct{}
+}
+
+func newDashboard(b *boards.Basic) *dashboard {
+	return &dashboard{
+		out:   prompt.NewStdoutWriter(),
+		in:    prompt.NewStandardInputParser(),
+		basic: b,
+		done:  make(chan struct{}),
+	}
+}
+
+// Start switches the terminal to raw mode and begins reading keys
+func (d *dashboard) Start() error {
+	err := d.in.Setup()
+	if err != nil {
+		return err
+	}
+
+	d.out.HideCursor()
+	d.render()
+
+	go d.readKeys()
+	return nil
+}
+
+// Stop restores the terminal
+func (d *dashboard) Stop() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.out.EraseScreen()
+	d.out.CursorGoTo(0, 0)
+	d.out.ShowCursor()
+	d.out.Flush()
+	d.in.TearDown()
+}
+
+// Done is closed when the user quits
+func (d *dashboard) Done() <-chan struct{} {
+	return d.done
+}
+
+func (d *dashboard) updateMotion(m *boards.Motion) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.motion = m
+	d.updated = time.Now()
+	d.history = append(d.history, m.Motion())
+	if len(d.history) > historyLength {
+		d.history = d.history[len(d.history)-historyLength:]
+	}
+	d.renderLocked()
+}
+
+func (d *dashboard) updateLight(l *boards.Light) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.light = l
+	d.updated = time.Now()
+	d.renderLocked()
+}
+
+// setMessage shows msg below the status until replaced
+func (d *dashboard) setMessage(msg string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.message = msg
+	d.renderLocked()
+}
+
+func (d *dashboard) readKeys() {
+	for {
+		select {
+		case <-d.done:
+			return
+		default:
+		}
+
+		b, err := d.in.Read()
+		if err == nil && len(b) > 0 && !(len(b) == 1 && b[0] == 0) {
+			d.handleKey(b)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (d *dashboard) handleKey(b []byte) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if prompt.GetKey(b) == prompt.ControlC {
+		b = []byte("q")
+	}
+
+	var err error
+	switch string(b) {
+	case "q":
+		close(d.done)
+		return
+	case "t":
+		err = d.basic.Trigger(0)
+		d.message = "trigger sent"
+	}
+
+	if d.motion != nil {
+		m := d.motion
+		switch string(b) {
+		case "m":
+			err = m.SetMotionThreshold(m.MotionThreshold()-0.01, true)
+		case "M":
+			err = m.SetMotionThreshold(m.MotionThreshold()+0.01, true)
+		case "l":
+			err = m.SetLuxLowThreshold(m.LuxLowThreshold()-1, true)
+		case "L":
+			err = m.SetLuxLowThreshold(m.LuxLowThreshold()+1, true)
+		case "h":
+			err = m.SetLuxHighThreshold(m.LuxHighThreshold()-1, true)
+		case "H":
+			err = m.SetLuxHighThreshold(m.LuxHighThreshold()+1, true)
+		}
+	}
+
+	if d.light != nil {
+		l := d.light
+		switch string(b) {
+		case "v":
+			err = l.SetLevel(l.Level()-0.05, true)
+		case "V":
+			err = l.SetLevel(l.Level()+0.05, true)
+		case "s":
+			err = l.SetSustain(l.Sustain()-1, true)
+		case "S":
+			err = l.SetSustain(l.Sustain()+1, true)
+		}
+	}
+
+	if err != nil {
+		d.message = err.Error()
+	}
+	d.renderLocked()
+}
+
+func (d *dashboard) render() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.renderLocked()
+}
+
+func (d *dashboard) renderLocked() {
+	var lines []string
+
+	switch {
+	case d.motion != nil:
+		m := d.motion
+		scale := max32(1, m.MotionThreshold()*2, m.Motion())
+		luxScale := max32(1, m.LuxHighThreshold()*1.5, m.Lux())
+
+		lines = append(lines,
+			fmt.Sprintf("Motion Sensor %s%s", deviceID, syncState(m.IsSynced())),
+			"",
+			fmt.Sprintf("  Motion %8.3f %s thresh %.3f", m.Motion(),
+				bar(m.Motion(), scale, m.MotionThreshold()), m.MotionThreshold()),
+			fmt.Sprintf("  History         %s", sparkline(d.history, scale)),
+			fmt.Sprintf("  Lux    %8.2f %s low %.2f high %.2f", m.Lux(),
+				bar(m.Lux(), luxScale, m.LuxLowThreshold(), m.LuxHighThreshold()),
+				m.LuxLowThreshold(), m.LuxHighThreshold()),
+			"",
+			fmt.Sprintf("  Cooldown %.1f sec", m.Cooldown()),
+			fmt.Sprintf("  CPU Temp %.2f degC", m.Temperature()),
+			fmt.Sprintf("  Voltage  %.2f V", m.Voltage()),
+			fmt.Sprintf("  Log Count %d", m.LogEntries()),
+			"",
+			"  [t] trigger  [m/M] motion thresh  [l/L] lux low  [h/H] lux high  [q] quit")
+	case d.light != nil:
+		l := d.light
+		lines = append(lines,
+			fmt.Sprintf("Light Controller %s%s", deviceID, syncState(l.IsSynced())),
+			"",
+			fmt.Sprintf("  Level  %8.2f %s", l.Level(), bar(l.Level(), 1)),
+			fmt.Sprintf("  Delay %.2f  Attack %.2f  Sustain %.2f  Release %.2f sec",
+				l.Delay(), l.Attack(), l.Sustain(), l.Release()),
+			"",
+			fmt.Sprintf("  Light Temp %.2f degC", l.LightTemperature()),
+			fmt.Sprintf("  Current    %.3f A", l.Current()),
+			fmt.Sprintf("  CPU Temp   %.2f degC", l.Temperature()),
+			fmt.Sprintf("  Voltage    %.2f V", l.Voltage()),
+			fmt.Sprintf("  Log Count  %d", l.LogEntries()),
+			"",
+			"  [t] trigger  [v/V] level  [s/S] sustain  [q] quit")
+	default:
+		lines = append(lines, fmt.Sprintf("Waiting for status from %s", deviceID))
+	}
+
+	lines = append(lines, "")
+	if !d.updated.IsZero() {
+		lines = append(lines, fmt.Sprintf("  Updated %s", d.updated.Format("15:04:05")))
+	}
+	if d.message != "" {
+		lines = append(lines, "  "+d.message)
+	}
+
+	d.out.EraseScreen()
+	d.out.CursorGoTo(0, 0)
+	for _, line := range lines {
+		d.out.WriteStr(line)
+		d.out.WriteRawStr("\r\n")
+	}
+	d.out.Flush()
+}
+
+func syncState(synced bool) string {
+	if synced {
+		return ""
+	}
+	return " (update pending)"
+}
+
+// bar draws value as a filled bar scaled to max with a '|' at each marker
+func bar(value float32, max float32, markers ...float32) string {
+	cells := []rune(strings.Repeat(" ", barWidth))
+
+	filled := int(value / max * barWidth)
+	for i := 0; i < filled && i < barWidth; i++ {
+		cells[i] = '#'
+	}
+
+	for _, marker := range markers {
+		i := int(marker / max * barWidth)
+		if i >= barWidth {
+			i = barWidth - 1
+		}
+		if i >= 0 {
+			cells[i] = '|'
+		}
+	}
+
+	return "[" + string(cells) + "]"
+}
+
+// sparkline draws values as a row of block characters scaled to max
+func sparkline(values []float32, max float32) string {
+	var s []rune
+	for _, v := range values {
+		i := int(v / max * float32(len(sparkChars)-1))
+		if i < 0 {
+			i = 0
+		} else if i >= len(sparkChars) {
+			i = len(sparkChars) - 1
+		}
+		s = append(s, sparkChars[i])
+	}
+	return string(s)
+}
+
+func max32(values ...float32) float32 {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/capture"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(decodeCmd)
+}
+
+var decodeCmd = &cobra.Command{
+	Use:   "decode [FILE]",
+	Short: "Decode messages from a hex dump or capture",
+	Long: `Decode messages from a hex dump or capture
+
+Reads FILE, or stdin, and prints the messages in each chunk of bytes. The
+input is a capture made with --record, the output of --debug, e.g.
+
+  RX 4 bytes: 0x45, 0x08, 0x01, 0x00,
+
+or lines of plain hex, which are decoded as received. Received bytes go
+through the same decoder as a live connection, so partial frames are shown
+as they are buffered and bytes skipped to find the next message are
+reported. Sent bytes are decoded a message at a time. --device limits a
+capture to one device.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  decode,
+}
+
+func decode(cmd *cobra.Command, args []string) {
+	var input []byte
+	var err error
+	if len(args) == 1 {
+		input, err = ioutil.ReadFile(args[0])
+	} else {
+		input, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	var records []capture.Record
+	if bytes.HasPrefix(bytes.TrimSpace(input), []byte("{")) {
+		records, err = capture.Read(bytes.NewReader(input))
+	} else {
+		records, err = capture.ParseDump(bytes.NewReader(input))
+	}
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	// Each device has its own stream of received bytes
+	decoders := make(map[string]*messages.Decoder)
+	for _, r := range records {
+		if deviceID != "" && r.Device != deviceID {
+			continue
+		}
+
+		fmt.Println(chunkHeader(r))
+		if r.Direction == capture.Sent {
+			decodeSent(r.Data)
+			continue
+		}
+
+		d, ok := decoders[r.Device]
+		if !ok {
+			d = &messages.Decoder{}
+			decoders[r.Device] = d
+		}
+		decodeReceived(d, r.Data)
+	}
+
+	var devices []string
+	for device := range decoders {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	for _, device := range devices {
+		d := decoders[device]
+		if len(d.Buffered()) == 0 {
+			continue
+		}
+		if device != "" {
+			device += ": "
+		}
+		fmt.Printf("%sincomplete frame at end of input: % x\n", device, d.Buffered())
+	}
+}
+
+func chunkHeader(r capture.Record) string {
+	var b bytes.Buffer
+	if !r.Time.IsZero() {
+		b.WriteString(r.Time.Format("15:04:05.000 "))
+	}
+	if r.Device != "" {
+		b.WriteString(r.Device + " ")
+	}
+	dir := "RX"
+	if r.Direction == capture.Sent {
+		dir = "TX"
+	}
+	fmt.Fprintf(&b, "%s %d bytes: % x", dir, len(r.Data), r.Data)
+	return b.String()
+}
+
+func printMessage(msg interface{}) {
+	fmt.Printf("  %s %+v\n", reflect.TypeOf(msg).Name(), msg)
+}
+
+// decodeReceived feeds a chunk to the decoder and prints every message it
+// completes along with the decoder's resync decisions
+func decodeReceived(d *messages.Decoder, data []byte) {
+	for {
+		before := d.Discarded()
+		buffered := len(d.Buffered())
+		msg, err := d.ReadMessage(data)
+		// Later passes only drain messages already buffered
+		data = nil
+
+		if skipped := d.Discarded() - before; skipped > 0 {
+			fmt.Printf("  resync: skipped %d bytes which do not start a message\n", skipped)
+		}
+		if err != nil {
+			fmt.Printf("  error: %s\n", err)
+			if len(d.Buffered()) == 0 || len(d.Buffered()) == buffered {
+				return
+			}
+			continue
+		}
+		if msg == nil {
+			break
+		}
+		printMessage(msg)
+	}
+
+	buf := d.Buffered()
+	switch {
+	case len(buf) == 0:
+	case len(buf) < 2:
+		fmt.Printf("  partial: %d byte buffered, waiting for a header\n", len(buf))
+	default:
+		t, ok := messages.TypeByCode(buf[0])
+		if ok && int(buf[1]) == t.Size() {
+			fmt.Printf("  partial: %s, %d of %d bytes buffered\n", t.Name, len(buf), t.Size())
+		} else {
+			fmt.Printf("  partial: %d bytes buffered, not yet a message header\n", len(buf))
+		}
+	}
+}
+
+// decodeSent prints each message in a chunk written to the device
+func decodeSent(data []byte) {
+	for len(data) > 0 {
+		t, ok := messages.TypeByCode(data[0])
+		if !ok || len(data) < 2 || int(data[1]) != t.Size() {
+			fmt.Printf("  unrecognised: % x\n", data)
+			return
+		}
+		if len(data) < t.Size() {
+			fmt.Printf("  partial: %s, %d of %d bytes\n", t.Name, len(data), t.Size())
+			return
+		}
+
+		msg, err := t.Decode(data)
+		if err != nil {
+			fmt.Printf("  error: %s\n", err)
+			return
+		}
+		printMessage(msg)
+		data = data[t.Size():]
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+)
+
+const (
+	plotWidth  = 60
+	plotHeight = 10
+
+	svgWidth   = 600
+	svgHeight  = 200
+	svgMargin  = 40
+	svgSamples = 300
+)
+
+// namedEnvelope is an envelope labelled for a plot legend
+type namedEnvelope struct {
+	name     string
+	envelope boards.Envelope
+}
+
+func plotDuration(envelopes []namedEnvelope) float32 {
+	var duration float32 = 1
+	for _, e := range envelopes {
+		if d := e.envelope.Duration(); d > duration {
+			duration = d
+		}
+	}
+	return duration
+}
+
+// plotEnvelopes draws the envelopes as ascii art, each with its own marker.
+// Cells where the curves overlap are drawn with '*'.
+func plotEnvelopes(w io.Writer, envelopes []namedEnvelope) {
+	markers := []rune{'.', '#'}
+	duration := plotDuration(envelopes)
+
+	grid := make([][]rune, plotHeight)
+	for row := range grid {
+		grid[row] = []rune(strings.Repeat(" ", plotWidth))
+	}
+
+	for i, e := range envelopes {
+		marker := markers[i%len(markers)]
+		for col := 0; col < plotWidth; col++ {
+			t := duration * (float32(col) + 0.5) / plotWidth
+			level := e.envelope.Brightness(t)
+			if level <= 0 {
+				continue
+			}
+
+			row := plotHeight - 1 - int(level*(plotHeight-1)+0.5)
+			if row < 0 {
+				row = 0
+			}
+			if grid[row][col] != ' ' && grid[row][col] != marker {
+				grid[row][col] = '*'
+			} else {
+				grid[row][col] = marker
+			}
+		}
+	}
+
+	for row := range grid {
+		label := "    "
+		if row == 0 {
+			label = "1.0 "
+		} else if row == plotHeight-1 {
+			label = "0.0 "
+		}
+		fmt.Fprintf(w, "%s|%s\n", label, string(grid[row]))
+	}
+	fmt.Fprintf(w, "    +%s\n", strings.Repeat("-", plotWidth))
+	fmt.Fprintf(w, "     0%*.1f sec\n", plotWidth-1, duration)
+
+	for i, e := range envelopes {
+		fmt.Fprintf(w, "  %c %s\n", markers[i%len(markers)], e.name)
+	}
+}
+
+// printEnvelope summarises the timing and output of an envelope
+func printEnvelope(w io.Writer, name string, e boards.Envelope) {
+	fmt.Fprintf(w, "%s: level %.2f delay %.2f attack %.2f sustain %.2f release %.2f\n",
+		name, e.Level, e.Delay, e.Attack, e.Sustain, e.Release)
+	fmt.Fprintf(w, "  on time %.2f sec, output %.2f sec at full brightness per trigger\n",
+		e.OnTime(), e.Energy())
+}
+
+// writeEnvelopeSVG writes the envelopes as an svg line chart
+func writeEnvelopeSVG(path string, envelopes []namedEnvelope) error {
+	colors := []string{"#888888", "#d62728"}
+	duration := plotDuration(envelopes)
+	plotW := float64(svgWidth - 2*svgMargin)
+	plotH := float64(svgHeight - 2*svgMargin)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\">\n",
+		svgWidth, svgHeight)
+	fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n")
+	fmt.Fprintf(&b, "<g font-family=\"sans-serif\" font-size=\"11\">\n")
+
+	// Axes
+	fmt.Fprintf(&b, "<path d=\"M%d %d V%d H%d\" stroke=\"black\" fill=\"none\"/>\n",
+		svgMargin, svgMargin, svgHeight-svgMargin, svgWidth-svgMargin)
+	fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">1.0</text>\n",
+		svgMargin-4, svgMargin+4)
+	fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">0.0</text>\n",
+		svgMargin-4, svgHeight-svgMargin)
+	fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\">0</text>\n",
+		svgMargin, svgHeight-svgMargin+14)
+	fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%.1f sec</text>\n",
+		svgWidth-svgMargin, svgHeight-svgMargin+14, duration)
+
+	for i, e := range envelopes {
+		color := colors[i%len(colors)]
+
+		var points []string
+		for s := 0; s <= svgSamples; s++ {
+			t := duration * float32(s) / svgSamples
+			x := float64(svgMargin) + plotW*float64(s)/svgSamples
+			y := float64(svgHeight-svgMargin) - plotH*math.Min(float64(e.envelope.Brightness(t)), 1)
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+
+		fmt.Fprintf(&b, "<polyline points=\"%s\" stroke=\"%s\" stroke-width=\"2\" fill=\"none\"/>\n",
+			strings.Join(points, " "), color)
+		fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" fill=\"%s\">%s, on %.1f sec</text>\n",
+			svgMargin+10, svgMargin-20+14*i, color, e.name, e.envelope.OnTime())
+	}
+
+	fmt.Fprintf(&b, "</g>\n</svg>\n")
+
+	return ioutil.WriteFile(path, []byte(b.String()), 0644)
+}
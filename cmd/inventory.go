@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/inventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inventoryFile string
+	selectSites   []string
+	selectTags    []string
+
+	// siteInventory is loaded from inventoryFile by initInventory
+	siteInventory *inventory.Inventory
+)
+
+// scanDevices returns the devices advertising nearby
+var scanDevices = func() (map[string]connection.Device, error) {
+	var b boards.Basic
+	return b.Scan()
+}
+
+// addSelectorFlags adds --site and --tag to a command run for inventory
+// devices in place of --device
+func addSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&selectSites, "site", nil, "Select the inventory devices at these sites")
+	cmd.Flags().StringSliceVar(&selectTags, "tag", nil, "Select the inventory devices with all of these tags")
+}
+
+// initInventory loads the inventory file. Only a file given with
+// --inventory has to exist.
+func initInventory() {
+	path := inventoryFile
+	if path == "" {
+		p, err := inventory.DefaultPath()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		path = p
+	}
+
+	inv, err := inventory.Load(path)
+	if os.IsNotExist(err) && inventoryFile == "" {
+		inv, err = &inventory.Inventory{}, nil
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+	siteInventory = inv
+}
+
+// selecting reports whether --site or --tag were given
+func selecting() bool {
+	return len(selectSites) > 0 || len(selectTags) > 0
+}
+
+// selectedDevices returns the inventory devices matching --site and --tag
+func selectedDevices() ([]inventory.Device, error) {
+	if deviceID != "" {
+		return nil, fmt.Errorf("--device cannot be used with --site or --tag")
+	}
+
+	devices := siteInventory.Select(selectSites, selectTags)
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("no inventory devices match --site %s --tag %s",
+			strings.Join(selectSites, ","), strings.Join(selectTags, ","))
+	}
+	return devices, nil
+}
+
+// runSelected runs fn for the --device given or, with --site or --tag, in
+// turn for each device selected with deviceID set to it. fn is passed the
+// inventory entry of the device, nil if it is not in the inventory.
+func runSelected(fn func(d *inventory.Device)) {
+	defer func(id string) {
+		deviceID = id
+	}(deviceID)
+
+	if !selecting() {
+		if d, ok := siteInventory.Find(deviceID); ok {
+			// --device may give the address, devices are found by name
+			deviceID = d.Name
+			fn(&d)
+			return
+		}
+		fn(nil)
+		return
+	}
+
+	devices, err := selectedDevices()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for i := range devices {
+		d := devices[i]
+		deviceID = d.Name
+		fmt.Printf("== %s %s\n", d.Name, d.Describe())
+		fn(&d)
+	}
+}
+
+// connectDevice connects m to the named device, giving up after
+// --connect-timeout so an absent device doesn't hold up the rest of a site
+func connectDevice(m *boards.Basic, name string) error {
+	m.SetConnectTimeout(timeConnectTimeout)
+	err := m.Init(name, debug)
+	if err != nil {
+		return fmt.Errorf("%s unreachable: %s", name, err)
+	}
+	return nil
+}
+
+// deviceFile inserts the device name before the extension of path, so each
+// selected device is written to its own file
+func deviceFile(path string, device string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + device + ext
+}
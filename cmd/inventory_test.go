@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+const northRidge = `
+devices:
+  - name: camera-trigger-001
+    site: north-ridge
+    role: motion
+    paired: camera-trigger-002
+    profile: night-trail
+    tags: [trail]
+  - name: camera-trigger-002
+    site: north-ridge
+    role: light
+    paired: camera-trigger-001
+    profile: night-trail
+    tags: [trail, solar]
+  - name: camera-trigger-003
+    site: north-ridge
+    role: motion
+  - name: camera-trigger-004
+    site: creek
+    role: motion
+`
+
+// northRidgeDevices returns the devices of northRidge which are present,
+// all but camera-trigger-003
+func northRidgeDevices() map[string]*fakeDevice {
+	return map[string]*fakeDevice{
+		"camera-trigger-001": newMotionDevice(messages.MotionSensorStatusMessage{
+			MotionThreshold:  0.5,
+			LuxLowThreshold:  5,
+			LuxHighThreshold: 100,
+			Cooldown:         10,
+		}),
+		"camera-trigger-002": newLightDevice(messages.LightStatusMessage{
+			Payload: messages.LightStatus{Level: 0.8, Sustain: 10},
+		}),
+		"camera-trigger-004": newMotionDevice(messages.MotionSensorStatusMessage{}),
+	}
+}
+
+func TestList(t *testing.T) {
+	path := writeConfig(t, northRidge)
+
+	out := executeSite(t, northRidgeDevices(), "--inventory", path, "list")
+	want := "camera-trigger-001 c4:7c:8d:6a:12:01 -60 dBm north-ridge motion paired with camera-trigger-002\n" +
+		"camera-trigger-002 c4:7c:8d:6a:12:02 -61 dBm north-ridge light paired with camera-trigger-001\n" +
+		"camera-trigger-004 c4:7c:8d:6a:12:03 -62 dBm creek motion\n"
+	if out != want {
+		t.Errorf("list printed %q, want %q", out, want)
+	}
+
+	out = executeSite(t, northRidgeDevices(), "--inventory", path, "list", "--site", "north-ridge")
+	want = "camera-trigger-001 c4:7c:8d:6a:12:01 -60 dBm north-ridge motion paired with camera-trigger-002\n" +
+		"camera-trigger-002 c4:7c:8d:6a:12:02 -61 dBm north-ridge light paired with camera-trigger-001\n" +
+		"camera-trigger-003 not found north-ridge motion\n"
+	if out != want {
+		t.Errorf("list --site printed %q, want %q", out, want)
+	}
+
+	out = executeSite(t, northRidgeDevices(), "--inventory", path, "list", "--tag", "trail,solar")
+	if !strings.HasPrefix(out, "camera-trigger-002 ") || strings.Count(out, "\n") != 1 {
+		t.Errorf("list --tag trail,solar printed %q", out)
+	}
+
+	out = executeSite(t, northRidgeDevices(), "--inventory", path, "list", "--site", "south")
+	if out != "" {
+		t.Errorf("list of an unknown site printed %q", out)
+	}
+}
+
+func TestProfileApplySite(t *testing.T) {
+	inv := writeConfig(t, northRidge)
+	cfg := writeConfig(t, `
+profiles:
+  night-trail:
+    motion:
+      motion_threshold: 0.35
+    light:
+      sustain: 20
+`)
+	devices := northRidgeDevices()
+
+	out := executeSite(t, devices, "--inventory", inv, "--config", cfg,
+		"profile", "apply", "--site", "north-ridge", "--tag", "trail")
+	want := "== camera-trigger-001 north-ridge motion paired with camera-trigger-002\n" +
+		"motion.motion_threshold 0.5 -> 0.35\n" +
+		"== camera-trigger-002 north-ridge light paired with camera-trigger-001\n" +
+		"light.sustain 10 -> 20\n"
+	if out != want {
+		t.Errorf("apply printed %q, want %q", out, want)
+	}
+
+	if got := devices["camera-trigger-001"].motion.MotionThreshold; got != 0.35 {
+		t.Errorf("motion threshold %v, want 0.35", got)
+	}
+	if got := devices["camera-trigger-002"].light.Payload.Sustain; got != 20 {
+		t.Errorf("sustain %v, want 20", got)
+	}
+	if sent := devices["camera-trigger-004"].sent(t); len(sent) != 0 {
+		t.Errorf("device of another site was sent %v", sent)
+	}
+
+	// camera-trigger-003 has no profile and is not present
+	out = executeSite(t, devices, "--inventory", inv, "--config", cfg,
+		"profile", "apply", "--site", "north-ridge")
+	want = "== camera-trigger-001 north-ridge motion paired with camera-trigger-002\n" +
+		"camera-trigger-001 already matches profile night-trail\n" +
+		"== camera-trigger-002 north-ridge light paired with camera-trigger-001\n" +
+		"camera-trigger-002 already matches profile night-trail\n" +
+		"== camera-trigger-003 north-ridge motion\n"
+	if out != want {
+		t.Errorf("second apply printed %q, want %q", out, want)
+	}
+}
+
+func TestSelectorWithDevice(t *testing.T) {
+	path := writeConfig(t, northRidge)
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+
+	out := execute(t, device, "--inventory", path, "logdump", "--site", "north-ridge")
+	if out != "" {
+		t.Errorf("logdump with --device and --site printed %q", out)
+	}
+	if written := device.conn.Written(); len(written) != 0 {
+		t.Errorf("device was sent %d frames", len(written))
+	}
+}
+
+func TestLogDumpSite(t *testing.T) {
+	path := writeConfig(t, northRidge)
+
+	out := executeSite(t, northRidgeDevices(), "--inventory", path, "logdump", "--site", "north-ridge", "--tag", "trail")
+	want := "== camera-trigger-001 north-ridge motion paired with camera-trigger-002\n" +
+		"Device reports 0 log entries\n" +
+		"== camera-trigger-002 north-ridge light paired with camera-trigger-001\n" +
+		"Device reports 0 log entries\n"
+	if out != want {
+		t.Errorf("logdump printed %q, want %q", out, want)
+	}
+}
+
+// creekAbsentFirst lists a device which is not present ahead of one which is
+const creekAbsentFirst = `
+devices:
+  - name: camera-trigger-003
+    site: creek
+  - name: camera-trigger-001
+    site: creek
+    address: c4:7c:8d:6a:12:01
+    profile: night-trail
+`
+
+func TestSiteUnreachableDevice(t *testing.T) {
+	inv := writeConfig(t, creekAbsentFirst)
+	cfg := writeConfig(t, `
+profiles:
+  night-trail:
+    motion:
+      motion_threshold: 0.35
+`)
+	devices := func() map[string]*fakeDevice {
+		return map[string]*fakeDevice{
+			"camera-trigger-001": newMotionDevice(messages.MotionSensorStatusMessage{MotionThreshold: 0.5}),
+		}
+	}
+
+	// The search for the absent device times out and the next is visited
+	out := executeSite(t, devices(), "--inventory", inv, "logdump", "--site", "creek", "--connect-timeout", "1s")
+	want := "== camera-trigger-003 creek\n" +
+		"== camera-trigger-001 creek\n" +
+		"Device reports 0 log entries\n"
+	if out != want {
+		t.Errorf("logdump printed %q, want %q", out, want)
+	}
+
+	out = executeSite(t, devices(), "--inventory", inv, "--config", cfg,
+		"profile", "apply", "night-trail", "--site", "creek")
+	want = "== camera-trigger-003 creek\n" +
+		"== camera-trigger-001 creek\n" +
+		"motion.motion_threshold 0.5 -> 0.35\n"
+	if out != want {
+		t.Errorf("profile apply printed %q, want %q", out, want)
+	}
+
+	present := newMotionDevice(messages.MotionSensorStatusMessage{Voltage: 3.3})
+	present.statusEvery(t, 20*time.Millisecond)
+	out, err := runErr(t, func() connection.Transport {
+		return &siteTransport{devices: map[string]*fakeDevice{"camera-trigger-001": present}}
+	}, "--inventory", inv, "monitor", "--site", "creek", "-a", "voltage < 3.4 => exit")
+	if err == nil || err.Error() != "exiting on alert" {
+		t.Errorf("monitor returned %v, want exiting on alert", err)
+	}
+	if !strings.Contains(out, "camera-trigger-001: Motion Sensor\n") {
+		t.Errorf("monitor printed %q", out)
+	}
+}
+
+func TestDeviceAddress(t *testing.T) {
+	inv := writeConfig(t, creekAbsentFirst)
+	devices := map[string]*fakeDevice{
+		"camera-trigger-001": newMotionDevice(messages.MotionSensorStatusMessage{}),
+	}
+
+	// The device is connected by the name the inventory gives the address
+	out := executeSite(t, devices, "--inventory", inv, "--device", "C4:7C:8D:6A:12:01", "logdump")
+	if out != "Device reports 0 log entries\n" {
+		t.Errorf("logdump printed %q", out)
+	}
+}
@@ -1,24 +1,85 @@
 package cmd
 
 import (
-	"time"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
 
 	"github.com/phelpsw/camera-trigger-bt-cli/connection"
 	"github.com/spf13/cobra"
 )
 
 func init() {
+	addSelectorFlags(listCmd)
 	rootCmd.AddCommand(listCmd)
 }
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List Devices",
-	Long:  "List all valid device ids for command and control.",
-	Run:   list,
+	Long: `List all valid device ids for command and control.
+
+Devices found which are in the inventory are shown with their site, role and
+paired device. With --site or --tag the selected inventory devices are listed
+instead, marking those which were not found.`,
+	Run: list,
+}
+
+// findScanned returns the scanned device with the name or address given
+func findScanned(found map[string]connection.Device, name string, address string) (connection.Device, bool) {
+	for _, dev := range found {
+		if strings.EqualFold(dev.Name, name) ||
+			(address != "" && strings.EqualFold(dev.Address, address)) {
+			return dev, true
+		}
+	}
+	return connection.Device{}, false
 }
 
 func list(cmd *cobra.Command, args []string) {
-	var conn connection.Connection
-	conn.Scan(5 * time.Second)
+	found, err := scanDevices()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if selecting() {
+		devices, err := selectedDevices()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		for _, d := range devices {
+			dev, ok := findScanned(found, d.Name, d.Address)
+			if !ok {
+				fmt.Println(strings.TrimSpace(fmt.Sprintf("%s not found %s", d.Name, d.Describe())))
+				continue
+			}
+			fmt.Println(strings.TrimSpace(fmt.Sprintf("%s %s %d dBm %s",
+				d.Name, dev.Address, dev.RSSI, d.Describe())))
+		}
+		return
+	}
+
+	var scanned []connection.Device
+	for _, dev := range found {
+		scanned = append(scanned, dev)
+	}
+	sort.Slice(scanned, func(i, j int) bool {
+		return scanned[i].Name < scanned[j].Name
+	})
+
+	for _, dev := range scanned {
+		line := fmt.Sprintf("%s %s %d dBm", dev.Name, dev.Address, dev.RSSI)
+		d, ok := siteInventory.Find(dev.Name)
+		if !ok {
+			d, ok = siteInventory.Find(dev.Address)
+		}
+		if ok {
+			line += " " + d.Describe()
+		}
+		fmt.Println(strings.TrimSpace(line))
+	}
 }
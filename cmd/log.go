@@ -5,11 +5,28 @@ import (
 	"log"
 	"time"
 
+	"github.com/phelpsw/camera-trigger-bt-cli/archive"
 	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/inventory"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
 	"github.com/spf13/cobra"
 )
 
 func init() {
+	dumpLogCmd.Flags().Uint16Var(&logStart, "start", 0, "Log index to resume the download from")
+	dumpLogCmd.Flags().StringVarP(&logOutput, "output", "o", "", "Write log entries to this file")
+	dumpLogCmd.Flags().StringVarP(&logFormat, "format", "f", "", "Output file format, csv or json (default from file extension)")
+	dumpLogCmd.Flags().DurationVar(&logTimeout, "timeout", 2*time.Second, "Time to wait for each log entry")
+	dumpLogCmd.Flags().IntVar(&logRetries, "retries", 3, "Number of times to re-request a log entry")
+	dumpLogCmd.Flags().BoolVar(&resetAfter, "reset-after", false, "Archive the complete log then reset the device log")
+	dumpLogCmd.Flags().StringVar(&archiveDir, "dir", "", "Archive directory used by --reset-after (default ~/.camera-trigger)")
+
+	dumpLogCmd.Flags().DurationVar(&timeConnectTimeout, "connect-timeout", 30*time.Second, "Time to search for each device")
+	addSelectorFlags(dumpLogCmd)
+
+	resetLogCmd.Flags().BoolVar(&resetForce, "force", false, "Reset without archiving or confirmation")
+	resetLogCmd.Flags().DurationVar(&timeConnectTimeout, "connect-timeout", 30*time.Second, "Time to search for the device")
+
 	rootCmd.AddCommand(dumpLogCmd)
 	rootCmd.AddCommand(resetLogCmd)
 }
@@ -17,8 +34,20 @@ func init() {
 var dumpLogCmd = &cobra.Command{
 	Use:   "logdump",
 	Short: "Pretty Print all log messages from the device",
-	Long:  "Pretty Print all log messages from the device",
-	Run:   dumpLog,
+	Long: `Pretty Print all log messages from the device
+
+Each entry is requested individually and retried on timeout. Missing and
+duplicate entries are reported, and an interrupted download can be resumed
+with --start. Entries can be saved with --output as csv or json.
+
+With --reset-after the complete log is first added to the local archive (see
+logsync) and the device log is only reset once every entry reported by the
+device has been saved. The reset is confirmed from the next status message.
+
+With --site or --tag the log of each selected inventory device is dumped in
+turn, and --output names a file per device, e.g. logs.csv becomes
+logs-camera-trigger-001.csv.`,
+	Run: dumpLog,
 }
 
 var resetLogCmd = &cobra.Command{
@@ -29,71 +58,233 @@ var resetLogCmd = &cobra.Command{
 }
 
 var (
-	logDone = make(chan struct{})
-
-	logIndex uint16 = 0
-	//triggered bool   = false // TODO: Dont use this variable
+	logStart   uint16
+	logOutput  string
+	logFormat  string
+	logTimeout time.Duration
+	logRetries int
+	resetAfter bool
+	resetForce bool
 )
 
-func dumpLogHandler(b *boards.Basic) error {
-	var logCount = b.LogEntries()
-
-	if logIndex >= logCount {
-		close(logDone)
+func printLogEntry(index uint16, entry *messages.LogResponseMessage) {
+	if entry == nil {
+		fmt.Printf("%5d: missing\n", index)
+		return
 	}
+	fmt.Printf("%5d: %s type %d payload %x\n",
+		entry.Index, entry.Timestamp, entry.LogType, entry.Payload)
+}
 
-	fmt.Println(logIndex)
+// connectLogBoard connects to the device and waits for the status message
+// which carries the log entry count
+func connectLogBoard(m *boards.Basic) error {
+	err := connectDevice(m, deviceID)
+	if err != nil {
+		return err
+	}
 
-	// TODO: Check index of last received log message before incrementing
-	go b.GetLog(logIndex)
-	logIndex++
+	for !m.IsConnected() {
+	}
 
-	return nil
+	return m.WaitForStatus(10 * time.Second)
 }
 
 func dumpLog(cmd *cobra.Command, args []string) {
+	runSelected(func(d *inventory.Device) {
+		output := logOutput
+		if output != "" && selecting() {
+			output = deviceFile(output, deviceID)
+		}
+		dumpDeviceLog(output)
+	})
+}
+
+// dumpDeviceLog dumps the log of deviceID, writing it to output if set
+func dumpDeviceLog(output string) {
 	m := boards.Basic{}
 
-	err := m.Init(deviceID, debug)
+	err := connectLogBoard(&m)
 	if err != nil {
-		log.Panicln(err)
+		log.Println(err)
 		return
 	}
+	defer m.Close()
 
-	for !m.IsConnected() {
+	count := m.LogEntries()
+	fmt.Printf("Device reports %d log entries\n", count)
+
+	if resetAfter {
+		if logStart != 0 {
+			log.Println("--start cannot be used with --reset-after")
+			return
+		}
+
+		err = archiveAndReset(&m, output)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		log.Println("Done")
+		return
+	}
+
+	if logStart >= count {
+		log.Println("Done")
+		return
+	}
+
+	dl, err := m.DownloadLog(logStart, count, logTimeout, logRetries, printLogEntry)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	fmt.Printf("Received %d of %d entries\n", len(dl.Entries), count-logStart)
+	if len(dl.Missing) > 0 {
+		fmt.Printf("Missing indices: %v\n", dl.Missing)
+		fmt.Printf("Resume with --start %d\n", dl.Missing[0])
+	}
+	if len(dl.Duplicates) > 0 {
+		fmt.Printf("Duplicate indices: %v\n", dl.Duplicates)
 	}
 
-	m.SetLogCallback(dumpLogHandler)
+	if output != "" {
+		var entries []archive.Entry
+		now := time.Now()
+		for _, entry := range dl.Entries {
+			entries = append(entries, archive.NewEntry(entry, 0, now))
+		}
 
-	// TODO: This won't be correct unless this receives status messages
-	// Maybe make these log messages generic and then let each specific board wrap the generics
-	if m.LogEntries() > 0 {
-		m.GetLog(logIndex)
-		logIndex++
+		err = archive.WriteFile(output, logFormat, entries)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		fmt.Printf("Wrote %d entries to %s\n", len(dl.Entries), output)
 	}
 
-	<-logDone
 	log.Println("Done")
 }
 
+// archiveAndReset saves every device log entry to the archive and, only once
+// they are persisted, resets the device log
+func archiveAndReset(m *boards.Basic, output string) error {
+	store, err := openArchive(deviceID)
+	if err != nil {
+		return err
+	}
+
+	count := m.LogEntries()
+	_, missing, err := syncArchive(m, store)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("entries %v could not be downloaded, log not reset", missing)
+	}
+	if store.State.NextIndex != count {
+		return fmt.Errorf("archived %d entries but device reports %d, log not reset",
+			store.State.NextIndex, count)
+	}
+
+	// Entries logged while downloading would be lost by the reset
+	err = m.WaitForNextStatus(10 * time.Second)
+	if err != nil {
+		return err
+	}
+	if m.LogEntries() != count {
+		return fmt.Errorf("device log grew from %d to %d entries, log not reset",
+			count, m.LogEntries())
+	}
+
+	fmt.Printf("Archived %d entries in %s\n", count, store.Dir())
+
+	if output != "" {
+		entries, err := store.Entries()
+		if err != nil {
+			return err
+		}
+
+		var current []archive.Entry
+		for _, entry := range entries {
+			if entry.Epoch == store.State.Epoch {
+				current = append(current, entry)
+			}
+		}
+
+		err = archive.WriteFile(output, logFormat, current)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %d entries to %s\n", len(current), output)
+	}
+
+	fmt.Println("Resetting device log")
+	err = m.ResetLog()
+	if err != nil {
+		return err
+	}
+
+	err = confirmReset(m)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Device log reset confirmed")
+
+	store.NewEpoch()
+	return store.Save()
+}
+
+// confirmReset waits for a status reporting the log of the device is empty
+func confirmReset(m *boards.Basic) error {
+	deadline := time.Now().Add(15 * time.Second)
+	for m.LogEntries() != 0 {
+		err := m.WaitForNextStatus(time.Until(deadline))
+		if err != nil {
+			return fmt.Errorf("reset not confirmed, device reports %d entries",
+				m.LogEntries())
+		}
+	}
+	return nil
+}
+
 func resetLog(cmd *cobra.Command, args []string) {
+	if !resetForce {
+		fmt.Printf("This permanently erases the log of %s.\n", deviceID)
+		fmt.Printf("Use 'logdump --reset-after' to archive the log before resetting.\n")
+		fmt.Printf("Type the device name to reset anyway: ")
+
+		var answer string
+		fmt.Scanln(&answer)
+		if answer != deviceID {
+			log.Println("Log not reset")
+			return
+		}
+	}
+
 	m := boards.Basic{}
 
-	err := m.Init(deviceID, debug)
+	err := connectLogBoard(&m)
 	if err != nil {
-		log.Panicln(err)
+		log.Println(err)
 		return
 	}
-
-	for !m.IsConnected() {
-	}
+	defer m.Close()
 
 	err = m.ResetLog()
 	if err != nil {
+		log.Println(err)
 		return
 	}
 
-	time.Sleep(5 * time.Second)
+	err = confirmReset(&m)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	fmt.Println("Device log reset confirmed")
 
 	log.Println("Done")
 }
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/archive"
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	syncLogCmd.Flags().StringVar(&archiveDir, "dir", "", "Archive directory (default ~/.camera-trigger)")
+	syncLogCmd.Flags().StringVarP(&logOutput, "output", "o", "", "Write the full archived history to this file")
+	syncLogCmd.Flags().StringVarP(&logFormat, "format", "f", "", "Output file format, csv or json (default from file extension)")
+	syncLogCmd.Flags().DurationVar(&logTimeout, "timeout", 2*time.Second, "Time to wait for each log entry")
+	syncLogCmd.Flags().IntVar(&logRetries, "retries", 3, "Number of times to re-request a log entry")
+	syncLogCmd.Flags().DurationVar(&timeConnectTimeout, "connect-timeout", 30*time.Second, "Time to search for the device")
+
+	rootCmd.AddCommand(syncLogCmd)
+}
+
+var syncLogCmd = &cobra.Command{
+	Use:   "logsync",
+	Short: "Fetch new log entries into the local archive",
+	Long: `Fetch new log entries into the local archive
+
+Each device has an archive directory holding every entry fetched so far and
+the next index to fetch. Only entries added since the last sync are
+downloaded. If the device log was reset since the last sync the archive
+starts a new epoch rather than overwriting earlier entries.`,
+	Run: syncLog,
+}
+
+var archiveDir string
+
+func openArchive(device string) (*archive.Store, error) {
+	root := archiveDir
+	if root == "" {
+		var err error
+		root, err = archive.DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return archive.Open(root, device)
+}
+
+// syncArchive fetches the entries the archive does not yet hold and appends
+// them, stopping at the first entry which could not be downloaded. It returns
+// the number of entries added and the indices still missing.
+func syncArchive(m *boards.Basic, store *archive.Store) (int, []uint16, error) {
+	count := m.LogEntries()
+	next := store.State.NextIndex
+
+	if next > count {
+		fmt.Printf("Device has %d log entries but %d were archived, log was reset\n",
+			count, next)
+		store.NewEpoch()
+	} else if next > 0 && store.State.LastEntry != nil {
+		// A reset followed by new activity can refill the log past the
+		// archived index, so confirm the last archived entry is unchanged
+		dl, err := m.DownloadLog(next-1, next, logTimeout, logRetries, nil)
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(dl.Entries) == 0 {
+			return 0, nil, fmt.Errorf("unable to verify archived entry %d", next-1)
+		}
+		if !store.State.LastEntry.Matches(dl.Entries[0]) {
+			fmt.Printf("Log entry %d differs from the archive, log was reset\n", next-1)
+			store.NewEpoch()
+		}
+	}
+
+	start := store.State.NextIndex
+	if start >= count {
+		return 0, nil, store.Save()
+	}
+
+	dl, err := m.DownloadLog(start, count, logTimeout, logRetries, printLogEntry)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Entries past a gap are fetched again on the next sync
+	entries := dl.Entries
+	if len(dl.Missing) > 0 {
+		entries = entries[:dl.Missing[0]-start]
+	}
+
+	err = store.Append(entries)
+	if err != nil {
+		return 0, dl.Missing, err
+	}
+
+	return len(entries), dl.Missing, store.Save()
+}
+
+func syncLog(cmd *cobra.Command, args []string) {
+	store, err := openArchive(deviceID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	m := boards.Basic{}
+
+	err = connectLogBoard(&m)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer m.Close()
+
+	fmt.Printf("Device reports %d log entries, archive epoch %d next index %d\n",
+		m.LogEntries(), store.State.Epoch, store.State.NextIndex)
+
+	added, missing, err := syncArchive(&m, store)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	fmt.Printf("Archived %d new entries in %s\n", added, store.Dir())
+	if len(missing) > 0 {
+		fmt.Printf("Missing indices: %v, run logsync again to retry\n", missing)
+	}
+
+	if logOutput != "" {
+		entries, err := store.Entries()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		err = archive.WriteFile(logOutput, logFormat, entries)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		fmt.Printf("Wrote %d entries to %s\n", len(entries), logOutput)
+	}
+
+	log.Println("Done")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/archive"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestLogSync(t *testing.T) {
+	dir := t.TempDir()
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	device.addLog(3)
+	device.statusEvery(t, 20*time.Millisecond)
+
+	sync := func(want string) {
+		t.Helper()
+		out := execute(t, device, "logsync", "--dir", dir, "--timeout", "100ms")
+		if !strings.Contains(out, want) {
+			t.Errorf("logsync printed %s\nwant %q", out, want)
+		}
+	}
+
+	sync("Archived 3 new entries")
+
+	// Only the entries added since are fetched
+	device.addLog(2)
+	before := len(device.sent(t))
+	sync("Archived 2 new entries")
+	for _, msg := range device.sent(t)[before:] {
+		if req, ok := msg.(messages.LogRequestMessage); ok && req.Index < 2 {
+			t.Errorf("entry %d requested again", req.Index)
+		}
+	}
+
+	// A reset starts a new epoch rather than overwriting the archive
+	device.mutex.Lock()
+	device.log = nil
+	device.mutex.Unlock()
+	device.addLog(1)
+	sync("log was reset")
+
+	store, err := archive.Open(dir, "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.State.Epoch != 1 || store.State.NextIndex != 1 {
+		t.Errorf("archive epoch %d next index %d, want epoch 1 index 1",
+			store.State.Epoch, store.State.NextIndex)
+	}
+	entries, err := store.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 {
+		t.Errorf("archive holds %d entries, want 6", len(entries))
+	}
+}
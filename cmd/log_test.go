@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/archive"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestLogDumpResetAfter(t *testing.T) {
+	dir := t.TempDir()
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	device.addLog(5)
+	device.statusEvery(t, 20*time.Millisecond)
+
+	// What the archive holds on disk when the reset arrives
+	archived := -1
+	device.onReceive = func(msg interface{}) {
+		if _, ok := msg.(messages.LogResetMessage); !ok {
+			return
+		}
+		store, err := archive.Open(dir, "fake")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		entries, err := store.Entries()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if store.State.NextIndex != uint16(len(entries)) {
+			t.Errorf("archive state next index %d with %d entries",
+				store.State.NextIndex, len(entries))
+		}
+		archived = len(entries)
+	}
+
+	out := execute(t, device, "logdump", "--reset-after", "--dir", dir, "--timeout", "100ms")
+
+	if archived != 5 {
+		t.Errorf("archive held %d entries when the log was reset, want 5", archived)
+	}
+	if !strings.Contains(out, "Device log reset confirmed\n") {
+		t.Errorf("logdump printed %s", out)
+	}
+	device.mutex.Lock()
+	if len(device.log) != 0 {
+		t.Errorf("device log has %d entries after reset", len(device.log))
+	}
+	device.mutex.Unlock()
+
+	// The next entries are archived in a new epoch
+	store, err := archive.Open(dir, "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.State.Epoch != 1 || store.State.NextIndex != 0 {
+		t.Errorf("archive epoch %d next index %d, want epoch 1 index 0",
+			store.State.Epoch, store.State.NextIndex)
+	}
+}
+
+func TestLogDumpResetRefusedWhenLogGrows(t *testing.T) {
+	dir := t.TempDir()
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	device.addLog(5)
+	device.statusEvery(t, 20*time.Millisecond)
+
+	// An entry is logged while the last one is downloaded
+	device.onReceive = func(msg interface{}) {
+		if msg, ok := msg.(messages.LogRequestMessage); ok && msg.Index == 4 {
+			device.addLogLocked(1)
+		}
+	}
+
+	out := execute(t, device, "logdump", "--reset-after", "--dir", dir, "--timeout", "100ms")
+
+	if strings.Contains(out, "Resetting device log") {
+		t.Errorf("logdump reset a growing log:\n%s", out)
+	}
+	for _, msg := range device.sent(t) {
+		if _, ok := msg.(messages.LogResetMessage); ok {
+			t.Error("device was sent a log reset")
+		}
+	}
+	device.mutex.Lock()
+	if len(device.log) != 6 {
+		t.Errorf("device log has %d entries, want 6", len(device.log))
+	}
+	device.mutex.Unlock()
+
+	// The entries downloaded are kept for the next sync
+	store, err := archive.Open(dir, "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.State.Epoch != 0 || store.State.NextIndex != 5 {
+		t.Errorf("archive epoch %d next index %d, want epoch 0 index 5",
+			store.State.Epoch, store.State.NextIndex)
+	}
+}
+
+func TestLogReset(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	device.addLog(3)
+	device.statusEvery(t, 20*time.Millisecond)
+
+	// Without --force the device name must be typed
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	_, _ = w.WriteString("camera-trigger-001\n")
+	w.Close()
+
+	out := execute(t, device, "logreset")
+	if !strings.Contains(out, "Type the device name") {
+		t.Errorf("logreset printed %s", out)
+	}
+	if sent := device.sent(t); len(sent) != 0 {
+		t.Errorf("refused reset sent %v", sent)
+	}
+
+	out = execute(t, device, "logreset", "--force")
+	if !strings.Contains(out, "Device log reset confirmed\n") {
+		t.Errorf("logreset --force printed %s", out)
+	}
+	device.mutex.Lock()
+	if len(device.log) != 0 {
+		t.Errorf("device log has %d entries after reset", len(device.log))
+	}
+	device.mutex.Unlock()
+}
@@ -1,82 +1,308 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/mattn/go-isatty"
+	"github.com/phelpsw/camera-trigger-bt-cli/alert"
 	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/recording"
 	"github.com/spf13/cobra"
 )
 
 func init() {
+	monitorCmd.Flags().BoolVar(&monitorPlain, "plain", false, "Print each status message rather than showing a dashboard")
+	monitorCmd.Flags().StringArrayVarP(&alertRules, "alert", "a", nil, "Alert rule, e.g. \"voltage < 3.4 => exit\" (repeatable)")
+	monitorCmd.Flags().StringVar(&alertFile, "alert-file", "", "File of alert rules, one per line")
+	monitorCmd.Flags().IntVar(&alertLogCapacity, "log-capacity", 0, "Log entries the device can hold, required by capacity rules")
+
+	monitorCmd.Flags().DurationVar(&timeConnectTimeout, "connect-timeout", 30*time.Second, "Time to search for each device")
+	addSelectorFlags(monitorCmd)
+
 	rootCmd.AddCommand(monitorCmd)
 }
 
 var monitorCmd = &cobra.Command{
 	Use:   "monitor",
 	Short: "Pretty Print all status messages from the device",
-	Long:  "Pretty Print all status messages from the device",
-	Run:   monitor,
+	Long: `Pretty Print all status messages from the device
+
+When run in a terminal the status is shown as a dashboard which updates in
+place. Key bindings shown at the bottom of the dashboard trigger the device
+or adjust its thresholds. Use --plain, or redirect the output, to print every
+status message instead.
+
+With --site or --tag every selected inventory device is monitored at once,
+each line of its status prefixed with its name. The dashboard shows a single
+device, so several are always printed as with --plain. Devices not found
+within --connect-timeout are reported unreachable and skipped.
+
+Alert rules flag problems on unattended devices. Each rule is a condition
+optionally followed by => and actions separated by ;
+
+  FIELD OP NUMBER              voltage < 3.4, cpu_temperature > 70
+  no status for DURATION       no status for 60s
+  log_entries approaching capacity (90% of --log-capacity, which must be
+                                   given as the device does not report it)
+
+Fields are voltage, cpu_temperature, log_entries, motion, motion_threshold,
+lux, level, current and light_temperature. Actions are print (the default),
+exit (with status 1), exec COMMAND (run with sh, ALERT_RULE, ALERT_DEVICE,
+ALERT_VALUE and ALERT_TIME set) and post URL (the alert as json). Quote a
+command containing ; as in exec "date; uptime". A rule fires once when its
+condition becomes true and again only after it clears.
+
+  monitor -a "voltage < 3.4" -a "no status for 60s => post http://localhost:9000/alert; exit"`,
+	RunE:         monitor,
+	SilenceUsage: true,
+}
+
+var (
+	monitorPlain     bool
+	alertRules       []string
+	alertFile        string
+	alertLogCapacity int
+	dash             *dashboard
+	alerts           *alert.Engine
+
+	// lastAlert is printed when the dashboard closes on an exit action.
+	// Alerts are printed from the status handlers, silence checks and
+	// background actions.
+	alertMutex sync.Mutex
+	lastAlert  string
+)
+
+// alertPrint shows alerts on the dashboard when it is running
+func alertPrint(msg string) {
+	alertMutex.Lock()
+	lastAlert = msg
+	alertMutex.Unlock()
+
+	if dash != nil {
+		dash.setMessage(msg)
+		return
+	}
+	log.Println(msg)
+}
+
+// loadAlerts builds the alert engine from the --alert and --alert-file
+// rules, returning nil when there are none
+func loadAlerts() (*alert.Engine, error) {
+	var rules []alert.Rule
+
+	if alertFile != "" {
+		contents, err := ioutil.ReadFile(alertFile)
+		if err != nil {
+			return nil, err
+		}
+		rules, err = alert.ParseFile(string(contents))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", alertFile, err)
+		}
+	}
+
+	for _, text := range alertRules {
+		r, err := alert.Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	e := alert.NewEngine(rules, alertPrint)
+	e.LogCapacity = alertLogCapacity
+	err := e.Check()
+	if err != nil {
+		return nil, fmt.Errorf("%s, set --log-capacity", err)
+	}
+	return e, nil
+}
+
+// watchSilence checks the silence rules once a second
+func watchSilence(e *alert.Engine) {
+	for now := range time.Tick(time.Second) {
+		e.Dispatch(e.CheckSilence(now))
+	}
+}
+
+// deviceMonitor shows the status messages of one device
+type deviceMonitor struct {
+	name string
+	// prefix labels each line printed with the device name, used when
+	// several devices are monitored
+	prefix bool
+	board  boards.Basic
+	motion boards.Motion
+	light  boards.Light
 }
 
-var motionBoard boards.Motion
-var lightBoard boards.Light
+// printMutex keeps the status printouts of devices from interleaving
+var printMutex sync.Mutex
+
+func (d *deviceMonitor) handler(m interface{}) error {
+	var out bytes.Buffer
 
-func monitorHandler(m interface{}) error {
 	switch m.(type) {
 	case *boards.Basic:
 		b := m.(*boards.Basic)
 		if b.GetType() == reflect.TypeOf(boards.Motion{}) {
 			// Initialize motion board type from basic board
-			motionBoard.InitFromBasic(b)
-			motionBoard.SetUpdateCallback(monitorHandler)
+			d.motion.InitFromBasic(b)
+			d.motion.SetUpdateCallback(d.handler)
 		} else if b.GetType() == reflect.TypeOf(boards.Light{}) {
 			// Initialize motion board type from basic board
-			lightBoard.InitFromBasic(b)
-			lightBoard.SetUpdateCallback(monitorHandler)
+			d.light.InitFromBasic(b)
+			d.light.SetUpdateCallback(d.handler)
 		} else {
-			fmt.Printf("Unknown type, %v\n", b.GetType())
+			fmt.Fprintf(&out, "Unknown type, %v\n", b.GetType())
 		}
 	case *boards.Motion:
 		b := m.(*boards.Motion)
-		fmt.Printf("Motion Sensor\n")
-		fmt.Printf("  Motion: %.3f Thresh %.3f\n", b.Motion(), b.MotionThreshold())
-		fmt.Printf("  Light: %.2f lux\n", b.Lux())
-		fmt.Printf("    Thresh Low: %.2f High %.2f\n", b.LuxLowThreshold(), b.LuxHighThreshold())
-		fmt.Printf("  Transmit Cooldown %.1f sec\n", b.Cooldown())
-		fmt.Printf("  CPU Temp %.2f degC\n", b.Temperature())
-		fmt.Printf("  Log Count: %d\n", b.LogEntries())
+		if alerts != nil {
+			alerts.Dispatch(alerts.Evaluate(recording.MotionSample(d.name, b, time.Now())))
+		}
+		if dash != nil {
+			dash.updateMotion(b)
+			return nil
+		}
+
+		fmt.Fprintf(&out, "Motion Sensor\n")
+		fmt.Fprintf(&out, "  Motion: %.3f Thresh %.3f\n", b.Motion(), b.MotionThreshold())
+		fmt.Fprintf(&out, "  Light: %.2f lux\n", b.Lux())
+		fmt.Fprintf(&out, "    Thresh Low: %.2f High %.2f\n", b.LuxLowThreshold(), b.LuxHighThreshold())
+		fmt.Fprintf(&out, "  Transmit Cooldown %.1f sec\n", b.Cooldown())
+		fmt.Fprintf(&out, "  CPU Temp %.2f degC\n", b.Temperature())
+		fmt.Fprintf(&out, "  Voltage %.2f V\n", b.Voltage())
+		fmt.Fprintf(&out, "  Log Count: %d\n", b.LogEntries())
 
 	case *boards.Light:
 		b := m.(*boards.Light)
-		fmt.Printf("Light Controller\n")
-		fmt.Printf("  Brightness Level %f\n", b.Level())
-		fmt.Printf("    Delay %.2f sec", b.Delay())
-		fmt.Printf("    Attack %.2f sec", b.Attack())
-		fmt.Printf("    Sustain %.2f sec", b.Sustain())
-		fmt.Printf("    Release %.2f sec", b.Release())
-		fmt.Printf("  CPU Temp %.2f degC\n", b.Temperature())
-		fmt.Printf("  Log Count: %d\n", b.LogEntries())
+		if alerts != nil {
+			alerts.Dispatch(alerts.Evaluate(recording.LightSample(d.name, b, time.Now())))
+		}
+		if dash != nil {
+			dash.updateLight(b)
+			return nil
+		}
+
+		fmt.Fprintf(&out, "Light Controller\n")
+		fmt.Fprintf(&out, "  Brightness Level %f\n", b.Level())
+		fmt.Fprintf(&out, "    Delay %.2f sec\n", b.Delay())
+		fmt.Fprintf(&out, "    Attack %.2f sec\n", b.Attack())
+		fmt.Fprintf(&out, "    Sustain %.2f sec\n", b.Sustain())
+		fmt.Fprintf(&out, "    Release %.2f sec\n", b.Release())
+		fmt.Fprintf(&out, "  CPU Temp %.2f degC\n", b.Temperature())
+		fmt.Fprintf(&out, "  Voltage %.2f V\n", b.Voltage())
+		fmt.Fprintf(&out, "  Log Count: %d\n", b.LogEntries())
 	}
-	fmt.Printf("\n")
 
+	d.print(out.String())
 	return nil
 }
 
-func monitor(cmd *cobra.Command, args []string) {
-	var done = make(chan struct{})
+// print writes a status printout followed by a blank line
+func (d *deviceMonitor) print(text string) {
+	printMutex.Lock()
+	defer printMutex.Unlock()
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		if d.prefix {
+			fmt.Printf("%s: ", d.name)
+		}
+		fmt.Print(line)
+	}
+	fmt.Printf("\n")
+}
 
-	m := boards.Basic{}
+func monitor(cmd *cobra.Command, args []string) error {
+	var done <-chan struct{} = make(chan struct{})
+	var exit <-chan struct{}
 
-	err := m.Init(deviceID, debug)
+	e, err := loadAlerts()
 	if err != nil {
-		log.Panicln(err)
-		return
+		return err
 	}
 
-	m.SetUpdateCallback(monitorHandler)
+	names := []string{deviceID}
+	if selecting() {
+		devices, err := selectedDevices()
+		if err != nil {
+			return err
+		}
+		names = nil
+		for _, d := range devices {
+			names = append(names, d.Name)
+		}
+	}
 
-	<-done
+	var monitors []*deviceMonitor
+	for _, name := range names {
+		d := &deviceMonitor{name: name, prefix: selecting()}
+		err = connectDevice(&d.board, name)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		defer d.board.Close()
+		monitors = append(monitors, d)
+	}
+	if len(monitors) == 0 {
+		return fmt.Errorf("no devices connected")
+	}
+
+	if !monitorPlain && len(monitors) == 1 && isatty.IsTerminal(os.Stdout.Fd()) {
+		d := newDashboard(&monitors[0].board)
+		err = d.Start()
+		if err != nil {
+			return err
+		}
+		dash = d
+		done = d.Done()
+	}
+
+	alerts = e
+	if e != nil {
+		exit = e.Exit()
+		for _, d := range monitors {
+			e.Watch(d.name, time.Now())
+		}
+		go watchSilence(e)
+	}
+
+	for _, d := range monitors {
+		d.board.SetUpdateCallback(d.handler)
+	}
+
+	select {
+	case <-done:
+	case <-exit:
+		if dash != nil {
+			dash.Stop()
+			alertMutex.Lock()
+			log.Println(lastAlert)
+			alertMutex.Unlock()
+		}
+		return fmt.Errorf("exiting on alert")
+	}
+
+	if dash != nil {
+		dash.Stop()
+	}
 	log.Println("Done")
+	return nil
 }
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+// executeErr runs a command line against device which is expected to fail
+func executeErr(t *testing.T, device *fakeDevice, args ...string) (string, error) {
+	t.Helper()
+
+	return runErr(t, func() connection.Transport {
+		return device.conn
+	}, append([]string{"--device", "fake"}, args...)...)
+}
+
+func TestMonitorAlertExit(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{
+		Motion:          0.125,
+		MotionThreshold: 0.3,
+		Voltage:         3.3,
+	})
+	device.statusEvery(t, 20*time.Millisecond)
+
+	out, err := executeErr(t, device, "monitor", "--plain", "-a", "voltage < 3.4 => print; exit")
+
+	if err == nil || err.Error() != "exiting on alert" {
+		t.Errorf("monitor returned %v, want exiting on alert", err)
+	}
+	if !strings.Contains(out, "Motion Sensor\n  Motion: 0.125 Thresh 0.300\n") {
+		t.Errorf("monitor printed %s", out)
+	}
+	// Returning rather than exiting closes the connection
+	if device.conn.IsConnected() {
+		t.Errorf("device left connected")
+	}
+}
+
+func TestMonitorLogCapacityRequired(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+
+	_, err := executeErr(t, device, "monitor", "--plain", "-a", "log_entries approaching capacity")
+	if err == nil || !strings.Contains(err.Error(), "--log-capacity") {
+		t.Errorf("monitor returned %v, want --log-capacity required", err)
+	}
+	if written := device.conn.Written(); len(written) != 0 {
+		t.Errorf("device was sent %d frames", len(written))
+	}
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/config"
+	"github.com/phelpsw/camera-trigger-bt-cli/inventory"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	profileApplyCmd.Flags().BoolVarP(&profileDryRun, "dry-run", "n", false, "Show the settings which differ without changing them")
+	profileApplyCmd.Flags().DurationVar(&profileTimeout, "timeout", 30*time.Second, "Time to wait for the device to report its new configuration")
+	profileApplyCmd.Flags().DurationVar(&timeConnectTimeout, "connect-timeout", 30*time.Second, "Time to search for each device")
+	addSelectorFlags(profileApplyCmd)
+	profileSaveCmd.Flags().DurationVar(&timeConnectTimeout, "connect-timeout", 30*time.Second, "Time to search for the device")
+	profileSaveCmd.Flags().StringSliceVar(&profileParams, "param", nil, "Parameters to save (default the persistent parameters other than device_id and device_group)")
+
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileApplyCmd)
+	profileCmd.AddCommand(profileSaveCmd)
+	rootCmd.AddCommand(profileCmd)
+}
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Apply and save profiles of device settings",
+	Long: `Apply and save profiles of device settings
+
+Profiles are named sets of settings in the config file, by default
+~/.camera-trigger/config.yaml, e.g.
+
+  profiles:
+    night-trail:
+      motion:
+        motion_threshold: 0.35
+        lux_low: 5
+      light:
+        level: 0.8
+        sustain: 20
+      params:
+        led_on_record: 0
+
+The motion settings are those of cfgmotion (motion_threshold, lux_low,
+lux_high and cooldown), the light settings those of cfglights (level, delay,
+attack, sustain and release) and params are named as for the prompt.
+Settings left out of a profile are not changed.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the profiles in the config file",
+	Long:  "List the profiles in the config file",
+	Args:  cobra.NoArgs,
+	Run:   profileList,
+}
+
+var profileApplyCmd = &cobra.Command{
+	Use:   "apply [NAME]",
+	Short: "Apply a profile to the device",
+	Long: `Apply a profile to the device
+
+Reads the settings of the device which the profile covers and changes those
+which differ. Motion settings only apply to motion sensors and light
+settings to lights, so one profile can describe a pair.
+
+Without NAME the profile given for the device in the inventory is applied.
+With --site or --tag the profile is applied to each selected inventory
+device in turn.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  profileApply,
+}
+
+var profileSaveCmd = &cobra.Command{
+	Use:   "save NAME",
+	Short: "Save the settings of the device as a profile",
+	Long: `Save the settings of the device as a profile
+
+Adds the configuration of the device and its parameters to the config file
+as profile NAME, replacing any profile of that name. Comments in the config
+file are not kept.`,
+	Args: cobra.ExactArgs(1),
+	Run:  profileSave,
+}
+
+var (
+	profileDryRun  bool
+	profileTimeout time.Duration
+	profileParams  []string
+)
+
+func profileList(cmd *cobra.Command, args []string) {
+	for _, name := range configFile.Names() {
+		p := configFile.Profiles[name]
+		var sections []string
+		if p.Motion != nil {
+			sections = append(sections, "motion")
+		}
+		if p.Light != nil {
+			sections = append(sections, "light")
+		}
+		if len(p.Params) > 0 {
+			sections = append(sections, fmt.Sprintf("%d params", len(p.Params)))
+		}
+		fmt.Printf("%s %v\n", name, sections)
+	}
+}
+
+// connectProfileBoard connects to the device and waits for the status
+// message which gives its type and configuration
+func connectProfileBoard(m *boards.Basic) error {
+	err := connectDevice(m, deviceID)
+	if err != nil {
+		return err
+	}
+
+	return m.WaitForStatus(10 * time.Second)
+}
+
+func profileApply(cmd *cobra.Command, args []string) {
+	runSelected(func(d *inventory.Device) {
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		} else if d != nil {
+			name = d.Profile
+		}
+		if name == "" {
+			log.Printf("No profile given for %s\n", deviceID)
+			return
+		}
+		applyProfile(name)
+	})
+}
+
+// applyProfile applies the named profile to deviceID
+func applyProfile(name string) {
+	p, err := configFile.Profile(name)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	m := boards.Basic{}
+	err = connectProfileBoard(&m)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer m.Close()
+
+	var changed []config.Setting
+	if profileDryRun {
+		settings, err := config.Compare(&m, p)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		for _, s := range settings {
+			if s.Differs() {
+				changed = append(changed, s)
+			}
+		}
+	} else {
+		changed, err = config.Apply(&m, p, profileTimeout)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+	}
+
+	if len(changed) == 0 {
+		fmt.Printf("%s already matches profile %s\n", deviceID, name)
+	}
+	for _, s := range changed {
+		fmt.Println(s)
+	}
+
+	log.Println("Done")
+}
+
+func profileSave(cmd *cobra.Command, args []string) {
+	params := profileParams
+	if !cmd.Flags().Changed("param") {
+		params = config.SharedParams()
+	}
+
+	m := boards.Basic{}
+	err := connectProfileBoard(&m)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer m.Close()
+
+	p, err := config.Capture(&m, params)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	configFile.SetProfile(args[0], p)
+	err = configFile.Save(configPath)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	fmt.Printf("Saved profile %s to %s\n", args[0], configPath)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/config"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func writeConfig(t *testing.T, data string) string {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(path, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const nightTrail = `
+profiles:
+  night-trail:
+    motion:
+      motion_threshold: 0.35
+      lux_low: 5
+    light:
+      level: 0.8
+      sustain: 20
+    params:
+      motion_gain: 0.5
+      led_on_record: 2
+`
+
+func TestProfileApply(t *testing.T) {
+	path := writeConfig(t, nightTrail)
+	device := newMotionDevice(messages.MotionSensorStatusMessage{
+		MotionThreshold:  0.5,
+		LuxLowThreshold:  5,
+		LuxHighThreshold: 100,
+		Cooldown:         10,
+	})
+	gain, _, _ := boards.FloatIndex("motion_gain")
+	led, _, _ := boards.Uint16Index("led_on_record")
+	device.floats[paramKey{gain, 1}] = 0.5
+	device.uint16s[paramKey{led, 1}] = 1
+
+	out := execute(t, device, "--config", path, "profile", "apply", "--dry-run", "night-trail")
+	want := "motion.motion_threshold 0.5 -> 0.35\nled_on_record 1 -> 2\n"
+	if out != want {
+		t.Errorf("dry run printed %q, want %q", out, want)
+	}
+	for _, msg := range device.sent(t) {
+		switch msg.(type) {
+		case messages.SetFloatRequest, messages.SetUint16Request, messages.MotionSensorConfigMessage:
+			t.Errorf("dry run sent %+v", msg)
+		}
+	}
+
+	out = execute(t, device, "--config", path, "profile", "apply", "night-trail")
+	if out != want {
+		t.Errorf("printed %q, want %q", out, want)
+	}
+	if device.uint16s[paramKey{led, 1}] != 2 {
+		t.Errorf("led_on_record is %d", device.uint16s[paramKey{led, 1}])
+	}
+	wantConfig := messages.NewMotionSensorConfigMessage(0.35, 5, 100, 10)
+	var configs []interface{}
+	for _, msg := range device.sent(t) {
+		if _, ok := msg.(messages.MotionSensorConfigMessage); ok {
+			configs = append(configs, msg)
+		}
+	}
+	if len(configs) != 1 || configs[0] != wantConfig {
+		t.Errorf("sent %+v, want %+v", configs, wantConfig)
+	}
+
+	out = execute(t, device, "--config", path, "profile", "apply", "night-trail")
+	if want := "fake already matches profile night-trail\n"; out != want {
+		t.Errorf("printed %q, want %q", out, want)
+	}
+}
+
+func TestProfileSave(t *testing.T) {
+	path := writeConfig(t, nightTrail)
+	device := newLightDevice(messages.LightStatusMessage{
+		Payload: messages.LightStatus{Level: 0.6, Delay: 1, Attack: 2, Sustain: 10, Release: 4},
+	})
+	sustain, _, _ := boards.FloatIndex("light_sustain")
+	device.floats[paramKey{sustain, 1}] = 10
+
+	out := execute(t, device, "--config", path, "profile", "save", "--param", "light_sustain", "porch")
+	if want := "Saved profile porch to " + path + "\n"; out != want {
+		t.Errorf("printed %q, want %q", out, want)
+	}
+
+	f, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := f.Names(); len(names) != 2 {
+		t.Errorf("profiles %v, want night-trail kept", names)
+	}
+	p, _ := f.Profile("porch")
+	if p.Motion != nil || p.Light == nil || *p.Light.Level != 0.6 || *p.Light.Release != 4 ||
+		len(p.Params) != 1 || p.Params["light_sustain"] != 10 {
+		t.Errorf("saved %+v", p)
+	}
+
+	out = execute(t, device, "--config", path, "profile", "list")
+	if want := "night-trail [motion light 2 params]\nporch [light 1 params]\n"; out != want {
+		t.Errorf("printed %q, want %q", out, want)
+	}
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"log"
-	"os"
 	"strconv"
 	"strings"
 
@@ -23,120 +22,13 @@ var promptCmd = &cobra.Command{
 	Run:   promptFunc,
 }
 
-type Member struct {
-	Name        string
-	Description string
+// promptExit reports whether the line entered ends the prompt
+func promptExit(in string, breakline bool) bool {
+	return breakline && strings.TrimSpace(in) == "exit"
 }
 
-var uint16_persist = []Member{
-	{"device_id", "Device ID"},
-	{"device_group", "Device Group ID"},
-	{"sony_sleep_mode", "Sony camera sleep mode, 0 - Off, 1 - Idle"},
-	{"led_on_record", "1 - red led, 2 - green led, 0 - disabled"},
-	{"motion_blink_on_detect", "1 - red led, 2 - green led, 0 - disabled"},
-	{"motion_transmit_on_detect", "1 - transmit enable, 0 - disabled"},
-}
-
-var uint16_temp = []Member{
-	{"version_major", "Major version number"},
-	{"version_minor", "Minor version number"},
-	{"version_patch", "Patch version number"},
-	{"version_dirty", "Bit indicating whether local mods have been made"},
-	{"version_hash1", "Top 16 bits of git version hash"},
-	{"version_hash2", "Lower 16 bits of git version hash"},
-	{"part_number", ""},
-	{"serial_number", ""},
-	{"manufacture_year", ""},
-	{"manufacture_doy", ""},
-	{"device_type", "Device type"},
-	{"led_red_state", "Red LED State, 0 - Off, 1 - On, 2 - Blink Once, 3 - Blink Continuous"},
-	{"led_green_state", "Green LED State, 0 - Off, 1 - On, 2 - Blink Once, 3 - Blink Continuous"},
-	{"motion_state", "Motion sensor state machine state"},
-	{"motion_trigger_count", "Motion sensor trigger count since boot"},
-	{"trigger_state", "State of device trigger, 0 available, 1 - cooldown"},
-	{"runcam_control_state", "Runcam controller state"},
-	{"runcam_state", "Runcam button push state machine"},
-	{"sony_control_state", ""},
-	{"sony_version_major", ""},
-	{"sony_version_minor", ""},
-	{"sony_version_patch", ""},
-	{"sony_version_dirty", ""},
-	{"sony_version_reg1", ""},
-	{"sony_version_reg2", ""},
-	{"sony_version_reg3", ""},
-	{"sony_version_reg4", ""},
-	{"sony_type", ""},
-	{"sony_mode", ""},
-	{"sony_status", ""},
-	{"sony_led", ""},
-}
-
-var float_persist = []Member{
-	{"motion_gain", "Motion sensor gain (0.0 - 1.0)"},
-	{"motion_threshold", "Motion sensor trigger threshold (0.0 - 1.0)"},
-	{"motion_cooldown", "Minimum seconds between motion sensor retrigger"},
-	{"lux_interval", "Lux measurement interval"},
-	{"video_duration", "Length of video recording trigger event in seconds"},
-	{"trigger_max_duration", "Cumulative consecutive length of trigger events in seconds"},
-	{"trigger_max_duration_cooldown", "Cooldown period following trigger max duration"},
-	{"led_on_period", "On time of LED blink"},
-	{"led_off_period", "Seconds between continuous LED blinks"},
-	{"light_level2_thresh", "Lux level for light brightness level 2"},
-	{"light_level3_thresh", "Lux level for light brightness level 3"},
-	{"light_delay", "Light delay in seconds before fade up"},
-	{"light_attack", "Light fade up in seconds"},
-	{"light_sustain", "Light on period in seconds"},
-	{"light_release", "Light fade out in seconds"},
-}
-
-var float_temp = []Member{
-	{"cpu_temperature", "Major version number"},
-	{"battery_voltage", "Minor version number"},
-	{"uptime", "System uptime in seconds"},
-	{"motion_value", "Motion sensor value"},
-	{"lux_value", "Lux measurement"},
-}
-
-var m boards.Basic
-
-func getIndex(input string, members []Member) (int, error) {
-	for indx, member := range members {
-		if input == member.Name {
-			return indx, nil
-		}
-	}
-
-	return 0, fmt.Errorf("not found")
-}
-
-func getUint16Index(input string) (uint16, uint8, error) {
-	indx, err := getIndex(input, uint16_persist)
-	if err == nil {
-		return uint16(indx), 1, nil
-	}
-
-	indx, err = getIndex(input, uint16_temp)
-	if err == nil {
-		return uint16(indx), 0, nil
-	}
-
-	return 0, 0, fmt.Errorf("not found")
-}
-
-func getFloatIndex(input string) (uint16, uint8, error) {
-	indx, err := getIndex(input, float_persist)
-	if err == nil {
-		return uint16(indx), 1, nil
-	}
-
-	indx, err = getIndex(input, float_temp)
-	if err == nil {
-		return uint16(indx), 0, nil
-	}
-
-	return 0, 0, fmt.Errorf("not found")
-}
-func executorFunc(in string) {
+// executorFunc runs a line entered at the prompt against board m
+func executorFunc(m *boards.Basic, in string) {
 	in = strings.TrimSpace(in)
 
 	var value_uint16 uint16
@@ -146,7 +38,7 @@ func executorFunc(in string) {
 	switch blocks[0] {
 	case "exit":
 		fmt.Println("Bye!")
-		os.Exit(0)
+		return
 	case "gi":
 		command = "gi"
 		if len(blocks) != 2 {
@@ -207,7 +99,7 @@ func executorFunc(in string) {
 
 	switch command {
 	case "gi":
-		indx, persist, err := getUint16Index(variable)
+		indx, persist, err := boards.Uint16Index(variable)
 		if err != nil {
 			fmt.Println("variable is not uint16")
 			return
@@ -225,7 +117,7 @@ func executorFunc(in string) {
 			fmt.Printf("%s: get failed\n", variable)
 		}
 	case "si":
-		indx, persist, err := getUint16Index(variable)
+		indx, persist, err := boards.Uint16Index(variable)
 		if err != nil {
 			fmt.Println("variable is not uint16")
 			return
@@ -243,7 +135,7 @@ func executorFunc(in string) {
 			fmt.Printf("%s: set failed\n", variable)
 		}
 	case "gf":
-		indx, persist, err := getFloatIndex(variable)
+		indx, persist, err := boards.FloatIndex(variable)
 		if err != nil {
 			fmt.Println("variable is not float")
 			return
@@ -261,7 +153,7 @@ func executorFunc(in string) {
 			fmt.Printf("%s: get failed\n", variable)
 		}
 	case "sf":
-		indx, persist, err := getFloatIndex(variable)
+		indx, persist, err := boards.FloatIndex(variable)
 		if err != nil {
 			fmt.Println("variable is not float")
 			return
@@ -305,16 +197,16 @@ func completerFunc(d prompt.Document) []prompt.Suggest {
 		{Text: "exit", Description: "Exit the program"},
 	}
 
-	for _, elem := range uint16_persist {
+	for _, elem := range boards.Uint16Persist {
 		s = append(s, prompt.Suggest{Text: elem.Name, Description: elem.Description})
 	}
-	for _, elem := range uint16_temp {
+	for _, elem := range boards.Uint16Temp {
 		s = append(s, prompt.Suggest{Text: elem.Name, Description: elem.Description})
 	}
-	for _, elem := range float_persist {
+	for _, elem := range boards.FloatPersist {
 		s = append(s, prompt.Suggest{Text: elem.Name, Description: elem.Description})
 	}
-	for _, elem := range float_temp {
+	for _, elem := range boards.FloatTemp {
 		s = append(s, prompt.Suggest{Text: elem.Name, Description: elem.Description})
 	}
 
@@ -322,24 +214,26 @@ func completerFunc(d prompt.Document) []prompt.Suggest {
 }
 
 func promptFunc(cmd *cobra.Command, args []string) {
-	m = boards.Basic{}
+	m := boards.Basic{}
 
 	err := m.Init(deviceID, debug)
 	if err != nil {
-		log.Panicln(err)
+		log.Println(err)
 		return
 	}
+	defer m.Close()
 
 	for !m.IsConnected() {
 	}
 
 	p := prompt.New(
-		executorFunc,
+		func(in string) { executorFunc(&m, in) },
 		completerFunc,
 		prompt.OptionTitle("camera-prompt: camera-trigger configuration prompt"),
 		prompt.OptionPrefix("> "),
 		prompt.OptionInputTextColor(prompt.Yellow),
 		prompt.OptionCompletionOnDown(),
+		prompt.OptionSetExitCheckerOnInput(promptExit),
 	)
 
 	p.Run()
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestPrompt(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+
+	var m boards.Basic
+	m.SetTransport(device.conn)
+	err := m.Init("fake", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	lines := map[string]string{
+		"sf motion_threshold 0.3": "motion_threshold: 0.300000\n",
+		"gf motion_threshold":     "motion_threshold: 0.300000\n",
+		"gf motion_gain":          "motion_gain: get failed\n",
+		"si device_id 7":          "device_id: 7\n",
+		"gi device_id":            "device_id: 7\n",
+		"gf device_id":            "variable is not float\n",
+		"si device_id x":          "cannot convert value to uint16\n",
+		"t 12.5":                  "trigger sent (lux 12.500000)\n",
+		"exit":                    "Bye!\n",
+	}
+	// Gets follow the sets they read back
+	order := []string{"sf motion_threshold 0.3", "gf motion_threshold", "gf motion_gain",
+		"si device_id 7", "gi device_id", "gf device_id", "si device_id x", "t 12.5", "exit"}
+
+	for _, in := range order {
+		out := captureStdout(t, func() { executorFunc(&m, in) })
+		if out != lines[in] {
+			t.Errorf("%s printed %q, want %q", in, out, lines[in])
+		}
+	}
+
+	if len(device.triggers) != 1 || device.triggers[0] != 12.5 {
+		t.Errorf("triggers %v, want [12.5]", device.triggers)
+	}
+	if !m.IsConnected() {
+		t.Error("exit disconnected the board")
+	}
+	if !promptExit(" exit ", true) || promptExit("exit", false) || promptExit("gf motion_gain", true) {
+		t.Error("promptExit does not end the prompt on exit alone")
+	}
+}
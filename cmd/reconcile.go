@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/config"
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/inventory"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addSelectorFlags(reconcileCmd)
+	reconcileCmd.Flags().BoolVarP(&utc, "utc", "u", false, "Device clocks are kept in UTC rather than local time")
+	reconcileCmd.Flags().IntVarP(&clockSamples, "samples", "n", 3, "Number of status messages used to measure drift")
+	reconcileCmd.Flags().DurationVar(&clockMaxDrift, "max-drift", 2*time.Second, "Drift allowed before a clock is set")
+	reconcileCmd.Flags().DurationVar(&timeConnectTimeout, "connect-timeout", 30*time.Second, "Time to search for each device")
+	reconcileCmd.Flags().DurationVar(&profileTimeout, "timeout", 30*time.Second, "Time to wait for a device to report its new configuration")
+
+	rootCmd.AddCommand(reconcileCmd)
+}
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Bring the devices of a site to their desired state",
+	Long: `Bring the devices of a site to their desired state
+
+Scans for the inventory devices selected with --site or --tag and connects
+to each one found. The device must report the role and firmware given in the
+inventory; a device which does not is failed and left unchanged, as firmware
+cannot be updated over bluetooth. Otherwise settings which differ from the
+device's profile are applied and the clock is set if it has drifted by more
+than --max-drift.
+
+Finishes with a report of the devices which were compliant, corrected,
+unreachable (not found or not connecting) and failed.`,
+	Args: cobra.NoArgs,
+	Run:  reconcile,
+}
+
+// Outcomes of reconciling a device, in the order reported
+const (
+	reconcileCompliant   = "Compliant"
+	reconcileCorrected   = "Corrected"
+	reconcileUnreachable = "Unreachable"
+	reconcileFailed      = "Failed"
+)
+
+var reconcileOutcomes = []string{
+	reconcileCompliant,
+	reconcileCorrected,
+	reconcileUnreachable,
+	reconcileFailed,
+}
+
+// deviceFirmware reads the firmware version of the device
+func deviceFirmware(m *boards.Basic) (inventory.Version, error) {
+	var v inventory.Version
+	names := []string{"version_major", "version_minor", "version_patch"}
+	fields := []*uint16{&v.Major, &v.Minor, &v.Patch}
+	for i, name := range names {
+		value, err := config.ReadParam(m, name)
+		if err != nil {
+			return v, err
+		}
+		*fields[i] = uint16(value)
+	}
+	return v, nil
+}
+
+// statusRole returns the role of a device from its status message
+func statusRole(status interface{}) inventory.Role {
+	switch status.(type) {
+	case messages.MotionSensorStatusMessage:
+		return inventory.Motion
+	case messages.LightStatusMessage:
+		return inventory.Light
+	}
+	return ""
+}
+
+// reconcileDevice brings a connected device to the state given for it in the
+// inventory, reporting whether anything was changed
+func reconcileDevice(m *boards.Basic, d inventory.Device) (bool, error) {
+	role := statusRole(m.Status())
+	if d.Role != "" && role != d.Role {
+		return false, fmt.Errorf("device is a %s, inventory role %s", role, d.Role)
+	}
+
+	firmware, err := deviceFirmware(m)
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("Firmware %s\n", firmware)
+	if d.Firmware != "" {
+		want, err := inventory.ParseVersion(d.Firmware)
+		if err != nil {
+			return false, err
+		}
+		if firmware != want {
+			return false, fmt.Errorf("firmware %s, want %s", firmware, want)
+		}
+	}
+
+	corrected := false
+	if d.Profile != "" {
+		p, err := configFile.Profile(d.Profile)
+		if err != nil {
+			return false, err
+		}
+		changed, err := config.Apply(m, p, profileTimeout)
+		if err != nil {
+			return false, err
+		}
+		for _, s := range changed {
+			fmt.Println(s)
+		}
+		corrected = len(changed) > 0
+	}
+
+	clockSet, err := adjustClock(m, func(format string, v ...interface{}) {
+		fmt.Printf(format+"\n", v...)
+	})
+	if err != nil {
+		return false, err
+	}
+	return corrected || clockSet, nil
+}
+
+func reconcile(cmd *cobra.Command, args []string) {
+	if !selecting() {
+		log.Println("--site or --tag required")
+		return
+	}
+	if clockSamples < 1 {
+		log.Println("--samples must be at least 1")
+		return
+	}
+
+	devices, err := selectedDevices()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	found, err := scanDevices()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	outcomes := make(map[string][]string)
+	for _, d := range devices {
+		fmt.Printf("== %s %s\n", d.Name, d.Describe())
+
+		outcome, detail := reconcileOutcome(found, d)
+		entry := d.Name
+		if detail != "" {
+			fmt.Printf("%s: %s\n", outcome, detail)
+			entry += " (" + detail + ")"
+		} else {
+			fmt.Println(outcome)
+		}
+		outcomes[outcome] = append(outcomes[outcome], entry)
+	}
+
+	fmt.Printf("\n")
+	for _, outcome := range reconcileOutcomes {
+		entries := outcomes[outcome]
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Printf("%s: %d\n", outcome, len(entries))
+		for _, entry := range entries {
+			fmt.Printf("  %s\n", entry)
+		}
+	}
+
+	log.Println("Done")
+}
+
+// reconcileOutcome reconciles a device if it was found by the scan, returning
+// the outcome and why a device was unreachable or failed
+func reconcileOutcome(found map[string]connection.Device, d inventory.Device) (string, string) {
+	if _, ok := findScanned(found, d.Name, d.Address); !ok {
+		return reconcileUnreachable, "not found"
+	}
+
+	m := boards.Basic{}
+	m.SetConnectTimeout(timeConnectTimeout)
+	err := m.Init(d.Name, debug)
+	if err != nil {
+		return reconcileUnreachable, err.Error()
+	}
+	defer m.Close()
+
+	err = m.WaitForStatus(10 * time.Second)
+	if err != nil {
+		return reconcileUnreachable, err.Error()
+	}
+
+	corrected, err := reconcileDevice(&m, d)
+	if err != nil {
+		return reconcileFailed, err.Error()
+	}
+	if corrected {
+		return reconcileCorrected, ""
+	}
+	return reconcileCompliant, ""
+}
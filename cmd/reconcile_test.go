@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+const creek = `
+devices:
+  - name: camera-trigger-001
+    site: creek
+    role: motion
+    paired: camera-trigger-002
+    profile: night-trail
+    firmware: 1.4.2
+  - name: camera-trigger-002
+    site: creek
+    role: light
+    paired: camera-trigger-001
+    profile: night-trail
+    firmware: 1.4.2
+  - name: camera-trigger-003
+    site: creek
+    role: motion
+  - name: camera-trigger-004
+    site: creek
+    role: motion
+    profile: night-trail
+    firmware: 1.4.2
+  - name: camera-trigger-005
+    site: creek
+    role: light
+  - name: camera-trigger-006
+    site: creek
+  - name: camera-trigger-007
+    site: north-ridge
+`
+
+// setFirmware sets the version parameters of the device
+func (d *fakeDevice) setFirmware(major, minor, patch uint16) {
+	for name, value := range map[string]uint16{
+		"version_major": major,
+		"version_minor": minor,
+		"version_patch": patch,
+	} {
+		indx, persist, _ := boards.Uint16Index(name)
+		d.uint16s[paramKey{indx, persist}] = value
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	inv := writeConfig(t, creek)
+	cfg := writeConfig(t, `
+profiles:
+  night-trail:
+    motion:
+      motion_threshold: 0.35
+    light:
+      sustain: 20
+`)
+
+	motion := func() *fakeDevice {
+		return newMotionDevice(messages.MotionSensorStatusMessage{MotionThreshold: 0.35})
+	}
+	devices := map[string]*fakeDevice{
+		"camera-trigger-001": newMotionDevice(messages.MotionSensorStatusMessage{MotionThreshold: 0.5}),
+		"camera-trigger-002": newLightDevice(messages.LightStatusMessage{
+			Payload: messages.LightStatus{Sustain: 20},
+		}),
+		"camera-trigger-004": motion(),
+		"camera-trigger-005": motion(),
+		"camera-trigger-006": motion(),
+		"camera-trigger-007": motion(),
+	}
+	for name, d := range devices {
+		d.setFirmware(1, 4, 2)
+		d.setClock(0)
+		d.statusEvery(t, 20*time.Millisecond)
+		if name == "camera-trigger-004" {
+			d.setFirmware(1, 3, 0)
+		}
+		if name == "camera-trigger-006" {
+			d.setClock(-time.Hour)
+		}
+	}
+
+	out := executeSite(t, devices, "--inventory", inv, "--config", cfg,
+		"reconcile", "--site", "creek", "--samples", "1")
+
+	want := `
+Compliant: 1
+  camera-trigger-002
+Corrected: 2
+  camera-trigger-001
+  camera-trigger-006
+Unreachable: 1
+  camera-trigger-003 (not found)
+Failed: 2
+  camera-trigger-004 (firmware 1.3.0, want 1.4.2)
+  camera-trigger-005 (device is a motion, inventory role light)
+`
+	i := strings.Index(out, "\nCompliant:")
+	if i < 0 || out[i:] != want {
+		t.Errorf("reconcile printed %s\nwant report %s", out, want)
+	}
+	if !strings.Contains(out, "motion.motion_threshold 0.5 -> 0.35\n") {
+		t.Errorf("reconcile did not report the changed setting:\n%s", out)
+	}
+
+	// The devices keep sending their status until the test ends
+	d := devices["camera-trigger-001"]
+	d.mutex.Lock()
+	if got := d.motion.MotionThreshold; got != 0.35 {
+		t.Errorf("motion threshold %v, want 0.35", got)
+	}
+	d.mutex.Unlock()
+	d = devices["camera-trigger-006"]
+	d.mutex.Lock()
+	if clock := *d.clock; clock < -2*time.Second || clock > 2*time.Second {
+		t.Errorf("clock offset %v after reconcile", clock)
+	}
+	d.mutex.Unlock()
+	for _, name := range []string{"camera-trigger-004", "camera-trigger-005"} {
+		for _, msg := range devices[name].sent(t) {
+			switch msg.(type) {
+			case messages.GetUint16Request, messages.GetFloatRequest:
+			default:
+				t.Errorf("failed device %s was sent %T", name, msg)
+			}
+		}
+	}
+	if sent := devices["camera-trigger-007"].sent(t); len(sent) != 0 {
+		t.Errorf("device of another site was sent %v", sent)
+	}
+}
+
+func TestReconcileRequiresSelector(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+
+	out := execute(t, device, "reconcile")
+	if out != "" {
+		t.Errorf("reconcile without --site printed %q", out)
+	}
+	if written := device.conn.Written(); len(written) != 0 {
+		t.Errorf("device was sent %d frames", len(written))
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/recording"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	recordCmd.Flags().StringVarP(&recordOutput, "output", "o", "status.csv", "File to record status messages to")
+	recordCmd.Flags().StringVarP(&recordFormat, "format", "f", "", "Recording format, csv or jsonl (default from file extension)")
+	recordCmd.Flags().Int64Var(&recordRotateSize, "rotate-size", 0, "Start a new file after this many megabytes")
+	recordCmd.Flags().DurationVar(&recordRotateInterval, "rotate-interval", 0, "Start a new file after this period, e.g. 1h")
+
+	rootCmd.AddCommand(recordCmd)
+}
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record all status messages from the device to a file",
+	Long: `Record all status messages from the device to a file
+
+Every status message is appended as a row with the host time, device time
+and each measured value. When rotation is enabled each file is named after
+--output with its start time and a sequence number added, e.g.
+status-20201019-130405-1.csv.`,
+	Run: record,
+}
+
+var (
+	recordOutput         string
+	recordFormat         string
+	recordRotateSize     int64
+	recordRotateInterval time.Duration
+)
+
+// statusRecorder writes the status messages of a device to a recording
+type statusRecorder struct {
+	name   string
+	motion boards.Motion
+	light  boards.Light
+
+	// mutex guards writer and samples, the handler runs on the board's
+	// goroutine. writer is nil once closed.
+	mutex   sync.Mutex
+	writer  *recording.Writer
+	samples int
+}
+
+func (r *statusRecorder) handler(m interface{}) error {
+	var sample recording.Sample
+
+	switch m.(type) {
+	case *boards.Basic:
+		b := m.(*boards.Basic)
+		if b.GetType() == reflect.TypeOf(boards.Motion{}) {
+			r.motion.InitFromBasic(b)
+			r.motion.SetUpdateCallback(r.handler)
+		} else if b.GetType() == reflect.TypeOf(boards.Light{}) {
+			r.light.InitFromBasic(b)
+			r.light.SetUpdateCallback(r.handler)
+		}
+		return nil
+	case *boards.Motion:
+		sample = recording.MotionSample(r.name, m.(*boards.Motion), time.Now())
+	case *boards.Light:
+		sample = recording.LightSample(r.name, m.(*boards.Light), time.Now())
+	default:
+		return fmt.Errorf("unknown type %+v", reflect.TypeOf(m))
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.writer == nil {
+		return nil
+	}
+
+	err := r.writer.Write(sample)
+	if err != nil {
+		return err
+	}
+
+	r.samples++
+	if r.samples%60 == 1 {
+		fmt.Printf("%d samples recorded to %s\n", r.samples, r.writer.Filename())
+	}
+
+	return nil
+}
+
+// close stops recording and closes the file, returning the number of
+// samples recorded. A handler already running finishes its sample first.
+func (r *statusRecorder) close() (int, error) {
+	r.motion.SetUpdateCallback(nil)
+	r.light.SetUpdateCallback(nil)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	w := r.writer
+	r.writer = nil
+	return r.samples, w.Close()
+}
+
+func record(cmd *cobra.Command, args []string) {
+	w, err := recording.NewWriter(recordOutput, recordFormat,
+		recordRotateSize*1024*1024, recordRotateInterval)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	r := &statusRecorder{name: deviceID, writer: w}
+
+	// Registered before connecting so an interrupt always closes the file
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	m := boards.Basic{}
+
+	err = m.Init(deviceID, debug)
+	if err != nil {
+		log.Println(err)
+		w.Close()
+		return
+	}
+	defer m.Close()
+
+	m.SetUpdateCallback(r.handler)
+
+	<-interrupt
+
+	// Stop handling messages before the file is closed
+	m.SetUpdateCallback(nil)
+	samples, err := r.close()
+	if err != nil {
+		log.Println(err)
+	}
+
+	fmt.Printf("%d samples recorded\n", samples)
+	log.Println("Done")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"github.com/phelpsw/camera-trigger-bt-cli/recording"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.csv")
+	device := newMotionDevice(messages.MotionSensorStatusMessage{
+		Motion:          0.25,
+		MotionThreshold: 0.5,
+		Voltage:         3.7,
+	})
+	device.statusEvery(t, 20*time.Millisecond)
+
+	// Interrupt once a few samples are on disk, as with ctrl-c
+	go func() {
+		deadline := time.Now().Add(3 * time.Second)
+		for time.Now().Before(deadline) {
+			samples, err := recording.ReadFile(path)
+			if err == nil && len(samples) >= 3 {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		p, err := os.FindProcess(os.Getpid())
+		if err == nil {
+			err = p.Signal(os.Interrupt)
+		}
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	out := execute(t, device, "record", "-o", path)
+
+	samples, err := recording.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) < 3 {
+		t.Fatalf("recorded %d samples, want at least 3", len(samples))
+	}
+	for _, s := range samples {
+		if s.Board != recording.BoardMotion || s.Device != "fake" ||
+			s.Motion != 0.25 || s.MotionThreshold != 0.5 || s.Voltage != 3.7 {
+			t.Errorf("recorded %+v", s)
+		}
+	}
+	if !strings.Contains(out, "samples recorded\n") {
+		t.Errorf("record printed %s", out)
+	}
+}
@@ -1,6 +1,13 @@
 package cmd
 
 import (
+	"log"
+	"os"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/capture"
+	"github.com/phelpsw/camera-trigger-bt-cli/config"
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
 	"github.com/spf13/cobra"
 )
 
@@ -10,6 +17,13 @@ var (
 	userLicense string
 	deviceID    string
 	debug       bool
+	recordFile  string
+	replayFile  string
+	replaySpeed float64
+
+	// configFile is loaded from configPath by initConfig
+	configPath string
+	configFile *config.File
 
 	rootCmd = &cobra.Command{
 		Use:   "bluetooth-test",
@@ -26,12 +40,58 @@ func Execute() error {
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initConfig, initInventory, initCapture)
 
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Config file of profiles (default ~/.camera-trigger/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&inventoryFile, "inventory", "", "Inventory file of deployed devices (default ~/.camera-trigger/inventory.yaml)")
 	rootCmd.PersistentFlags().StringVarP(&deviceID, "device", "d", "", "Bluetooth device ID")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Set flag for debug messages")
+	rootCmd.PersistentFlags().StringVar(&recordFile, "record", "", "Capture all bytes sent to and received from devices to this file")
+	rootCmd.PersistentFlags().StringVar(&replayFile, "replay", "", "Replay a capture file in place of connecting to a device")
+	rootCmd.PersistentFlags().Float64Var(&replaySpeed, "replay-speed", 1, "Pace of --replay relative to the capture, 0 for no delay")
 }
 
+// initCapture sets up --record and --replay before the command runs
+func initCapture() {
+	if recordFile != "" {
+		w, err := capture.Create(recordFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		connection.SetRecorder(w)
+	}
+
+	if replayFile != "" {
+		records, err := capture.ReadFile(replayFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		boards.NewTransport = func() connection.Transport {
+			r := connection.NewReplay(records)
+			r.Speed = replaySpeed
+			return r
+		}
+	}
+}
+
+// initConfig loads the config file. Only a file given with --config has to
+// exist.
 func initConfig() {
-	return
+	configPath = cfgFile
+	if configPath == "" {
+		path, err := config.DefaultPath()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		configPath = path
+	}
+
+	f, err := config.Load(configPath)
+	if os.IsNotExist(err) && cfgFile == "" {
+		f, err = &config.File{}, nil
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+	configFile = f
 }
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	sendCmd.Flags().StringVar(&sendHex, "hex", "", "Send these raw bytes, e.g. \"03 02\", instead of building a message")
+	sendCmd.Flags().DurationVarP(&sendWait, "wait", "w", 2*time.Second, "Time to print replies for")
+	sendCmd.Flags().BoolVar(&sendStatus, "status", false, "Also print status messages received while waiting")
+	sendCmd.Flags().BoolVar(&sendList, "list", false, "List message types and their fields")
+
+	rootCmd.AddCommand(sendCmd)
+}
+
+var sendCmd = &cobra.Command{
+	Use:   "send [TYPE [FIELD=VALUE...]]",
+	Short: "Send a raw message to the device",
+	Long: `Send a raw message to the device
+
+Builds a message of TYPE, e.g. SetFloatRequest, with the given fields set
+and prints the messages received from the device for --wait afterwards.
+Type and Length are filled in unless given. Nested fields are named with
+dots and integers may be given in hex. Timestamp years are an offset from
+2000.
+
+  send SetFloatRequest id=1 persist=1 value=0.35
+  send SetTime timestamp.year=20 timestamp.month=6
+  send --hex "44 05 00 01 00"
+
+--list shows every message type and its fields. Bytes the decoder does not
+recognise are only shown with --debug.`,
+	Args: cobra.ArbitraryArgs,
+	Run:  send,
+}
+
+var (
+	sendHex    string
+	sendWait   time.Duration
+	sendStatus bool
+	sendList   bool
+)
+
+// listMessageTypes prints each message type with its fields
+func listMessageTypes() {
+	for _, t := range messages.Types {
+		fmt.Printf("0x%02x %s, %d bytes\n", t.Code, t.Name, t.Size())
+		listFields(t.Type, "  ")
+	}
+}
+
+func listFields(t reflect.Type, prefix string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type.Kind() == reflect.Struct {
+			nested := prefix
+			if !f.Anonymous {
+				nested += f.Name + "."
+			}
+			listFields(f.Type, nested)
+			continue
+		}
+		fmt.Printf("%s%s %s\n", prefix, f.Name, f.Type)
+	}
+}
+
+// sendFrame returns the bytes to send from the arguments and the message
+// they were built from, nil for --hex
+func sendFrame(args []string) ([]byte, interface{}, error) {
+	if sendHex != "" {
+		if len(args) > 0 {
+			return nil, nil, fmt.Errorf("--hex takes no arguments")
+		}
+		digits := strings.NewReplacer(" ", "", ",", "", "0x", "", "0X", "").Replace(sendHex)
+		frame, err := hex.DecodeString(digits)
+		return frame, nil, err
+	}
+
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("a message type or --hex is required")
+	}
+	t, ok := messages.TypeByName(args[0])
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown message type %s, see --list", args[0])
+	}
+	msg, err := t.Build(args[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf, err := messages.WriteMessage(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), msg, nil
+}
+
+func send(cmd *cobra.Command, args []string) {
+	if sendList {
+		listMessageTypes()
+		return
+	}
+
+	frame, msg, err := sendFrame(args)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	m := boards.Basic{}
+	m.SetMessageCallback(func(msg interface{}) error {
+		switch msg.(type) {
+		case messages.MotionSensorStatusMessage, messages.LightStatusMessage:
+			if !sendStatus {
+				return nil
+			}
+		}
+		fmt.Printf("RX %s %+v\n", reflect.TypeOf(msg).Name(), msg)
+		return nil
+	})
+
+	err = m.Init(deviceID, debug)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer m.Close()
+
+	fmt.Printf("TX %d bytes: % x\n", len(frame), frame)
+	if msg != nil {
+		printMessage(msg)
+	}
+	err = m.GetConnection().WriteBytes(bytes.NewBuffer(frame))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	time.Sleep(sendWait)
+}
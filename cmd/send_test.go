@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestSend(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	device.floats[paramKey{1, 1}] = 0.25
+
+	out := execute(t, device, "send", "GetFloatRequest", "id=1", "persist=1", "--wait", "100ms")
+	for _, want := range []string{
+		"TX 5 bytes: 40 05 00 01 01\n",
+		"RX GetFloatResponse {BasicMessage:{Type:65 Length:10} Success:1 Id:1 Persist:1 Value:0.25}\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printed %q, want %q", out, want)
+		}
+	}
+	if strings.Contains(out, "MotionSensorStatus") {
+		t.Errorf("printed status without --status: %q", out)
+	}
+
+	execute(t, device, "send", "--hex", "44 05 00 02 00", "--wait", "0")
+	sent := device.conn.Written()
+	if len(sent) != 2 || string(sent[1]) != "\x44\x05\x00\x02\x00" {
+		t.Errorf("sent % x", sent)
+	}
+}
@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	serveCmd.Flags().StringVarP(&serveListen, "listen", "l", "127.0.0.1:8080", "Address to serve the API on")
+	serveCmd.Flags().BoolVar(&utc, "utc", false, "Set the device clock to UTC rather than local time")
+	serveCmd.Flags().StringVar(&serveTextfile, "textfile", "", "Also write metrics to this file for the node exporter textfile collector")
+	serveCmd.Flags().DurationVar(&serveTextfileInterval, "textfile-interval", 15*time.Second, "Interval between textfile writes")
+	serveCmd.Flags().DurationVar(&serveLogTimeout, "log-timeout", 2*time.Second, "Time to wait for each log entry")
+	serveCmd.Flags().IntVar(&serveLogRetries, "log-retries", 3, "Number of times to re-request a log entry")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve an HTTP API for controlling the device",
+	Long: `Serve an HTTP API for controlling the device
+
+Connects to the device and serves a JSON API. Request and response bodies
+use the field names of the bluetooth messages.
+
+  GET  /status          latest status message
+  GET  /params          parameter names, types and descriptions
+  GET  /params/NAME     read a parameter, a GetFloatResponse or GetUint16Response
+  PUT  /params/NAME     write a parameter, body {"Value": 0.3}
+  POST /trigger         trigger the device, optional body {"Lux": 10}
+  GET  /logs            download log entries, optional ?start=N&end=N
+  POST /settime         set the clock, optional Calendar body, default now
+  GET  /events          stream messages as server-sent events
+  GET  /metrics         prometheus metrics
+
+/events pushes every message decoded from the device as it arrives, with
+the message in the same form as the other endpoints. Filter the stream with
+?device=NAME and ?type=TYPE, each taking a comma separated list. Types are
+motion_status, light_status, status (either board), log, get_float,
+set_float, get_uint16 and set_uint16.
+
+  curl -N 'localhost:8080/events?type=status,log'
+
+/metrics has gauges of the latest status, the connection RSSI and counters
+of messages decoded, decode errors, request timeouts and reconnects. With
+--textfile the same metrics are written to a file for the node exporter
+textfile collector. The device is reconnected if the connection drops.
+
+Requests to the device are handled one at a time. Failures return a non 2xx
+status and a body of {"error": "..."}.`,
+	Run: serve,
+}
+
+var (
+	serveListen           string
+	serveTextfile         string
+	serveTextfileInterval time.Duration
+	serveLogTimeout       time.Duration
+	serveLogRetries       int
+)
+
+const (
+	serveReconnectTimeout  = 30 * time.Second
+	serveReconnectInterval = 5 * time.Second
+)
+
+// paramInfo describes a named parameter for GET /params
+type paramInfo struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Persist     bool   `json:"persist"`
+	Description string `json:"description"`
+}
+
+func paramList() []paramInfo {
+	var params []paramInfo
+	add := func(members []boards.Param, kind string, persist bool) {
+		for _, member := range members {
+			params = append(params, paramInfo{member.Name, kind, persist, member.Description})
+		}
+	}
+	add(boards.FloatPersist, "float", true)
+	add(boards.FloatTemp, "float", false)
+	add(boards.Uint16Persist, "uint16", true)
+	add(boards.Uint16Temp, "uint16", false)
+	return params
+}
+
+// apiServer exposes a board over http
+type apiServer struct {
+	// mutex serialises requests to the device, the board can only wait for
+	// one response of each type at a time
+	mutex  sync.Mutex
+	board  *boards.Basic
+	events *eventHub
+
+	// logTimeout and logRetries are passed to DownloadLog for GET /logs
+	logTimeout time.Duration
+	logRetries int
+}
+
+// apiError is an error with the http status to report it with
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(format string, a ...interface{}) error {
+	return &apiError{http.StatusBadRequest, fmt.Errorf(format, a...)}
+}
+
+func notFound(format string, a ...interface{}) error {
+	return &apiError{http.StatusNotFound, fmt.Errorf(format, a...)}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// readJSON decodes the request body into v, an empty body leaves v unchanged
+func readJSON(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return badRequest("invalid body: %s", err)
+	}
+	return nil
+}
+
+// methodNotAllowed rejects a request, listing the methods allowed
+func methodNotAllowed(w http.ResponseWriter, allow ...string) {
+	w.Header().Set("Allow", strings.Join(allow, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+}
+
+// handle adapts a handler returning a response body or error
+func (s *apiServer) handle(method string, h func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			methodNotAllowed(w, method)
+			return
+		}
+
+		s.mutex.Lock()
+		v, err := s.deviceCall(r, h)
+		s.mutex.Unlock()
+
+		if err != nil {
+			status := http.StatusBadGateway
+			if e, ok := err.(*apiError); ok {
+				status = e.status
+			}
+			writeJSON(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+func (s *apiServer) deviceCall(r *http.Request, h func(r *http.Request) (interface{}, error)) (interface{}, error) {
+	if !s.board.IsConnected() {
+		return nil, &apiError{http.StatusServiceUnavailable, fmt.Errorf("not connected")}
+	}
+	return h(r)
+}
+
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handle(http.MethodGet, s.getStatus))
+	mux.HandleFunc("/params", s.handle(http.MethodGet, s.getParams))
+	mux.HandleFunc("/params/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			s.handle(http.MethodGet, s.getParam)(w, r)
+		case http.MethodPut:
+			s.handle(http.MethodPut, s.putParam)(w, r)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPut)
+		}
+	})
+	mux.HandleFunc("/trigger", s.handle(http.MethodPost, s.trigger))
+	mux.HandleFunc("/logs", s.handle(http.MethodGet, s.getLogs))
+	mux.HandleFunc("/settime", s.handle(http.MethodPost, s.setTime))
+	mux.HandleFunc("/events", s.events.serveEvents)
+	mux.HandleFunc("/metrics", s.serveMetrics)
+	return mux
+}
+
+func (s *apiServer) getStatus(r *http.Request) (interface{}, error) {
+	status := s.board.Status()
+	if status == nil {
+		return nil, &apiError{http.StatusServiceUnavailable, fmt.Errorf("no status received")}
+	}
+	return status, nil
+}
+
+func (s *apiServer) getParams(r *http.Request) (interface{}, error) {
+	return paramList(), nil
+}
+
+func (s *apiServer) getParam(r *http.Request) (interface{}, error) {
+	name := strings.TrimPrefix(r.URL.Path, "/params/")
+
+	if indx, persist, err := boards.FloatIndex(name); err == nil {
+		return s.board.GetFloat(indx, persist)
+	}
+	if indx, persist, err := boards.Uint16Index(name); err == nil {
+		return s.board.GetUint16(indx, persist)
+	}
+	return nil, notFound("unknown parameter %q", name)
+}
+
+func (s *apiServer) putParam(r *http.Request) (interface{}, error) {
+	name := strings.TrimPrefix(r.URL.Path, "/params/")
+
+	if indx, persist, err := boards.FloatIndex(name); err == nil {
+		var body struct{ Value *float32 }
+		err = readJSON(r, &body)
+		if err != nil {
+			return nil, err
+		}
+		if body.Value == nil {
+			return nil, badRequest("Value is required")
+		}
+		return s.board.SetFloat(indx, persist, *body.Value)
+	}
+
+	if indx, persist, err := boards.Uint16Index(name); err == nil {
+		var body struct{ Value *uint16 }
+		err = readJSON(r, &body)
+		if err != nil {
+			return nil, err
+		}
+		if body.Value == nil {
+			return nil, badRequest("Value is required")
+		}
+		return s.board.SetUint16(indx, persist, *body.Value)
+	}
+
+	return nil, notFound("unknown parameter %q", name)
+}
+
+func (s *apiServer) trigger(r *http.Request) (interface{}, error) {
+	var body struct{ Lux float32 }
+	err := readJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.board.Trigger(body.Lux)
+	if err != nil {
+		return nil, err
+	}
+	return messages.NewMotionSensorTriggerMessage(body.Lux), nil
+}
+
+// queryUint16 reads an optional uint16 query parameter
+func queryUint16(r *http.Request, name string, def uint16) (uint16, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 16)
+	if err != nil {
+		return 0, badRequest("%s: %s", name, err)
+	}
+	return uint16(n), nil
+}
+
+func (s *apiServer) getLogs(r *http.Request) (interface{}, error) {
+	if s.board.Status() == nil {
+		return nil, &apiError{http.StatusServiceUnavailable, fmt.Errorf("log count unknown, no status received")}
+	}
+
+	start, err := queryUint16(r, "start", 0)
+	if err != nil {
+		return nil, err
+	}
+	end, err := queryUint16(r, "end", s.board.LogEntries())
+	if err != nil {
+		return nil, err
+	}
+	if end > s.board.LogEntries() {
+		end = s.board.LogEntries()
+	}
+
+	dl := boards.LogDownload{Entries: []messages.LogResponseMessage{}}
+	if start >= end {
+		return dl, nil
+	}
+	return s.board.DownloadLog(start, end, s.logTimeout, s.logRetries, nil)
+}
+
+func (s *apiServer) setTime(r *http.Request) (interface{}, error) {
+	var cal *messages.Calendar
+	err := readJSON(r, &cal)
+	if err != nil {
+		return nil, err
+	}
+
+	if cal == nil {
+		return s.board.SyncTime(deviceLocation(), 0)
+	}
+	if !cal.IsSet() {
+		return nil, badRequest("invalid date %s", cal)
+	}
+
+	err = s.board.SetTime(*cal)
+	if err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// keepConnected reconnects to the device whenever the connection drops
+func (s *apiServer) keepConnected() {
+	s.board.SetConnectTimeout(serveReconnectTimeout)
+
+	for {
+		time.Sleep(time.Second)
+
+		// Requests wait for the reconnect rather than using the board while
+		// Init resets it
+		s.mutex.Lock()
+		if s.board.IsConnected() {
+			s.mutex.Unlock()
+			continue
+		}
+		log.Printf("Reconnecting to %s\n", deviceID)
+		err := s.board.Init(deviceID, debug)
+		s.mutex.Unlock()
+
+		if err != nil {
+			log.Println(err)
+			time.Sleep(serveReconnectInterval)
+		}
+	}
+}
+
+func serve(cmd *cobra.Command, args []string) {
+	m := boards.Basic{}
+	s := &apiServer{board: &m, events: newEventHub(),
+		logTimeout: serveLogTimeout, logRetries: serveLogRetries}
+	m.SetMessageCallback(s.events.messageHandler(deviceID))
+
+	err := m.Init(deviceID, debug)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = m.WaitForStatus(10 * time.Second)
+	if err != nil {
+		log.Println(err)
+	}
+
+	go s.keepConnected()
+	if serveTextfile != "" {
+		go s.writeTextfile(serveTextfile, serveTextfileInterval)
+	}
+
+	log.Printf("Serving %s on http://%s\n", deviceID, serveListen)
+	err = http.ListenAndServe(serveListen, s.routes())
+	if err != nil {
+		log.Println(err)
+	}
+}
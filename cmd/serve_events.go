@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+const (
+	// eventBuffer is the number of events queued for a slow client before
+	// further events are dropped
+	eventBuffer = 256
+
+	eventKeepAlive = 15 * time.Second
+)
+
+// event is a decoded message pushed to streaming clients
+type event struct {
+	Device  string      `json:"device"`
+	Type    string      `json:"type"`
+	Time    time.Time   `json:"time"`
+	Message interface{} `json:"message"`
+}
+
+// messageTypeName names a decoded message for event filtering
+func messageTypeName(msg interface{}) string {
+	switch msg.(type) {
+	case messages.MotionSensorStatusMessage:
+		return "motion_status"
+	case messages.LightStatusMessage:
+		return "light_status"
+	case messages.LogResponseMessage:
+		return "log"
+	case messages.GetFloatResponse:
+		return "get_float"
+	case messages.SetFloatResponse:
+		return "set_float"
+	case messages.GetUint16Response:
+		return "get_uint16"
+	case messages.SetUint16Response:
+		return "set_uint16"
+	}
+	return "unknown"
+}
+
+// eventFilter selects the events a client receives, empty sets match
+// everything
+type eventFilter struct {
+	devices map[string]bool
+	types   map[string]bool
+}
+
+func parseFilterList(values []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				set[item] = true
+			}
+		}
+	}
+	return set
+}
+
+func (f eventFilter) matches(e event) bool {
+	if len(f.devices) > 0 && !f.devices[e.Device] {
+		return false
+	}
+	if len(f.types) == 0 || f.types[e.Type] {
+		return true
+	}
+	// status matches the status message of either board
+	return f.types["status"] && strings.HasSuffix(e.Type, "_status")
+}
+
+type eventClient struct {
+	filter  eventFilter
+	events  chan event
+	dropped int
+}
+
+// eventHub fans decoded messages out to streaming clients
+type eventHub struct {
+	mutex   sync.Mutex
+	clients map[*eventClient]bool
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{clients: make(map[*eventClient]bool)}
+}
+
+func (h *eventHub) subscribe(filter eventFilter) *eventClient {
+	c := &eventClient{filter: filter, events: make(chan event, eventBuffer)}
+
+	h.mutex.Lock()
+	h.clients[c] = true
+	h.mutex.Unlock()
+
+	return c
+}
+
+func (h *eventHub) unsubscribe(c *eventClient) {
+	h.mutex.Lock()
+	delete(h.clients, c)
+	h.mutex.Unlock()
+}
+
+// publish queues the event for every matching client without blocking, a
+// client which falls behind loses events rather than stalling the device
+func (h *eventHub) publish(e event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for c := range h.clients {
+		if !c.filter.matches(e) {
+			continue
+		}
+		select {
+		case c.events <- e:
+		default:
+			c.dropped++
+		}
+	}
+}
+
+// messageHandler returns a board message callback which publishes messages
+// from device
+func (h *eventHub) messageHandler(device string) func(interface{}) error {
+	return func(msg interface{}) error {
+		h.publish(event{
+			Device:  device,
+			Type:    messageTypeName(msg),
+			Time:    time.Now(),
+			Message: msg,
+		})
+		return nil
+	}
+}
+
+// serveEvents streams events as server-sent events. The device and type
+// query parameters take comma separated lists.
+func (h *eventHub) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming unsupported"})
+		return
+	}
+
+	query := r.URL.Query()
+	c := h.subscribe(eventFilter{
+		devices: parseFilterList(query["device"]),
+		types:   parseFilterList(query["type"]),
+	})
+	defer h.unsubscribe(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	reported := 0
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprintf(w, ": keep-alive\n\n")
+		case e := <-c.events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		}
+
+		h.mutex.Lock()
+		dropped := c.dropped
+		h.mutex.Unlock()
+		if dropped > reported {
+			fmt.Fprintf(w, "event: dropped\ndata: {\"dropped\": %d}\n\n", dropped)
+			reported = dropped
+		}
+
+		flusher.Flush()
+	}
+}
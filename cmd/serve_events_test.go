@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+// readEvent returns the type and data of the next server-sent event
+func readEvent(t *testing.T, scanner *bufio.Scanner) (string, string) {
+	t.Helper()
+
+	var name, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "" && name != "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	t.Fatalf("event stream ended: %v", scanner.Err())
+	return "", ""
+}
+
+func TestServeEvents(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{Motion: 0.25})
+	_, srv := newTestServer(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events?type=status", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /events returned %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// The filter leaves out the parameter response
+	request(t, http.MethodGet, srv.URL+"/params/motion_threshold", "", nil)
+	device.conn.Receive(device.status()...)
+
+	name, data := readEvent(t, bufio.NewScanner(resp.Body))
+	if name != "motion_status" {
+		t.Fatalf("event %q, want motion_status", name)
+	}
+
+	var e struct {
+		Device  string
+		Type    string
+		Message messages.MotionSensorStatusMessage
+	}
+	err = json.Unmarshal([]byte(data), &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Device != "fake" || e.Type != "motion_status" || e.Message.Motion != 0.25 {
+		t.Errorf("event data %s", data)
+	}
+}
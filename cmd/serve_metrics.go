@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"github.com/phelpsw/camera-trigger-bt-cli/metrics"
+)
+
+const metricPrefix = "camera_trigger_"
+
+// boardMetrics adds the status gauges and connection counters of a board
+func boardMetrics(set *metrics.Set, device string, b *boards.Basic) {
+	l := metrics.Label{Name: "device", Value: device}
+	gauge := func(name, help string, value float32) {
+		set.Gauge(metricPrefix+name, help, float64(value), l)
+	}
+
+	connected := 0.0
+	if b.IsConnected() {
+		connected = 1
+		set.Gauge(metricPrefix+"rssi_dbm", "Signal strength of the connection", float64(b.RSSI()), l)
+	}
+	set.Gauge(metricPrefix+"connected", "Whether the device is connected", connected, l)
+
+	switch s := b.Status().(type) {
+	case messages.MotionSensorStatusMessage:
+		gauge("motion", "Motion sensor value", s.Motion)
+		gauge("motion_threshold", "Motion trigger threshold", s.MotionThreshold)
+		gauge("lux", "Ambient light level", s.Lux)
+		gauge("temperature_celsius", "CPU temperature", s.Temperature)
+		gauge("voltage_volts", "Battery voltage", s.Voltage)
+		gauge("log_entries", "Entries in the device log", float32(s.LogEntries))
+	case messages.LightStatusMessage:
+		gauge("light_level", "Configured light level", s.Payload.Level)
+		gauge("light_current_amps", "Light current", s.Payload.Current)
+		gauge("light_temperature_celsius", "Light temperature", s.Payload.LightTemperature)
+		gauge("temperature_celsius", "CPU temperature", s.Payload.Temperature)
+		gauge("voltage_volts", "Battery voltage", s.Payload.Voltage)
+		gauge("log_entries", "Entries in the device log", float32(s.Payload.LogEntries))
+	}
+
+	c := b.Counters()
+	set.Counter(metricPrefix+"frames_received_total", "Messages decoded from the device", float64(c.Frames), l)
+	set.Counter(metricPrefix+"parse_errors_total", "Errors decoding messages from the device", float64(c.ParseErrors), l)
+	set.Counter(metricPrefix+"reconnects_total", "Connections to the device after the first", float64(c.Reconnects()), l)
+
+	timeouts := []struct {
+		request string
+		count   uint64
+	}{
+		{"get_float", c.GetFloatTimeouts},
+		{"set_float", c.SetFloatTimeouts},
+		{"get_uint16", c.GetUint16Timeouts},
+		{"set_uint16", c.SetUint16Timeouts},
+	}
+	for _, t := range timeouts {
+		set.Counter(metricPrefix+"request_timeouts_total", "Requests which received no response",
+			float64(t.count), l, metrics.Label{Name: "request", Value: t.request})
+	}
+}
+
+// collect gathers the metrics of the served device
+func (s *apiServer) collect() []metrics.Family {
+	set := metrics.NewSet()
+	boardMetrics(set, deviceID, s.board)
+	// Discarded bytes are only counted across every connection
+	set.Counter(metricPrefix+"discarded_bytes_total", "Received bytes skipped while searching for a message",
+		float64(messages.DiscardedBytes()))
+	return set.Families()
+}
+
+func (s *apiServer) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	err := metrics.Write(w, s.collect())
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// writeTextfile periodically writes the metrics for the node exporter
+// textfile collector
+func (s *apiServer) writeTextfile(path string, interval time.Duration) {
+	for {
+		err := metrics.WriteFile(path, s.collect())
+		if err != nil {
+			log.Println(err)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+// newTestServer serves the API of a board connected to device
+func newTestServer(t *testing.T, device *fakeDevice) (*apiServer, *httptest.Server) {
+	m := &boards.Basic{}
+	s := &apiServer{board: m, events: newEventHub(), logTimeout: 2 * time.Second, logRetries: 3}
+	m.SetMessageCallback(s.events.messageHandler("fake"))
+	m.SetTransport(device.conn)
+
+	err := m.Init("fake", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+
+	err = m.WaitForStatus(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+// request sends a request to the server, decoding a json response into v
+// when it is non-nil, and returns the status code
+func request(t *testing.T, method, url, body string, v interface{}) int {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil {
+		err = json.NewDecoder(resp.Body).Decode(v)
+		if err != nil {
+			t.Fatalf("%s %s: %s", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServeStatus(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{Motion: 0.25, MotionThreshold: 0.5})
+	_, srv := newTestServer(t, device)
+
+	var status messages.MotionSensorStatusMessage
+	if code := request(t, http.MethodGet, srv.URL+"/status", "", &status); code != http.StatusOK {
+		t.Fatalf("GET /status returned %d", code)
+	}
+	if status.Motion != 0.25 || status.MotionThreshold != 0.5 {
+		t.Errorf("GET /status = %+v", status)
+	}
+}
+
+func TestServeParamRoundTrip(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	_, srv := newTestServer(t, device)
+
+	var set messages.SetFloatResponse
+	code := request(t, http.MethodPut, srv.URL+"/params/motion_threshold", `{"Value": 0.35}`, &set)
+	if code != http.StatusOK || set.Success != 1 || set.Value != 0.35 {
+		t.Errorf("PUT /params/motion_threshold returned %d %+v", code, set)
+	}
+
+	var got messages.GetFloatResponse
+	code = request(t, http.MethodGet, srv.URL+"/params/motion_threshold", "", &got)
+	if code != http.StatusOK || got.Success != 1 || got.Value != 0.35 {
+		t.Errorf("GET /params/motion_threshold returned %d %+v", code, got)
+	}
+
+	var setInt messages.SetUint16Response
+	code = request(t, http.MethodPut, srv.URL+"/params/led_on_record", `{"Value": 2}`, &setInt)
+	if code != http.StatusOK || setInt.Value != 2 {
+		t.Errorf("PUT /params/led_on_record returned %d %+v", code, setInt)
+	}
+
+	var gotInt messages.GetUint16Response
+	code = request(t, http.MethodGet, srv.URL+"/params/led_on_record", "", &gotInt)
+	if code != http.StatusOK || gotInt.Success != 1 || gotInt.Value != 2 {
+		t.Errorf("GET /params/led_on_record returned %d %+v", code, gotInt)
+	}
+
+	indx, persist, _ := boards.FloatIndex("motion_threshold")
+	device.mutex.Lock()
+	if v := device.floats[paramKey{indx, persist}]; v != 0.35 {
+		t.Errorf("device motion_threshold %v, want 0.35", v)
+	}
+	device.mutex.Unlock()
+}
+
+func TestServeBadRequests(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	_, srv := newTestServer(t, device)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{http.MethodPut, "/params/motion_threshold", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/params/motion_threshold", `{"Value": "high"}`, http.StatusBadRequest},
+		{http.MethodPut, "/params/led_on_record", `{"Value": -1}`, http.StatusBadRequest},
+		{http.MethodPut, "/params/motion_threshold", `0.35`, http.StatusBadRequest},
+		{http.MethodGet, "/params/brightness", ``, http.StatusNotFound},
+		{http.MethodPut, "/params/brightness", `{"Value": 1}`, http.StatusNotFound},
+		{http.MethodGet, "/logs?start=first", ``, http.StatusBadRequest},
+		{http.MethodGet, "/logs?end=70000", ``, http.StatusBadRequest},
+		{http.MethodPost, "/trigger", `{"Lux": "bright"}`, http.StatusBadRequest},
+		{http.MethodPost, "/settime", `{}`, http.StatusBadRequest},
+		{http.MethodPost, "/status", ``, http.StatusMethodNotAllowed},
+		{http.MethodGet, "/trigger", ``, http.StatusMethodNotAllowed},
+		{http.MethodPost, "/params/motion_threshold", `{"Value": 0.3}`, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		var body map[string]string
+		code := request(t, tt.method, srv.URL+tt.path, tt.body, &body)
+		if code != tt.want {
+			t.Errorf("%s %s %s returned %d, want %d", tt.method, tt.path, tt.body, code, tt.want)
+		}
+		if body["error"] == "" {
+			t.Errorf("%s %s %s returned no error message", tt.method, tt.path, tt.body)
+		}
+	}
+
+	for path, want := range map[string]string{
+		"/params/motion_threshold": "GET, PUT",
+		"/status":                  "GET",
+	} {
+		resp, err := http.Post(srv.URL+path, "application/json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if allow := resp.Header.Get("Allow"); allow != want {
+			t.Errorf("POST %s allows %q, want %q", path, allow, want)
+		}
+	}
+
+	// Nothing was set on the device
+	for _, msg := range device.sent(t) {
+		switch msg.(type) {
+		case messages.GetFloatRequest, messages.GetUint16Request:
+		default:
+			t.Errorf("device was sent %T", msg)
+		}
+	}
+}
@@ -35,7 +35,7 @@ func getUint16(cmd *cobra.Command, args []string) {
 
 	err := m.Init(deviceID, debug)
 	if err != nil {
-		log.Panicln(err)
+		log.Println(err)
 		return
 	}
 
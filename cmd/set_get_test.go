@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestGetUint16(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	device.uint16s[paramKey{1, 0}] = 4
+
+	out := execute(t, device, "gi", "1", "false")
+	want := "{BasicMessage:{Type:69 Length:8} Success:1 Id:1 Persist:0 Value:4}\n"
+	if out != want {
+		t.Errorf("printed %q, want %q", out, want)
+	}
+
+	info, _ := messages.TypeByName("GetUint16Request")
+	sent := device.sent(t)
+	if len(sent) != 1 || sent[0] != (messages.GetUint16Request{BasicMessage: header(info.Name), Id: 1}) {
+		t.Errorf("sent %+v", sent)
+	}
+}
@@ -6,60 +6,186 @@ import (
 	"time"
 
 	"github.com/phelpsw/camera-trigger-bt-cli/boards"
-	"github.com/phelpsw/camera-trigger-bt-cli/messages"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	setTimeCmd.Flags().BoolVarP(&utc, "utc", "u", false, "Set flag UTC rather than local time")
+	setTimeCmd.Flags().BoolVar(&timeDaemon, "daemon", false, "Keep running and check device clocks periodically")
+	setTimeCmd.Flags().StringSliceVar(&timeDevices, "devices", nil, "Devices to keep in sync in daemon mode (default --device)")
+	setTimeCmd.Flags().DurationVar(&timeInterval, "interval", time.Hour, "Period between clock checks in daemon mode")
+	setTimeCmd.Flags().DurationVar(&clockMaxDrift, "max-drift", 2*time.Second, "Drift allowed before a clock is set in daemon mode")
+	setTimeCmd.Flags().IntVarP(&clockSamples, "samples", "n", 3, "Number of status messages used to measure drift")
+	setTimeCmd.Flags().DurationVar(&timeConnectTimeout, "connect-timeout", 30*time.Second, "Time to search for each device in daemon mode")
 	rootCmd.AddCommand(setTimeCmd)
 }
 
 var setTimeCmd = &cobra.Command{
 	Use:   "settime",
 	Short: "Set the time",
-	Long:  "Set the time",
-	Run:   setTime,
+	Long: `Set the time
+
+The clock is set so the device starts each second on time, compensating
+for the measured bluetooth latency.
+
+With --daemon the command keeps running, connecting to each of --devices
+every --interval. Each clock is measured from status messages and only set
+when it has drifted by more than --max-drift.`,
+	Run: setTime,
 }
 
 var (
-	timeDone      = make(chan struct{})
-	utc      bool = false
+	utc bool = false
+
+	timeDaemon         bool
+	timeDevices        []string
+	timeInterval       time.Duration
+	timeConnectTimeout time.Duration
 )
 
+// deviceLocation returns the zone the device clock is kept in
+func deviceLocation() *time.Location {
+	if utc {
+		return time.UTC
+	}
+	return time.Local
+}
+
+// clockUnset reports whether the device has sent a status with a clock which
+// has never been set
+func clockUnset(m *boards.Basic) bool {
+	return m.StatusCount() > 0 && !m.Timestamp().IsSet()
+}
+
+// adjustClock measures the drift of the device clock and sets it when the
+// drift exceeds clockMaxDrift or the clock has never been set, reporting
+// whether it was set. Progress is reported through printf.
+func adjustClock(m *boards.Basic, printf func(format string, v ...interface{})) (bool, error) {
+	latency, err := m.MeasureLatency(3)
+	if err != nil {
+		return false, err
+	}
+
+	drift, err := measureDrift(m, clockSamples, latency)
+	switch {
+	case err == nil:
+		if drift <= clockMaxDrift && drift >= -clockMaxDrift {
+			printf("Clock drift %v within %v", drift.Round(time.Millisecond), clockMaxDrift)
+			return false, nil
+		}
+		printf("Clock drift %v exceeds %v", drift.Round(time.Millisecond), clockMaxDrift)
+	case clockUnset(m):
+		printf("Clock not set")
+	default:
+		return false, err
+	}
+
+	cal, err := m.SyncTime(deviceLocation(), latency)
+	if err != nil {
+		return false, err
+	}
+	printf("Set clock to %s", cal)
+
+	// Skip a status message which may have been sent before the clock was set
+	err = m.WaitForNextStatus(10 * time.Second)
+	if err != nil {
+		return false, err
+	}
+
+	drift, err = measureDrift(m, 1, latency)
+	if err != nil {
+		return false, err
+	}
+	if drift > clockMaxDrift || drift < -clockMaxDrift {
+		return false, fmt.Errorf("clock drift %v after setting", drift.Round(time.Millisecond))
+	}
+	printf("Clock drift %v after setting", drift.Round(time.Millisecond))
+	return true, nil
+}
+
+// syncDeviceClock connects to the named device and sets its clock if it has
+// drifted by more than clockMaxDrift
+func syncDeviceClock(m *boards.Basic, name string) error {
+	m.SetConnectTimeout(timeConnectTimeout)
+
+	err := m.Init(name, debug)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	_, err = adjustClock(m, func(format string, v ...interface{}) {
+		log.Printf("%s: %s", name, fmt.Sprintf(format, v...))
+	})
+	return err
+}
+
+func timeDaemonLoop() {
+	devices := timeDevices
+	if len(devices) == 0 && deviceID != "" {
+		devices = []string{deviceID}
+	}
+	if len(devices) == 0 {
+		log.Println("--devices or --device required")
+		return
+	}
+
+	// A single board is reused so the bluetooth interface is only opened once
+	m := boards.Basic{}
+
+	for {
+		for _, name := range devices {
+			err := syncDeviceClock(&m, name)
+			if err != nil {
+				log.Printf("%s: %s", name, err)
+			}
+		}
+
+		log.Printf("Next check in %v", timeInterval)
+		time.Sleep(timeInterval)
+	}
+}
+
 func setTime(cmd *cobra.Command, args []string) {
+	if timeDaemon {
+		timeDaemonLoop()
+		return
+	}
+
 	m := boards.Basic{}
 
 	err := m.Init(deviceID, debug)
 	if err != nil {
-		log.Panicln(err)
+		log.Println(err)
 		return
 	}
+	defer m.Close()
 
 	for !m.IsConnected() {
 	}
 
-	var ts time.Time
-	if utc {
-		ts = time.Now().UTC()
-	} else {
-		ts = time.Now()
-	}
+	latency := measureLatency(&m)
 
-	cal := messages.Calendar{
-		Seconds:    uint8(ts.Second()),
-		Minutes:    uint8(ts.Minute()),
-		Hours:      uint8(ts.Hour()),
-		DayOfWeek:  uint8(ts.Weekday()),
-		DayOfMonth: uint8(ts.Day()),
-		Month:      uint8(ts.Month()),
-		Year:       uint16(ts.Year()),
+	cal, err := m.SyncTime(deviceLocation(), latency)
+	if err != nil {
+		log.Println(err)
+		return
 	}
-	fmt.Printf("%+v\n", cal)
+	fmt.Printf("%s\n", cal)
 
-	m.SetTime(cal)
+	// Skip a status message which may have been sent before the clock was set
+	err = m.WaitForNextStatus(10 * time.Second)
+	if err != nil {
+		log.Println(err)
+		return
+	}
 
-	time.Sleep(5 * time.Second)
+	drift, err := measureDrift(&m, 1, latency)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	fmt.Printf("Drift after setting %v\n", drift.Round(time.Millisecond))
 
 	log.Println("Done")
 }
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/connection"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestSetTime(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	device.setClock(-time.Hour)
+	device.statusEvery(t, 20*time.Millisecond)
+
+	out := execute(t, device, "settime")
+
+	if !strings.Contains(out, "Drift after setting") {
+		t.Errorf("settime printed %s", out)
+	}
+	device.mutex.Lock()
+	if clock := *device.clock; clock < -2*time.Second || clock > 2*time.Second {
+		t.Errorf("clock offset %v after settime", clock)
+	}
+	device.mutex.Unlock()
+}
+
+func TestSyncDeviceClockUnset(t *testing.T) {
+	// The clock of a new device has never been set, so no drift can be
+	// measured
+	device := newMotionDevice(messages.MotionSensorStatusMessage{})
+	device.statusEvery(t, 20*time.Millisecond)
+
+	transport := boards.NewTransport
+	boards.NewTransport = func() connection.Transport {
+		return device.conn
+	}
+	samples := clockSamples
+	clockSamples = 1
+	defer func() {
+		boards.NewTransport = transport
+		clockSamples = samples
+	}()
+
+	var err error
+	captureStdout(t, func() {
+		err = syncDeviceClock(&boards.Basic{}, "fake")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	device.mutex.Lock()
+	if device.clock == nil {
+		t.Errorf("clock not set")
+	} else if clock := *device.clock; clock < -2*time.Second || clock > 2*time.Second {
+		t.Errorf("clock offset %v after sync", clock)
+	}
+	device.mutex.Unlock()
+}
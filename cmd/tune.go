@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/analysis"
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/recording"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	tuneCmd.Flags().StringSliceVarP(&tuneInputs, "input", "i", nil, "Recorded status files to analyse instead of live samples")
+	tuneCmd.Flags().StringVar(&tuneFrom, "from", "", "Start of the quiet window in recorded files (RFC3339)")
+	tuneCmd.Flags().StringVar(&tuneTo, "to", "", "End of the quiet window in recorded files (RFC3339)")
+	tuneCmd.Flags().DurationVar(&tuneDuration, "duration", 5*time.Minute, "Length of the live quiet window")
+	tuneCmd.Flags().Float64Var(&tuneRate, "rate", 1, "Target false triggers per hour")
+	tuneCmd.Flags().BoolVar(&tuneApply, "apply", false, "Set the recommended motion threshold on the device")
+
+	rootCmd.AddCommand(tuneCmd)
+}
+
+var tuneCmd = &cobra.Command{
+	Use:   "tune",
+	Short: "Recommend a motion threshold from sensor noise",
+	Long: `Recommend a motion threshold from sensor noise
+
+Motion values are sampled over a window with no real activity, either live
+from the device for --duration or from files written by the record command.
+The noise distribution is used to pick the lowest threshold which noise
+alone would exceed no more than --rate times an hour.`,
+	Run: tune,
+}
+
+var (
+	tuneInputs   []string
+	tuneFrom     string
+	tuneTo       string
+	tuneDuration time.Duration
+	tuneRate     float64
+	tuneApply    bool
+)
+
+// connectMotion connects to the device, confirms it is a motion sensor and
+// waits for the motion board to receive its first status
+func connectMotion(b *boards.Basic, m *boards.Motion) error {
+	err := b.Init(deviceID, debug)
+	if err != nil {
+		return err
+	}
+
+	err = awaitMotion(b, m)
+	if err != nil {
+		b.Close()
+	}
+	return err
+}
+
+// awaitMotion waits for a connected board to report itself as a motion
+// sensor and initializes m from it
+func awaitMotion(b *boards.Basic, m *boards.Motion) error {
+	err := b.WaitForStatus(10 * time.Second)
+	if err != nil {
+		return err
+	}
+
+	if b.GetType() != reflect.TypeOf(boards.Motion{}) {
+		return fmt.Errorf("%s is not a motion sensor", deviceID)
+	}
+
+	err = m.InitFromBasic(b)
+	if err != nil {
+		return err
+	}
+
+	// Sync fills unchanged settings from the last status so one is needed
+	// before anything is set
+	return awaitUpdate(m, 10*time.Second)
+}
+
+// awaitUpdate waits for the board to handle its next status message
+func awaitUpdate(b boards.Board, timeout time.Duration) error {
+	received := make(chan struct{}, 1)
+	b.SetUpdateCallback(func(interface{}) error {
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	defer b.SetUpdateCallback(nil)
+
+	select {
+	case <-received:
+	case <-time.After(timeout):
+		return fmt.Errorf("no status received")
+	}
+
+	return nil
+}
+
+// readRecordings reads the samples from every file matching the patterns
+func readRecordings(patterns []string) ([]recording.Sample, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files match %v", patterns)
+	}
+
+	return recording.ReadFiles(paths)
+}
+
+func recordedMotion() ([]float64, float64, error) {
+	samples, err := readRecordings(tuneInputs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var from, to time.Time
+	if tuneFrom != "" {
+		from, err = time.Parse(time.RFC3339, tuneFrom)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if tuneTo != "" {
+		to, err = time.Parse(time.RFC3339, tuneTo)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var values []float64
+	var first, last time.Time
+	for _, s := range samples {
+		if s.Board != recording.BoardMotion {
+			continue
+		}
+		if deviceID != "" && s.Device != deviceID {
+			continue
+		}
+		if (!from.IsZero() && s.HostTime.Before(from)) || (!to.IsZero() && s.HostTime.After(to)) {
+			continue
+		}
+
+		if len(values) == 0 {
+			first = s.HostTime
+		}
+		last = s.HostTime
+		values = append(values, float64(s.Motion))
+	}
+
+	return values, sampleRate(len(values), last.Sub(first)), nil
+}
+
+func liveMotion(m *boards.Motion) ([]float64, float64) {
+	// Appended by the board's receive goroutine
+	var mutex sync.Mutex
+	var values []float64
+	count := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(values)
+	}
+
+	start := time.Now()
+	m.SetUpdateCallback(func(b interface{}) error {
+		mutex.Lock()
+		values = append(values, float64(b.(*boards.Motion).Motion()))
+		mutex.Unlock()
+		return nil
+	})
+
+	fmt.Printf("Sampling motion for %v, keep the sensor's field of view clear\n", tuneDuration)
+	for elapsed := time.Since(start); elapsed < tuneDuration; elapsed = time.Since(start) {
+		wait := 10 * time.Second
+		if remaining := tuneDuration - elapsed; remaining < wait {
+			wait = remaining
+		}
+		time.Sleep(wait)
+		fmt.Printf("  %v elapsed, %d samples\n", time.Since(start).Round(time.Second), count())
+	}
+
+	m.SetUpdateCallback(nil)
+	window := time.Since(start)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	return values, sampleRate(len(values), window)
+}
+
+func sampleRate(count int, window time.Duration) float64 {
+	if count < 2 || window <= 0 {
+		return 0
+	}
+	return float64(count-1) / window.Seconds()
+}
+
+func tune(cmd *cobra.Command, args []string) {
+	var values []float64
+	var rate float64
+	var err error
+
+	b := boards.Basic{}
+	m := boards.Motion{}
+	connected := false
+
+	if len(tuneInputs) > 0 {
+		values, rate, err = recordedMotion()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+	} else {
+		err = connectMotion(&b, &m)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		defer b.Close()
+		connected = true
+
+		values, rate = liveMotion(&m)
+	}
+
+	r, err := analysis.RecommendThreshold(values, rate, tuneRate)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	fmt.Printf("Noise over %d samples at %.2f samples/sec\n", r.Noise.Count, r.SampleRate)
+	fmt.Printf("  mean %.4f stddev %.4f min %.4f max %.4f\n",
+		r.Noise.Mean, r.Noise.StdDev, r.Noise.Min, r.Noise.Max)
+	for _, q := range []float64{0.5, 0.9, 0.99, 0.999} {
+		fmt.Printf("  p%g %.4f\n", q*100, analysis.Quantile(values, q))
+	}
+
+	threshold := r.Threshold
+	if threshold > 1 {
+		fmt.Printf("Recommended threshold %.4f exceeds the maximum of 1.0\n", threshold)
+		threshold = 1
+	}
+
+	fmt.Printf("Recommended motion threshold %.4f for %g false triggers/hour", threshold, tuneRate)
+	if r.Extrapolated {
+		fmt.Printf(" (extrapolated, record a longer quiet window for a measured value)")
+	}
+	fmt.Printf("\n")
+
+	if connected {
+		current := float64(m.MotionThreshold())
+		fmt.Printf("Current threshold %.4f gives %.1f false triggers/hour\n",
+			current, analysis.TriggersPerHour(values, rate, current))
+	}
+
+	if !tuneApply {
+		log.Println("Done")
+		return
+	}
+
+	if !connected {
+		err = connectMotion(&b, &m)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		defer b.Close()
+	}
+
+	err = m.SetMotionThreshold(float32(threshold), true)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	deadline := time.Now().Add(30 * time.Second)
+	for !m.IsSynced() {
+		if time.Now().After(deadline) {
+			log.Println("motion threshold not confirmed by device")
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	fmt.Printf("Motion threshold set to %.4f\n", m.MotionThreshold())
+
+	log.Println("Done")
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func TestTuneLive(t *testing.T) {
+	device := newMotionDevice(messages.MotionSensorStatusMessage{Motion: 0.05, MotionThreshold: 0.5})
+	device.statusEvery(t, 5*time.Millisecond)
+
+	out := execute(t, device, "tune", "--duration", "200ms")
+
+	if !strings.Contains(out, "Sampling motion for 200ms") {
+		t.Errorf("tune printed %s", out)
+	}
+	if !strings.Contains(out, "Recommended motion threshold") {
+		t.Errorf("tune made no recommendation:\n%s", out)
+	}
+	if !strings.Contains(out, "Current threshold 0.5000") {
+		t.Errorf("tune did not report the current threshold:\n%s", out)
+	}
+
+	// The board is closed so the device can be connected again
+	out = execute(t, device, "tune", "--duration", "200ms")
+	if !strings.Contains(out, "Recommended motion threshold") {
+		t.Errorf("second tune made no recommendation:\n%s", out)
+	}
+}
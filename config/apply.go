@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+// Setting is a profile setting compared with the device. Motion and light
+// settings are named motion.NAME and light.NAME, params by their name.
+type Setting struct {
+	Name    string
+	Current float64
+	Desired float64
+}
+
+// Differs reports whether the device does not have the desired value
+func (s Setting) Differs() bool {
+	return math.Abs(s.Current-s.Desired) > 0.000001
+}
+
+func (s Setting) String() string {
+	return fmt.Sprintf("%s %g -> %g", s.Name, s.Current, s.Desired)
+}
+
+// configSettings compares the motion or light settings of p with status
+func configSettings(status interface{}, p Profile) []Setting {
+	var settings []Setting
+	add := func(name string, current float32, desired *float32) {
+		if desired != nil {
+			settings = append(settings, Setting{name, roundFloat32(current), roundFloat32(*desired)})
+		}
+	}
+
+	switch s := status.(type) {
+	case messages.MotionSensorStatusMessage:
+		if p.Motion != nil {
+			add("motion.motion_threshold", s.MotionThreshold, p.Motion.MotionThreshold)
+			add("motion.lux_low", s.LuxLowThreshold, p.Motion.LuxLow)
+			add("motion.lux_high", s.LuxHighThreshold, p.Motion.LuxHigh)
+			add("motion.cooldown", s.Cooldown, p.Motion.Cooldown)
+		}
+	case messages.LightStatusMessage:
+		if p.Light != nil {
+			add("light.level", s.Payload.Level, p.Light.Level)
+			add("light.delay", s.Payload.Delay, p.Light.Delay)
+			add("light.attack", s.Payload.Attack, p.Light.Attack)
+			add("light.sustain", s.Payload.Sustain, p.Light.Sustain)
+			add("light.release", s.Payload.Release, p.Light.Release)
+		}
+	}
+	return settings
+}
+
+// roundFloat32 converts v to the float64 of its shortest decimal form, so a
+// setting of 0.35 compares and prints as 0.35 rather than 0.3499999940
+func roundFloat32(v float32) float64 {
+	f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+	return f
+}
+
+func differing(settings []Setting) []Setting {
+	var diff []Setting
+	for _, s := range settings {
+		if s.Differs() {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
+
+// Compare reads the settings of the device which p covers. The board must
+// have received a status message, which gives its type and configuration.
+func Compare(b *boards.Basic, p Profile) ([]Setting, error) {
+	status := b.Status()
+	if status == nil {
+		return nil, fmt.Errorf("no status received")
+	}
+
+	settings := configSettings(status, p)
+	for _, name := range p.paramNames() {
+		current, err := ReadParam(b, name)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, Setting{name, current, p.Params[name]})
+	}
+	return settings, nil
+}
+
+// Apply writes the settings of p which differ on the device and waits up to
+// timeout for its status to report the new configuration. It returns the
+// settings changed.
+func Apply(b *boards.Basic, p Profile, timeout time.Duration) ([]Setting, error) {
+	settings, err := Compare(b, p)
+	if err != nil {
+		return nil, err
+	}
+	changed := differing(settings)
+
+	configChanged := false
+	for _, s := range changed {
+		if _, ok := p.Params[s.Name]; !ok {
+			configChanged = true
+			continue
+		}
+		err = WriteParam(b, s.Name, s.Desired)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !configChanged {
+		return changed, nil
+	}
+
+	switch status := b.Status().(type) {
+	case messages.MotionSensorStatusMessage:
+		err = b.Send(p.MotionConfig(status))
+	case messages.LightStatusMessage:
+		err = b.Send(p.LightConfig(status.Payload))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		pending := differing(configSettings(b.Status(), p))
+		if len(pending) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			var names []string
+			for _, s := range pending {
+				names = append(names, s.Name)
+			}
+			return nil, fmt.Errorf("%s not confirmed by device", strings.Join(names, ", "))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return changed, nil
+}
+
+// Capture returns a profile of the configuration of the device and the
+// named params. The board must have received a status message.
+func Capture(b *boards.Basic, params []string) (Profile, error) {
+	var p Profile
+	switch s := b.Status().(type) {
+	case messages.MotionSensorStatusMessage:
+		p.Motion = &Motion{
+			MotionThreshold: &s.MotionThreshold,
+			LuxLow:          &s.LuxLowThreshold,
+			LuxHigh:         &s.LuxHighThreshold,
+			Cooldown:        &s.Cooldown,
+		}
+	case messages.LightStatusMessage:
+		p.Light = &Light{
+			Level:   &s.Payload.Level,
+			Delay:   &s.Payload.Delay,
+			Attack:  &s.Payload.Attack,
+			Sustain: &s.Payload.Sustain,
+			Release: &s.Payload.Release,
+		}
+	default:
+		return p, fmt.Errorf("no status received")
+	}
+
+	for _, name := range params {
+		value, err := ReadParam(b, name)
+		if err != nil {
+			return p, err
+		}
+		if p.Params == nil {
+			p.Params = make(map[string]float64)
+		}
+		p.Params[name] = value
+	}
+	return p, nil
+}
+
+// ReadParam reads a named parameter from the device
+func ReadParam(b *boards.Basic, name string) (float64, error) {
+	if indx, persist, err := boards.FloatIndex(name); err == nil {
+		resp, err := b.GetFloat(indx, persist)
+		if err != nil {
+			return 0, err
+		}
+		if resp.Success != 1 {
+			return 0, fmt.Errorf("%s: get failed", name)
+		}
+		return roundFloat32(resp.Value), nil
+	}
+
+	if indx, persist, err := boards.Uint16Index(name); err == nil {
+		resp, err := b.GetUint16(indx, persist)
+		if err != nil {
+			return 0, err
+		}
+		if resp.Success != 1 {
+			return 0, fmt.Errorf("%s: get failed", name)
+		}
+		return float64(resp.Value), nil
+	}
+
+	return 0, fmt.Errorf("unknown parameter %s", name)
+}
+
+// WriteParam sets a named parameter on the device
+func WriteParam(b *boards.Basic, name string, value float64) error {
+	if indx, persist, err := boards.FloatIndex(name); err == nil {
+		resp, err := b.SetFloat(indx, persist, float32(value))
+		if err != nil {
+			return err
+		}
+		if resp.Success != 1 {
+			return fmt.Errorf("%s: set failed", name)
+		}
+		return nil
+	}
+
+	if indx, persist, err := boards.Uint16Index(name); err == nil {
+		resp, err := b.SetUint16(indx, persist, uint16(value))
+		if err != nil {
+			return err
+		}
+		if resp.Success != 1 {
+			return fmt.Errorf("%s: set failed", name)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown parameter %s", name)
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// File is the configuration file, a set of named profiles
+//
+//	profiles:
+//	  night-trail:
+//	    motion:
+//	      motion_threshold: 0.35
+//	      lux_low: 5
+//	    light:
+//	      level: 0.8
+//	      sustain: 20
+//	    params:
+//	      led_on_record: 0
+type File struct {
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+// Profile is a named set of device settings. Settings left out are not
+// changed, and the motion settings only apply to motion sensors and the
+// light settings to lights, so one profile can describe a pair.
+type Profile struct {
+	Motion *Motion `yaml:"motion,omitempty"`
+	Light  *Light  `yaml:"light,omitempty"`
+	// Params are named parameters from the board parameter tables
+	Params map[string]float64 `yaml:"params,omitempty"`
+}
+
+// Motion holds the settings of a MotionSensorConfigMessage
+type Motion struct {
+	MotionThreshold *float32 `yaml:"motion_threshold,omitempty"`
+	LuxLow          *float32 `yaml:"lux_low,omitempty"`
+	LuxHigh         *float32 `yaml:"lux_high,omitempty"`
+	Cooldown        *float32 `yaml:"cooldown,omitempty"`
+}
+
+// Light holds the settings of a LightConfigMessage
+type Light struct {
+	Level   *float32 `yaml:"level,omitempty"`
+	Delay   *float32 `yaml:"delay,omitempty"`
+	Attack  *float32 `yaml:"attack,omitempty"`
+	Sustain *float32 `yaml:"sustain,omitempty"`
+	Release *float32 `yaml:"release,omitempty"`
+}
+
+// DefaultPath returns the default configuration file,
+// ~/.camera-trigger/config.yaml
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".camera-trigger", "config.yaml"), nil
+}
+
+// Load reads and validates the configuration file at path. The error of a
+// missing file satisfies os.IsNotExist.
+func Load(path string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	err = yaml.UnmarshalStrict(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	for _, name := range f.Names() {
+		err = f.Profiles[name].Validate()
+		if err != nil {
+			return nil, fmt.Errorf("%s: profile %s: %s", path, name, err)
+		}
+	}
+
+	return &f, nil
+}
+
+// Save writes the configuration file to path, creating its directory
+func (f *File) Save(path string) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Names returns the profile names in order
+func (f *File) Names() []string {
+	var names []string
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the named profile
+func (f *File) Profile(name string) (Profile, error) {
+	p, ok := f.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %s", name)
+	}
+	return p, nil
+}
+
+// SetProfile adds or replaces the named profile
+func (f *File) SetProfile(name string, p Profile) {
+	if f.Profiles == nil {
+		f.Profiles = make(map[string]Profile)
+	}
+	f.Profiles[name] = p
+}
+
+// Validate checks the params of the profile are in the parameter tables
+// with values their type can hold
+func (p Profile) Validate() error {
+	for _, name := range p.paramNames() {
+		value := p.Params[name]
+		if _, _, err := boards.FloatIndex(name); err == nil {
+			continue
+		}
+		if _, _, err := boards.Uint16Index(name); err == nil {
+			if value < 0 || value > math.MaxUint16 || value != math.Trunc(value) {
+				return fmt.Errorf("%s must be an integer from 0 to %d", name, math.MaxUint16)
+			}
+			continue
+		}
+		return fmt.Errorf("unknown parameter %s", name)
+	}
+	return nil
+}
+
+func (p Profile) paramNames() []string {
+	var names []string
+	for name := range p.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SharedParams returns the persistent parameters a profile saves by
+// default, leaving out those identifying a device
+func SharedParams() []string {
+	var names []string
+	for _, param := range boards.Uint16Persist {
+		if param.Name == "device_id" || param.Name == "device_group" {
+			continue
+		}
+		names = append(names, param.Name)
+	}
+	for _, param := range boards.FloatPersist {
+		names = append(names, param.Name)
+	}
+	return names
+}
+
+// MotionConfig returns the configuration message setting the motion
+// settings of p, keeping the others from status
+func (p Profile) MotionConfig(status messages.MotionSensorStatusMessage) messages.MotionSensorConfigMessage {
+	msg := messages.NewMotionSensorConfigMessage(status.MotionThreshold,
+		status.LuxLowThreshold, status.LuxHighThreshold, status.Cooldown).(messages.MotionSensorConfigMessage)
+	if p.Motion == nil {
+		return msg
+	}
+	set(&msg.MotionThreshold, p.Motion.MotionThreshold)
+	set(&msg.LuxLowThreshold, p.Motion.LuxLow)
+	set(&msg.LuxHighThreshold, p.Motion.LuxHigh)
+	set(&msg.Cooldown, p.Motion.Cooldown)
+	return msg
+}
+
+// LightConfig returns the configuration message setting the light settings
+// of p, keeping the others from status
+func (p Profile) LightConfig(status messages.LightStatus) messages.LightConfigMessage {
+	msg := messages.NewLightConfigMessage(status.Level, status.Delay,
+		status.Attack, status.Sustain, status.Release).(messages.LightConfigMessage)
+	if p.Light == nil {
+		return msg
+	}
+	set(&msg.Level, p.Light.Level)
+	set(&msg.Delay, p.Light.Delay)
+	set(&msg.Attack, p.Light.Attack)
+	set(&msg.Sustain, p.Light.Sustain)
+	set(&msg.Release, p.Light.Release)
+	return msg
+}
+
+func set(field *float32, value *float32) {
+	if value != nil {
+		*field = *value
+	}
+}
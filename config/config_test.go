@@ -0,0 +1,143 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+func float(v float32) *float32 {
+	return &v
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+
+	_, err = Load(path)
+	if !os.IsNotExist(err) {
+		t.Errorf("Load() of a missing file error %v", err)
+	}
+
+	err = ioutil.WriteFile(path, []byte(`
+profiles:
+  night-trail:
+    motion:
+      motion_threshold: 0.35
+      lux_low: 5
+    light:
+      level: 0.8
+      sustain: 20
+    params:
+      led_on_record: 0
+      motion_gain: 0.5
+  day: {}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := f.Names(); !reflect.DeepEqual(names, []string{"day", "night-trail"}) {
+		t.Errorf("Names() = %v", names)
+	}
+	want := Profile{
+		Motion: &Motion{MotionThreshold: float(0.35), LuxLow: float(5)},
+		Light:  &Light{Level: float(0.8), Sustain: float(20)},
+		Params: map[string]float64{"led_on_record": 0, "motion_gain": 0.5},
+	}
+	p, err := f.Profile("night-trail")
+	if err != nil || !reflect.DeepEqual(p, want) {
+		t.Errorf("Profile() = %+v, %v, want %+v", p, err, want)
+	}
+	if _, err = f.Profile("missing"); err == nil {
+		t.Error("Profile() of a missing profile succeeded")
+	}
+
+	// Saving keeps every setting
+	err = f.Save(filepath.Join(dir, "saved", "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := Load(filepath.Join(dir, "saved", "config.yaml"))
+	if err != nil || !reflect.DeepEqual(saved, f) {
+		t.Errorf("saved %+v, %v, want %+v", saved, err, f)
+	}
+
+	errors := map[string]string{
+		"profiles:\n  a:\n    params:\n      missing: 1\n":       "profile a: unknown parameter missing",
+		"profiles:\n  a:\n    params:\n      device_id: 1.5\n":   "profile a: device_id must be an integer from 0 to 65535",
+		"profiles:\n  a:\n    params:\n      device_id: 70000\n": "profile a: device_id must be an integer from 0 to 65535",
+		"profiles:\n  a:\n    motion:\n      motion_thresh: 1\n": "field motion_thresh not found",
+	}
+	for data, want := range errors {
+		err = ioutil.WriteFile(path, []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = Load(path)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load(%q) error %v, want %s", data, err, want)
+		}
+	}
+}
+
+func TestConfigMessages(t *testing.T) {
+	p := Profile{
+		Motion: &Motion{MotionThreshold: float(0.35), Cooldown: float(30)},
+		Light:  &Light{Level: float(0.8)},
+	}
+
+	motion := p.MotionConfig(messages.MotionSensorStatusMessage{
+		MotionThreshold:  0.5,
+		LuxLowThreshold:  5,
+		LuxHighThreshold: 100,
+		Cooldown:         10,
+	})
+	want := messages.NewMotionSensorConfigMessage(0.35, 5, 100, 30)
+	if motion != want {
+		t.Errorf("MotionConfig() = %+v, want %+v", motion, want)
+	}
+
+	light := p.LightConfig(messages.LightStatus{Level: 0.5, Delay: 1, Attack: 2, Sustain: 10, Release: 4})
+	wantLight := messages.NewLightConfigMessage(0.8, 1, 2, 10, 4)
+	if light != wantLight {
+		t.Errorf("LightConfig() = %+v, want %+v", light, wantLight)
+	}
+
+	settings := configSettings(messages.MotionSensorStatusMessage{MotionThreshold: 0.35, Cooldown: 10}, p)
+	wantSettings := []Setting{
+		{"motion.motion_threshold", 0.35, 0.35},
+		{"motion.cooldown", 10, 30},
+	}
+	if !reflect.DeepEqual(settings, wantSettings) {
+		t.Errorf("configSettings() = %v, want %v", settings, wantSettings)
+	}
+	if diff := differing(settings); len(diff) != 1 || diff[0].String() != "motion.cooldown 10 -> 30" {
+		t.Errorf("differing() = %v", diff)
+	}
+}
+
+func TestSharedParams(t *testing.T) {
+	for _, name := range SharedParams() {
+		if name == "device_id" || name == "device_group" {
+			t.Errorf("SharedParams() includes %s", name)
+		}
+		err := Profile{Params: map[string]float64{name: 1}}.Validate()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/JuulLabs-OSS/ble"
 	"github.com/JuulLabs-OSS/ble/examples/lib/dev"
+	"github.com/phelpsw/camera-trigger-bt-cli/capture"
 	"github.com/pkg/errors"
 )
 
@@ -24,7 +25,24 @@ type readBytesCallbackType func(b []byte) error
 var mutex sync.RWMutex
 var devices map[string]Device
 
+// Transport carries bytes to and from a device. Connection is the bluetooth
+// transport, Replay plays back a capture.
+type Transport interface {
+	Init(device string, callback func(b []byte) error, debug bool) error
+	Callback(callback func(b []byte) error)
+	IsConnected() bool
+	WriteBytes(b *bytes.Buffer) error
+	Stop()
+	RSSI() int
+}
+
+// Searcher is a transport which searches for the device on Init
+type Searcher interface {
+	SetConnectTimeout(timeout time.Duration)
+}
+
 type Connection struct {
+	name                  string
 	device                ble.Device
 	client                ble.Client
 	profile               *ble.Profile
@@ -32,6 +50,11 @@ type Connection struct {
 	debug                 bool
 	callback              readBytesCallbackType
 	connected             bool
+	scanStop              chan struct{}
+	scanDone              chan struct{}
+
+	// ConnectTimeout limits the search for the device, zero waits forever
+	ConnectTimeout time.Duration
 }
 
 type Device struct {
@@ -44,19 +67,32 @@ type Device struct {
 	//ScanResponse  string    `json:"scanresponse"`
 }
 
+// adapter is the bluetooth interface shared by every connection
+var adapter ble.Device
+var adapterMutex sync.Mutex
+var connectMutex sync.Mutex
+
 func (curr *Connection) setup() error {
+	adapterMutex.Lock()
+	defer adapterMutex.Unlock()
+
 	if devices == nil {
 		devices = make(map[string]Device)
 	}
 	if curr.device != nil {
 		return nil
 	}
+	if adapter != nil {
+		curr.device = adapter
+		return nil
+	}
 	fmt.Printf("Initializing interface...")
 	var err error
 	curr.device, err = dev.NewDevice("default")
 	if err != nil {
 		return errors.Wrap(err, "can't init new device")
 	}
+	adapter = curr.device
 	ble.SetDefaultDevice(curr.device)
 	fmt.Printf("complete\n")
 	return nil
@@ -116,43 +152,73 @@ func chkErr(err error) error {
 	return err
 }
 
-var scanStop bool
-
-// Scan for eligible devices and print details when they are found
+// Scan for eligible devices and print details when they are found. The
+// adapter is held until StopScan, so no device connects during the scan.
 func (curr *Connection) Scan(dur time.Duration) (map[string]Device, error) {
 	err := curr.setup()
 
 	if err != nil {
-		return devices, err
+		return curr.ListDevices(), err
 	}
 
-	scanStop = false
+	// The adapter can't search for a device to connect to while scanning
+	connectMutex.Lock()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	curr.scanStop, curr.scanDone = stop, done
 	go func() {
+		defer close(done)
+		defer connectMutex.Unlock()
+
 		var allowDuplicates bool = false
-		for !scanStop {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
 			ctx := ble.WithSigHandler(context.WithTimeout(context.Background(), dur))
-			err = ble.Scan(ctx, allowDuplicates, adScanHandler, advFilter())
+			err := ble.Scan(ctx, allowDuplicates, adScanHandler, advFilter())
 			if err != nil {
 				return
 			}
 		}
-		return
 	}()
 
-	return devices, nil
+	return curr.ListDevices(), nil
 }
 
+// StopScan stops a scan started by Scan, waiting for the scan in progress
+// to finish
 func (curr *Connection) StopScan() error {
-	scanStop = true
+	if curr.scanStop == nil {
+		return nil
+	}
+	close(curr.scanStop)
+	<-curr.scanDone
+	curr.scanStop, curr.scanDone = nil, nil
 	return nil
 }
 
+// ListDevices returns a copy of the devices found by scanning
 func (curr *Connection) ListDevices() map[string]Device {
-	return devices
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	list := make(map[string]Device, len(devices))
+	for addr, device := range devices {
+		list[addr] = device
+	}
+	return list
+}
+
+// SetConnectTimeout limits the search for the device, zero waits forever
+func (curr *Connection) SetConnectTimeout(timeout time.Duration) {
+	curr.ConnectTimeout = timeout
 }
 
 // Set the callback to be used when receiving bytes
-func (curr *Connection) Callback(_callback readBytesCallbackType) {
+func (curr *Connection) Callback(_callback func(b []byte) error) {
 	curr.callback = _callback
 }
 
@@ -167,8 +233,17 @@ func (curr *Connection) connect(name string) error {
 		return strings.ToUpper(a.LocalName()) == strings.ToUpper(name)
 	}
 
-	ctx := ble.WithSigHandler(context.WithCancel(context.Background()))
-	if cln, err = ble.Connect(ctx, filter); err == nil {
+	var ctx context.Context
+	if curr.ConnectTimeout > 0 {
+		ctx = ble.WithSigHandler(context.WithTimeout(context.Background(), curr.ConnectTimeout))
+	} else {
+		ctx = ble.WithSigHandler(context.WithCancel(context.Background()))
+	}
+	// The adapter can only search for one device at a time
+	connectMutex.Lock()
+	cln, err = ble.Connect(ctx, filter)
+	connectMutex.Unlock()
+	if err == nil {
 		fmt.Printf("Connected to %s [%s]\n", name, cln.Addr())
 	}
 
@@ -189,7 +264,8 @@ func (curr *Connection) connect(name string) error {
 }
 
 // Init a connection to the a bluetooth device with the specified name.
-func (curr *Connection) Init(_device string, _callback readBytesCallbackType, _debug bool) error {
+func (curr *Connection) Init(_device string, _callback func(b []byte) error, _debug bool) error {
+	curr.name = _device
 	curr.debug = _debug
 	curr.callback = _callback
 	curr.connected = false
@@ -205,7 +281,8 @@ func (curr *Connection) Init(_device string, _callback readBytesCallbackType, _d
 	fmt.Printf("Discovering profile...")
 	p, err := curr.client.DiscoverProfile(true)
 	if err != nil {
-		log.Fatalf("can't discover profile: %s", err)
+		curr.client.CancelConnection()
+		return errors.Wrap(err, "can't discover profile")
 	}
 	curr.profile = p
 	fmt.Printf("complete\n")
@@ -226,7 +303,8 @@ func (curr *Connection) Init(_device string, _callback readBytesCallbackType, _d
 		}
 		indication := false
 		if err := curr.client.Subscribe(u.(*ble.Characteristic), indication, curr.readBytes); err != nil {
-			log.Fatalf("subscribe failed: %s", err)
+			curr.client.CancelConnection()
+			return errors.Wrap(err, "subscribe failed")
 		}
 	} else if u == nil {
 		return fmt.Errorf("Could not find TX Characteristic")
@@ -258,6 +336,16 @@ func (curr *Connection) IsConnected() bool {
 	return curr.connected
 }
 
+// RSSI reads the signal strength of the connected device in dBm, zero when
+// not connected
+func (curr *Connection) RSSI() int {
+	client := curr.client
+	if client == nil {
+		return 0
+	}
+	return client.ReadRSSI()
+}
+
 func (curr *Connection) WriteBytes(b *bytes.Buffer) error {
 	if curr.debug {
 		fmt.Printf("TX %d bytes: ", b.Len())
@@ -267,6 +355,7 @@ func (curr *Connection) WriteBytes(b *bytes.Buffer) error {
 		}
 		fmt.Printf("\n")
 	}
+	record(curr.name, capture.Sent, b.Bytes())
 
 	var noResp bool = true
 	err := curr.client.WriteCharacteristic(curr.receiveCharacteristic, b.Bytes(), noResp)
@@ -285,6 +374,7 @@ func (curr *Connection) readBytes(b []byte) {
 		}
 		fmt.Printf("\n")
 	}
+	record(curr.name, capture.Received, b)
 
 	if curr.callback != nil {
 		err := curr.callback(b)
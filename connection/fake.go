@@ -0,0 +1,149 @@
+package connection
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Fake is an in-memory transport standing in for a device in tests. Chunks
+// written are kept and passed to Respond, and the chunks it returns are
+// received in order as if sent by the device.
+type Fake struct {
+	// Connected returns the chunks the device sends once connected
+	Connected func() [][]byte
+	// Respond returns the chunks the device sends in reply to a chunk written
+	Respond func(b []byte) [][]byte
+
+	mutex     sync.Mutex
+	callback  func(b []byte) error
+	connected bool
+	queue     [][]byte
+	wake      chan struct{}
+	stop      chan struct{}
+	written   [][]byte
+}
+
+// Init connects to the fake device
+func (f *Fake) Init(device string, callback func(b []byte) error, debug bool) error {
+	f.mutex.Lock()
+	if f.connected {
+		f.mutex.Unlock()
+		return fmt.Errorf("already connected")
+	}
+	f.callback = callback
+	f.connected = true
+	f.queue = nil
+	f.wake = make(chan struct{}, 1)
+	f.stop = make(chan struct{})
+	wake, stop := f.wake, f.stop
+	f.mutex.Unlock()
+
+	go f.deliver(wake, stop)
+
+	if f.Connected != nil {
+		f.Receive(f.Connected()...)
+	}
+	return nil
+}
+
+// deliver passes queued chunks to the callback one at a time, as the
+// notifications of a live connection arrive
+func (f *Fake) deliver(wake, stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-wake:
+		}
+
+		for {
+			f.mutex.Lock()
+			if len(f.queue) == 0 || !f.connected {
+				f.mutex.Unlock()
+				break
+			}
+			chunk := f.queue[0]
+			f.queue = f.queue[1:]
+			callback := f.callback
+			f.mutex.Unlock()
+
+			if callback != nil {
+				err := callback(chunk)
+				if err != nil {
+					log.Printf("Callback handling error: %s\n", err)
+				}
+			}
+		}
+	}
+}
+
+// Receive queues chunks to be received from the device
+func (f *Fake) Receive(chunks ...[]byte) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.connected {
+		return
+	}
+	f.queue = append(f.queue, chunks...)
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Callback sets the callback to be used when receiving bytes
+func (f *Fake) Callback(callback func(b []byte) error) {
+	f.mutex.Lock()
+	f.callback = callback
+	f.mutex.Unlock()
+}
+
+// IsConnected is true from Init until Stop
+func (f *Fake) IsConnected() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.connected
+}
+
+// WriteBytes keeps a copy of the bytes written and queues the reply
+func (f *Fake) WriteBytes(b *bytes.Buffer) error {
+	chunk := append([]byte(nil), b.Bytes()...)
+
+	f.mutex.Lock()
+	if !f.connected {
+		f.mutex.Unlock()
+		return fmt.Errorf("not connected")
+	}
+	f.written = append(f.written, chunk)
+	f.mutex.Unlock()
+
+	if f.Respond != nil {
+		f.Receive(f.Respond(chunk)...)
+	}
+	return nil
+}
+
+// Written returns the chunks written to the device
+func (f *Fake) Written() [][]byte {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.written
+}
+
+// Stop disconnects, dropping any chunks not yet received
+func (f *Fake) Stop() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.connected {
+		return
+	}
+	f.connected = false
+	close(f.stop)
+}
+
+// RSSI is always zero
+func (f *Fake) RSSI() int {
+	return 0
+}
@@ -0,0 +1,39 @@
+package connection
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/capture"
+)
+
+var recorderMutex sync.Mutex
+var recorder *capture.Writer
+
+// SetRecorder captures every chunk of bytes written to or received from a
+// device by any connection, nil stops capturing
+func SetRecorder(w *capture.Writer) {
+	recorderMutex.Lock()
+	recorder = w
+	recorderMutex.Unlock()
+}
+
+func record(device string, dir capture.Direction, b []byte) {
+	recorderMutex.Lock()
+	w := recorder
+	recorderMutex.Unlock()
+	if w == nil {
+		return
+	}
+
+	err := w.Write(capture.Record{
+		Time:      time.Now(),
+		Device:    device,
+		Direction: dir,
+		Data:      append([]byte(nil), b...),
+	})
+	if err != nil {
+		log.Printf("Capture error: %s\n", err)
+	}
+}
@@ -0,0 +1,170 @@
+package connection
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/capture"
+)
+
+// Replay is a transport which plays the received chunks of a capture back
+// as if they came from a live device, keeping their original spacing. Bytes
+// written are kept for inspection rather than compared with the capture.
+// The device disconnects once the capture is exhausted.
+type Replay struct {
+	// Speed scales the pace of the replay, zero replays without delay
+	Speed float64
+
+	records []capture.Record
+	debug   bool
+
+	mutex     sync.Mutex
+	callback  func(b []byte) error
+	connected bool
+	stop      chan struct{}
+	done      chan struct{}
+	written   [][]byte
+}
+
+// NewReplay creates a transport replaying records at their original pace
+func NewReplay(records []capture.Record) *Replay {
+	return &Replay{Speed: 1, records: records}
+}
+
+// Init starts the replay of the chunks received from device. If the capture
+// holds no chunks for device, for instance because it was recorded under
+// another name, every received chunk is replayed.
+func (r *Replay) Init(device string, callback func(b []byte) error, debug bool) error {
+	var chunks []capture.Record
+	for _, rec := range r.records {
+		if rec.Direction == capture.Received && strings.EqualFold(rec.Device, device) {
+			chunks = append(chunks, rec)
+		}
+	}
+	if len(chunks) == 0 {
+		for _, rec := range r.records {
+			if rec.Direction == capture.Received {
+				chunks = append(chunks, rec)
+			}
+		}
+	}
+	if len(chunks) == 0 {
+		return fmt.Errorf("capture holds no received bytes")
+	}
+
+	r.mutex.Lock()
+	r.debug = debug
+	r.callback = callback
+	r.connected = true
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	stop, done := r.stop, r.done
+	r.mutex.Unlock()
+
+	go r.play(chunks, stop, done)
+	return nil
+}
+
+func (r *Replay) play(chunks []capture.Record, stop, done chan struct{}) {
+	defer close(done)
+	defer func() {
+		r.mutex.Lock()
+		r.connected = false
+		r.mutex.Unlock()
+	}()
+
+	start := time.Now()
+	for _, rec := range chunks {
+		if r.Speed > 0 {
+			offset := time.Duration(float64(rec.Time.Sub(chunks[0].Time)) / r.Speed)
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Until(start.Add(offset))):
+			}
+		} else {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+
+		r.mutex.Lock()
+		callback, debug := r.callback, r.debug
+		r.mutex.Unlock()
+
+		if debug {
+			fmt.Printf("RX %d bytes: ", len(rec.Data))
+			for i := 0; i < len(rec.Data); i++ {
+				fmt.Printf("0x%.2x, ", rec.Data[i])
+			}
+			fmt.Printf("\n")
+		}
+		if callback != nil {
+			err := callback(rec.Data)
+			if err != nil {
+				log.Printf("Callback handling error: %s\n", err)
+			}
+		}
+	}
+}
+
+// Callback sets the callback to be used when receiving bytes
+func (r *Replay) Callback(callback func(b []byte) error) {
+	r.mutex.Lock()
+	r.callback = callback
+	r.mutex.Unlock()
+}
+
+// IsConnected is true until the replay finishes or is stopped
+func (r *Replay) IsConnected() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.connected
+}
+
+// WriteBytes keeps a copy of the bytes written
+func (r *Replay) WriteBytes(b *bytes.Buffer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.connected {
+		return fmt.Errorf("not connected")
+	}
+	r.written = append(r.written, append([]byte(nil), b.Bytes()...))
+	return nil
+}
+
+// Written returns the chunks written to the replayed device
+func (r *Replay) Written() [][]byte {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.written
+}
+
+// Stop ends the replay
+func (r *Replay) Stop() {
+	r.mutex.Lock()
+	stop := r.stop
+	r.stop = nil
+	r.mutex.Unlock()
+	if stop != nil {
+		close(stop)
+	}
+}
+
+// Done is closed when the replay started by Init finishes or is stopped
+func (r *Replay) Done() <-chan struct{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.done
+}
+
+// RSSI is always zero
+func (r *Replay) RSSI() int {
+	return 0
+}
@@ -0,0 +1,147 @@
+package control
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/control/controlpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Client calls the control service. It is safe for concurrent use.
+//
+// Errors are gRPC status errors, status.Code gives their code.
+type Client struct {
+	conn *grpc.ClientConn
+	rpc  controlpb.ControlClient
+}
+
+// Dial connects to the service at addr. The connection is made on the
+// first call.
+func Dial(addr string) (*Client, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient wraps an existing gRPC connection
+func NewClient(conn *grpc.ClientConn) *Client {
+	return &Client{conn: conn, rpc: controlpb.NewControlClient(conn)}
+}
+
+// Close closes the connection to the service
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// duration converts d, zero leaving the service default
+func duration(d time.Duration) *durationpb.Duration {
+	if d <= 0 {
+		return nil
+	}
+	return durationpb.New(d)
+}
+
+// Scan lists the devices advertising during d, zero scans for the service
+// default
+func (c *Client) Scan(ctx context.Context, d time.Duration) ([]Device, error) {
+	resp, err := c.rpc.Scan(ctx, &controlpb.ScanRequest{Duration: duration(d)})
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	for _, dev := range resp.GetDevices() {
+		devices = append(devices, DeviceFromProto(dev))
+	}
+	return devices, nil
+}
+
+// Connect connects the service to a device and waits for its first status.
+// timeout limits the search for the device, zero uses the service default.
+func (c *Client) Connect(ctx context.Context, device string, timeout time.Duration) (Connected, error) {
+	resp, err := c.rpc.Connect(ctx, &controlpb.ConnectRequest{Device: device, Timeout: duration(timeout)})
+	if err != nil {
+		return Connected{}, err
+	}
+	return ConnectedFromProto(resp), nil
+}
+
+// Disconnect disconnects the service from a device
+func (c *Client) Disconnect(ctx context.Context, device string) error {
+	_, err := c.rpc.Disconnect(ctx, &controlpb.DeviceRequest{Device: device})
+	return err
+}
+
+// List returns the connected devices
+func (c *Client) List(ctx context.Context) ([]Connected, error) {
+	resp, err := c.rpc.List(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	var devices []Connected
+	for _, dev := range resp.GetDevices() {
+		devices = append(devices, ConnectedFromProto(dev))
+	}
+	return devices, nil
+}
+
+// GetParam reads a named parameter
+func (c *Client) GetParam(ctx context.Context, device, name string) (Param, error) {
+	resp, err := c.rpc.GetParam(ctx, &controlpb.ParamRequest{Device: device, Name: name})
+	if err != nil {
+		return Param{}, err
+	}
+	return ParamFromProto(resp), nil
+}
+
+// SetParam writes a named parameter
+func (c *Client) SetParam(ctx context.Context, device, name string, value float64) (Param, error) {
+	resp, err := c.rpc.SetParam(ctx, &controlpb.SetParamRequest{Device: device, Name: name, Value: value})
+	if err != nil {
+		return Param{}, err
+	}
+	return ParamFromProto(resp), nil
+}
+
+// Trigger triggers a device
+func (c *Client) Trigger(ctx context.Context, device string, lux float32) error {
+	_, err := c.rpc.Trigger(ctx, &controlpb.TriggerRequest{Device: device, Lux: lux})
+	return err
+}
+
+// StreamStatus calls fn with the latest status from device and then each
+// new one, until ctx is done or the stream fails. It returns nil when ctx
+// is done or the device is disconnected. Statuses arriving faster than fn
+// returns are skipped, Sequence shows how many.
+func (c *Client) StreamStatus(ctx context.Context, device string, fn func(Status)) error {
+	stream, err := c.rpc.StreamStatus(ctx, &controlpb.DeviceRequest{Device: device})
+	if err != nil {
+		return err
+	}
+	for {
+		s, err := stream.Recv()
+		if err == io.EOF || ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fn(StatusFromProto(s))
+	}
+}
+
+// Logs fetches log entries [start, end), end zero fetches to the end of the
+// log
+func (c *Client) Logs(ctx context.Context, device string, start, end uint16) (Logs, error) {
+	resp, err := c.rpc.Logs(ctx, &controlpb.LogsRequest{Device: device, Start: uint32(start), End: uint32(end)})
+	if err != nil {
+		return Logs{}, err
+	}
+	return LogsFromProto(resp), nil
+}
@@ -0,0 +1,66 @@
+// Package control is the client of the board control service run by the
+// control command. One process owns the bluetooth adapter and any number of
+// clients drive boards through it concurrently.
+//
+// The service is gRPC, defined in controlpb/control.proto, so it can also
+// be called from other languages. The client here returns the types below,
+// which carry device messages as the types of the messages package.
+package control
+
+import (
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+)
+
+// DefaultAddress is the default listen address of the service
+const DefaultAddress = "127.0.0.1:7070"
+
+// Board types reported by Connect and Status
+const (
+	BoardMotion = "motion"
+	BoardLight  = "light"
+)
+
+// Device is a device seen while scanning
+type Device struct {
+	Name     string
+	Address  string
+	RSSI     int
+	Detected time.Time
+}
+
+// Connected is a device the service is connected to
+type Connected struct {
+	Device string
+	Board  string
+}
+
+// Param is the result of reading or writing a named parameter
+type Param struct {
+	Name string
+	// Type is float or uint16
+	Type    string
+	Persist bool
+	Success bool
+	Value   float64
+}
+
+// Status is a status message from a device. Exactly one of Motion and
+// Light is set.
+type Status struct {
+	Device string
+	// Sequence counts the status messages received, zero if none has been
+	Sequence uint64
+	Board    string
+	Received time.Time
+	Motion   *messages.MotionSensorStatusMessage
+	Light    *messages.LightStatusMessage
+}
+
+// Logs is the result of downloading log entries
+type Logs struct {
+	Entries []messages.LogResponseMessage
+	// Missing lists the indices which could not be fetched
+	Missing []uint16
+}
@@ -0,0 +1,191 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/control/controlpb"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeService implements part of the service without any hardware
+type fakeService struct {
+	controlpb.UnimplementedControlServer
+
+	mutex  sync.Mutex
+	params map[string]float64
+	logs   Logs
+}
+
+func (s *fakeService) GetParam(ctx context.Context, req *controlpb.ParamRequest) (*controlpb.Param, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	value, ok := s.params[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown parameter %q", req.Name)
+	}
+	return Param{Name: req.Name, Type: "float", Persist: true, Success: true, Value: value}.Proto(), nil
+}
+
+func (s *fakeService) SetParam(ctx context.Context, req *controlpb.SetParamRequest) (*controlpb.Param, error) {
+	s.mutex.Lock()
+	s.params[req.Name] = req.Value
+	s.mutex.Unlock()
+	return s.GetParam(ctx, &controlpb.ParamRequest{Device: req.Device, Name: req.Name})
+}
+
+// StreamStatus sends motion statuses until the client goes away
+func (s *fakeService) StreamStatus(req *controlpb.DeviceRequest, stream grpc.ServerStreamingServer[controlpb.Status]) error {
+	for sequence := uint64(1); ; sequence++ {
+		status := Status{
+			Device:   req.Device,
+			Sequence: sequence,
+			Board:    BoardMotion,
+			Received: time.Now(),
+			Motion:   &messages.MotionSensorStatusMessage{Motion: float32(sequence) / 10},
+		}
+		err := stream.Send(status.Proto())
+		if err != nil {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (s *fakeService) Logs(ctx context.Context, req *controlpb.LogsRequest) (*controlpb.LogsResponse, error) {
+	return s.logs.Proto(), nil
+}
+
+func newTestClient(t *testing.T, service *fakeService) *Client {
+	listener := bufconn.Listen(1 << 16)
+	server := grpc.NewServer()
+	controlpb.RegisterControlServer(server, service)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(conn)
+}
+
+func TestParams(t *testing.T) {
+	c := newTestClient(t, &fakeService{params: map[string]float64{"motion_threshold": 0.5}})
+	defer c.Close()
+	ctx := context.Background()
+
+	p, err := c.GetParam(ctx, "camera", "motion_threshold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Value != 0.5 || !p.Success || p.Type != "float" {
+		t.Errorf("GetParam() = %+v", p)
+	}
+
+	p, err = c.SetParam(ctx, "camera", "motion_threshold", 0.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Value != 0.3 {
+		t.Errorf("SetParam() = %+v", p)
+	}
+
+	_, err = c.GetParam(ctx, "camera", "missing")
+	if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != `unknown parameter "missing"` {
+		t.Errorf("GetParam(missing) error %v", err)
+	}
+}
+
+func TestConcurrentCalls(t *testing.T) {
+	c := newTestClient(t, &fakeService{params: map[string]float64{}})
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(device string) {
+			defer wg.Done()
+			_, err := c.SetParam(context.Background(), device, "motion_threshold", 0.1)
+			if err != nil {
+				t.Error(err)
+			}
+		}(fmt.Sprintf("camera%d", i))
+	}
+	wg.Wait()
+}
+
+func TestStreamStatus(t *testing.T) {
+	c := newTestClient(t, &fakeService{})
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var received []Status
+	err := c.StreamStatus(ctx, "camera", func(s Status) {
+		received = append(received, s)
+		if len(received) == 3 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(received) != 3 {
+		t.Fatalf("received %d statuses after cancelling, want 3", len(received))
+	}
+	for i, s := range received {
+		if s.Sequence != uint64(i+1) || s.Device != "camera" || s.Motion == nil || s.Light != nil ||
+			s.Motion.Motion != float32(i+1)/10 || s.Received.IsZero() {
+			t.Errorf("status %d = %+v", i, s)
+		}
+	}
+}
+
+func TestStreamStatusError(t *testing.T) {
+	c := newTestClient(t, &fakeService{})
+	c.Close()
+
+	err := c.StreamStatus(context.Background(), "camera", func(Status) {})
+	if err == nil {
+		t.Error("StreamStatus() on a closed client returned nil")
+	}
+}
+
+func TestLogs(t *testing.T) {
+	info, _ := messages.TypeByName("LogResponse")
+	entry := messages.LogResponseMessage{
+		Type:      info.Code,
+		Length:    uint8(info.Size()),
+		Index:     3,
+		Timestamp: messages.Calendar{Seconds: 5, Hours: 12, DayOfMonth: 19, Month: 10, Year: 26},
+		LogType:   1,
+		Payload:   [13]byte{1, 2, 3},
+	}
+	want := Logs{Entries: []messages.LogResponseMessage{entry}, Missing: []uint16{4}}
+	c := newTestClient(t, &fakeService{logs: want})
+	defer c.Close()
+
+	got, err := c.Logs(context.Background(), "camera", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Logs() = %+v, want %+v", got, want)
+	}
+}
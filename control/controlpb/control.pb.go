@@ -0,0 +1,1492 @@
+// The board control service run by the control command. One process owns
+// the bluetooth adapter and any number of clients drive boards through it
+// concurrently. Requests to one device are handled one at a time, requests
+// to different devices run concurrently.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v5.29.3
+// source: control.proto
+
+package controlpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DeviceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Device        string                 `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceRequest) Reset() {
+	*x = DeviceRequest{}
+	mi := &file_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceRequest) ProtoMessage() {}
+
+func (x *DeviceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceRequest.ProtoReflect.Descriptor instead.
+func (*DeviceRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DeviceRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+type ScanRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unset scans for the service default
+	Duration      *durationpb.Duration `protobuf:"bytes,1,opt,name=duration,proto3" json:"duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ScanRequest) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+// A device seen while scanning
+type Device struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Rssi          int32                  `protobuf:"varint,3,opt,name=rssi,proto3" json:"rssi,omitempty"`
+	Detected      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=detected,proto3" json:"detected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Device) Reset() {
+	*x = Device{}
+	mi := &file_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Device) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Device) ProtoMessage() {}
+
+func (x *Device) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Device.ProtoReflect.Descriptor instead.
+func (*Device) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Device) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Device) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Device) GetRssi() int32 {
+	if x != nil {
+		return x.Rssi
+	}
+	return 0
+}
+
+func (x *Device) GetDetected() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Detected
+	}
+	return nil
+}
+
+type ScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Devices       []*Device              `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ScanResponse) GetDevices() []*Device {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+type ConnectRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Device string                 `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	// Limits the search for the device, unset uses the service default
+	Timeout       *durationpb.Duration `protobuf:"bytes,2,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectRequest) Reset() {
+	*x = ConnectRequest{}
+	mi := &file_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectRequest) ProtoMessage() {}
+
+func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectRequest.ProtoReflect.Descriptor instead.
+func (*ConnectRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ConnectRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *ConnectRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+type ConnectResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Device string                 `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	// motion or light
+	Board         string `protobuf:"bytes,2,opt,name=board,proto3" json:"board,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectResponse) Reset() {
+	*x = ConnectResponse{}
+	mi := &file_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectResponse) ProtoMessage() {}
+
+func (x *ConnectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectResponse.ProtoReflect.Descriptor instead.
+func (*ConnectResponse) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ConnectResponse) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *ConnectResponse) GetBoard() string {
+	if x != nil {
+		return x.Board
+	}
+	return ""
+}
+
+type ListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Devices       []*ConnectResponse     `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListResponse) GetDevices() []*ConnectResponse {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+type ParamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Device        string                 `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParamRequest) Reset() {
+	*x = ParamRequest{}
+	mi := &file_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParamRequest) ProtoMessage() {}
+
+func (x *ParamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParamRequest.ProtoReflect.Descriptor instead.
+func (*ParamRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ParamRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *ParamRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type SetParamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Device        string                 `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Value         float64                `protobuf:"fixed64,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetParamRequest) Reset() {
+	*x = SetParamRequest{}
+	mi := &file_control_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetParamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetParamRequest) ProtoMessage() {}
+
+func (x *SetParamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetParamRequest.ProtoReflect.Descriptor instead.
+func (*SetParamRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SetParamRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *SetParamRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SetParamRequest) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+// The result of reading or writing a named parameter
+type Param struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// float or uint16
+	Type          string  `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Persist       bool    `protobuf:"varint,3,opt,name=persist,proto3" json:"persist,omitempty"`
+	Success       bool    `protobuf:"varint,4,opt,name=success,proto3" json:"success,omitempty"`
+	Value         float64 `protobuf:"fixed64,5,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Param) Reset() {
+	*x = Param{}
+	mi := &file_control_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Param) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Param) ProtoMessage() {}
+
+func (x *Param) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Param.ProtoReflect.Descriptor instead.
+func (*Param) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Param) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Param) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Param) GetPersist() bool {
+	if x != nil {
+		return x.Persist
+	}
+	return false
+}
+
+func (x *Param) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *Param) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type TriggerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Device        string                 `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	Lux           float32                `protobuf:"fixed32,2,opt,name=lux,proto3" json:"lux,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerRequest) Reset() {
+	*x = TriggerRequest{}
+	mi := &file_control_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerRequest) ProtoMessage() {}
+
+func (x *TriggerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerRequest.ProtoReflect.Descriptor instead.
+func (*TriggerRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TriggerRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *TriggerRequest) GetLux() float32 {
+	if x != nil {
+		return x.Lux
+	}
+	return 0
+}
+
+// A device clock reading. The year is an offset from 2000.
+type Calendar struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seconds       uint32                 `protobuf:"varint,1,opt,name=seconds,proto3" json:"seconds,omitempty"`
+	Minutes       uint32                 `protobuf:"varint,2,opt,name=minutes,proto3" json:"minutes,omitempty"`
+	Hours         uint32                 `protobuf:"varint,3,opt,name=hours,proto3" json:"hours,omitempty"`
+	DayOfWeek     uint32                 `protobuf:"varint,4,opt,name=day_of_week,json=dayOfWeek,proto3" json:"day_of_week,omitempty"`
+	DayOfMonth    uint32                 `protobuf:"varint,5,opt,name=day_of_month,json=dayOfMonth,proto3" json:"day_of_month,omitempty"`
+	Month         uint32                 `protobuf:"varint,6,opt,name=month,proto3" json:"month,omitempty"`
+	Year          uint32                 `protobuf:"varint,7,opt,name=year,proto3" json:"year,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Calendar) Reset() {
+	*x = Calendar{}
+	mi := &file_control_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Calendar) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Calendar) ProtoMessage() {}
+
+func (x *Calendar) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Calendar.ProtoReflect.Descriptor instead.
+func (*Calendar) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Calendar) GetSeconds() uint32 {
+	if x != nil {
+		return x.Seconds
+	}
+	return 0
+}
+
+func (x *Calendar) GetMinutes() uint32 {
+	if x != nil {
+		return x.Minutes
+	}
+	return 0
+}
+
+func (x *Calendar) GetHours() uint32 {
+	if x != nil {
+		return x.Hours
+	}
+	return 0
+}
+
+func (x *Calendar) GetDayOfWeek() uint32 {
+	if x != nil {
+		return x.DayOfWeek
+	}
+	return 0
+}
+
+func (x *Calendar) GetDayOfMonth() uint32 {
+	if x != nil {
+		return x.DayOfMonth
+	}
+	return 0
+}
+
+func (x *Calendar) GetMonth() uint32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+func (x *Calendar) GetYear() uint32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+type MotionStatus struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp        *Calendar              `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Temperature      float32                `protobuf:"fixed32,2,opt,name=temperature,proto3" json:"temperature,omitempty"`
+	Voltage          float32                `protobuf:"fixed32,3,opt,name=voltage,proto3" json:"voltage,omitempty"`
+	Motion           float32                `protobuf:"fixed32,4,opt,name=motion,proto3" json:"motion,omitempty"`
+	MotionThreshold  float32                `protobuf:"fixed32,5,opt,name=motion_threshold,json=motionThreshold,proto3" json:"motion_threshold,omitempty"`
+	Lux              float32                `protobuf:"fixed32,6,opt,name=lux,proto3" json:"lux,omitempty"`
+	LuxLowThreshold  float32                `protobuf:"fixed32,7,opt,name=lux_low_threshold,json=luxLowThreshold,proto3" json:"lux_low_threshold,omitempty"`
+	LuxHighThreshold float32                `protobuf:"fixed32,8,opt,name=lux_high_threshold,json=luxHighThreshold,proto3" json:"lux_high_threshold,omitempty"`
+	Cooldown         float32                `protobuf:"fixed32,9,opt,name=cooldown,proto3" json:"cooldown,omitempty"`
+	MotionSensorType uint32                 `protobuf:"varint,10,opt,name=motion_sensor_type,json=motionSensorType,proto3" json:"motion_sensor_type,omitempty"`
+	LedModes         uint32                 `protobuf:"varint,11,opt,name=led_modes,json=ledModes,proto3" json:"led_modes,omitempty"`
+	LogEntries       uint32                 `protobuf:"varint,12,opt,name=log_entries,json=logEntries,proto3" json:"log_entries,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MotionStatus) Reset() {
+	*x = MotionStatus{}
+	mi := &file_control_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MotionStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MotionStatus) ProtoMessage() {}
+
+func (x *MotionStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MotionStatus.ProtoReflect.Descriptor instead.
+func (*MotionStatus) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *MotionStatus) GetTimestamp() *Calendar {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *MotionStatus) GetTemperature() float32 {
+	if x != nil {
+		return x.Temperature
+	}
+	return 0
+}
+
+func (x *MotionStatus) GetVoltage() float32 {
+	if x != nil {
+		return x.Voltage
+	}
+	return 0
+}
+
+func (x *MotionStatus) GetMotion() float32 {
+	if x != nil {
+		return x.Motion
+	}
+	return 0
+}
+
+func (x *MotionStatus) GetMotionThreshold() float32 {
+	if x != nil {
+		return x.MotionThreshold
+	}
+	return 0
+}
+
+func (x *MotionStatus) GetLux() float32 {
+	if x != nil {
+		return x.Lux
+	}
+	return 0
+}
+
+func (x *MotionStatus) GetLuxLowThreshold() float32 {
+	if x != nil {
+		return x.LuxLowThreshold
+	}
+	return 0
+}
+
+func (x *MotionStatus) GetLuxHighThreshold() float32 {
+	if x != nil {
+		return x.LuxHighThreshold
+	}
+	return 0
+}
+
+func (x *MotionStatus) GetCooldown() float32 {
+	if x != nil {
+		return x.Cooldown
+	}
+	return 0
+}
+
+func (x *MotionStatus) GetMotionSensorType() uint32 {
+	if x != nil {
+		return x.MotionSensorType
+	}
+	return 0
+}
+
+func (x *MotionStatus) GetLedModes() uint32 {
+	if x != nil {
+		return x.LedModes
+	}
+	return 0
+}
+
+func (x *MotionStatus) GetLogEntries() uint32 {
+	if x != nil {
+		return x.LogEntries
+	}
+	return 0
+}
+
+type LightStatus struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp        *Calendar              `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Temperature      float32                `protobuf:"fixed32,2,opt,name=temperature,proto3" json:"temperature,omitempty"`
+	Voltage          float32                `protobuf:"fixed32,3,opt,name=voltage,proto3" json:"voltage,omitempty"`
+	Level            float32                `protobuf:"fixed32,4,opt,name=level,proto3" json:"level,omitempty"`
+	Delay            float32                `protobuf:"fixed32,5,opt,name=delay,proto3" json:"delay,omitempty"`
+	Attack           float32                `protobuf:"fixed32,6,opt,name=attack,proto3" json:"attack,omitempty"`
+	Sustain          float32                `protobuf:"fixed32,7,opt,name=sustain,proto3" json:"sustain,omitempty"`
+	Release          float32                `protobuf:"fixed32,8,opt,name=release,proto3" json:"release,omitempty"`
+	LightTemperature float32                `protobuf:"fixed32,9,opt,name=light_temperature,json=lightTemperature,proto3" json:"light_temperature,omitempty"`
+	Current          float32                `protobuf:"fixed32,10,opt,name=current,proto3" json:"current,omitempty"`
+	LedModes         uint32                 `protobuf:"varint,11,opt,name=led_modes,json=ledModes,proto3" json:"led_modes,omitempty"`
+	LogEntries       uint32                 `protobuf:"varint,12,opt,name=log_entries,json=logEntries,proto3" json:"log_entries,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *LightStatus) Reset() {
+	*x = LightStatus{}
+	mi := &file_control_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LightStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LightStatus) ProtoMessage() {}
+
+func (x *LightStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LightStatus.ProtoReflect.Descriptor instead.
+func (*LightStatus) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *LightStatus) GetTimestamp() *Calendar {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *LightStatus) GetTemperature() float32 {
+	if x != nil {
+		return x.Temperature
+	}
+	return 0
+}
+
+func (x *LightStatus) GetVoltage() float32 {
+	if x != nil {
+		return x.Voltage
+	}
+	return 0
+}
+
+func (x *LightStatus) GetLevel() float32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *LightStatus) GetDelay() float32 {
+	if x != nil {
+		return x.Delay
+	}
+	return 0
+}
+
+func (x *LightStatus) GetAttack() float32 {
+	if x != nil {
+		return x.Attack
+	}
+	return 0
+}
+
+func (x *LightStatus) GetSustain() float32 {
+	if x != nil {
+		return x.Sustain
+	}
+	return 0
+}
+
+func (x *LightStatus) GetRelease() float32 {
+	if x != nil {
+		return x.Release
+	}
+	return 0
+}
+
+func (x *LightStatus) GetLightTemperature() float32 {
+	if x != nil {
+		return x.LightTemperature
+	}
+	return 0
+}
+
+func (x *LightStatus) GetCurrent() float32 {
+	if x != nil {
+		return x.Current
+	}
+	return 0
+}
+
+func (x *LightStatus) GetLedModes() uint32 {
+	if x != nil {
+		return x.LedModes
+	}
+	return 0
+}
+
+func (x *LightStatus) GetLogEntries() uint32 {
+	if x != nil {
+		return x.LogEntries
+	}
+	return 0
+}
+
+type Status struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Device string                 `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	// Counts the status messages received, carrying on across reconnects
+	Sequence uint64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// motion or light
+	Board    string                 `protobuf:"bytes,3,opt,name=board,proto3" json:"board,omitempty"`
+	Received *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=received,proto3" json:"received,omitempty"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*Status_Motion
+	//	*Status_Light
+	Message       isStatus_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_control_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Status) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *Status) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *Status) GetBoard() string {
+	if x != nil {
+		return x.Board
+	}
+	return ""
+}
+
+func (x *Status) GetReceived() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Received
+	}
+	return nil
+}
+
+func (x *Status) GetMessage() isStatus_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *Status) GetMotion() *MotionStatus {
+	if x != nil {
+		if x, ok := x.Message.(*Status_Motion); ok {
+			return x.Motion
+		}
+	}
+	return nil
+}
+
+func (x *Status) GetLight() *LightStatus {
+	if x != nil {
+		if x, ok := x.Message.(*Status_Light); ok {
+			return x.Light
+		}
+	}
+	return nil
+}
+
+type isStatus_Message interface {
+	isStatus_Message()
+}
+
+type Status_Motion struct {
+	Motion *MotionStatus `protobuf:"bytes,5,opt,name=motion,proto3,oneof"`
+}
+
+type Status_Light struct {
+	Light *LightStatus `protobuf:"bytes,6,opt,name=light,proto3,oneof"`
+}
+
+func (*Status_Motion) isStatus_Message() {}
+
+func (*Status_Light) isStatus_Message() {}
+
+type LogsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Device string                 `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	Start  uint32                 `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	// One past the last index to fetch, zero fetches to the end of the log
+	End           uint32 `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogsRequest) Reset() {
+	*x = LogsRequest{}
+	mi := &file_control_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogsRequest) ProtoMessage() {}
+
+func (x *LogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogsRequest.ProtoReflect.Descriptor instead.
+func (*LogsRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *LogsRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *LogsRequest) GetStart() uint32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *LogsRequest) GetEnd() uint32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type LogEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         uint32                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Timestamp     *Calendar              `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	LogType       uint32                 `protobuf:"varint,3,opt,name=log_type,json=logType,proto3" json:"log_type,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_control_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *LogEntry) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *LogEntry) GetTimestamp() *Calendar {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *LogEntry) GetLogType() uint32 {
+	if x != nil {
+		return x.LogType
+	}
+	return 0
+}
+
+func (x *LogEntry) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type LogsResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Entries []*LogEntry            `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// Indices which could not be fetched
+	Missing       []uint32 `protobuf:"varint,2,rep,packed,name=missing,proto3" json:"missing,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogsResponse) Reset() {
+	*x = LogsResponse{}
+	mi := &file_control_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogsResponse) ProtoMessage() {}
+
+func (x *LogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogsResponse.ProtoReflect.Descriptor instead.
+func (*LogsResponse) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *LogsResponse) GetEntries() []*LogEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *LogsResponse) GetMissing() []uint32 {
+	if x != nil {
+		return x.Missing
+	}
+	return nil
+}
+
+var File_control_proto protoreflect.FileDescriptor
+
+const file_control_proto_rawDesc = "" +
+	"\n" +
+	"\rcontrol.proto\x12\x15cameratrigger.control\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"'\n" +
+	"\rDeviceRequest\x12\x16\n" +
+	"\x06device\x18\x01 \x01(\tR\x06device\"D\n" +
+	"\vScanRequest\x125\n" +
+	"\bduration\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\bduration\"\x82\x01\n" +
+	"\x06Device\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x12\n" +
+	"\x04rssi\x18\x03 \x01(\x05R\x04rssi\x126\n" +
+	"\bdetected\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\bdetected\"G\n" +
+	"\fScanResponse\x127\n" +
+	"\adevices\x18\x01 \x03(\v2\x1d.cameratrigger.control.DeviceR\adevices\"]\n" +
+	"\x0eConnectRequest\x12\x16\n" +
+	"\x06device\x18\x01 \x01(\tR\x06device\x123\n" +
+	"\atimeout\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\atimeout\"?\n" +
+	"\x0fConnectResponse\x12\x16\n" +
+	"\x06device\x18\x01 \x01(\tR\x06device\x12\x14\n" +
+	"\x05board\x18\x02 \x01(\tR\x05board\"P\n" +
+	"\fListResponse\x12@\n" +
+	"\adevices\x18\x01 \x03(\v2&.cameratrigger.control.ConnectResponseR\adevices\":\n" +
+	"\fParamRequest\x12\x16\n" +
+	"\x06device\x18\x01 \x01(\tR\x06device\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"S\n" +
+	"\x0fSetParamRequest\x12\x16\n" +
+	"\x06device\x18\x01 \x01(\tR\x06device\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x01R\x05value\"y\n" +
+	"\x05Param\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\apersist\x18\x03 \x01(\bR\apersist\x12\x18\n" +
+	"\asuccess\x18\x04 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05value\x18\x05 \x01(\x01R\x05value\":\n" +
+	"\x0eTriggerRequest\x12\x16\n" +
+	"\x06device\x18\x01 \x01(\tR\x06device\x12\x10\n" +
+	"\x03lux\x18\x02 \x01(\x02R\x03lux\"\xc0\x01\n" +
+	"\bCalendar\x12\x18\n" +
+	"\aseconds\x18\x01 \x01(\rR\aseconds\x12\x18\n" +
+	"\aminutes\x18\x02 \x01(\rR\aminutes\x12\x14\n" +
+	"\x05hours\x18\x03 \x01(\rR\x05hours\x12\x1e\n" +
+	"\vday_of_week\x18\x04 \x01(\rR\tdayOfWeek\x12 \n" +
+	"\fday_of_month\x18\x05 \x01(\rR\n" +
+	"dayOfMonth\x12\x14\n" +
+	"\x05month\x18\x06 \x01(\rR\x05month\x12\x12\n" +
+	"\x04year\x18\a \x01(\rR\x04year\"\xc0\x03\n" +
+	"\fMotionStatus\x12=\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1f.cameratrigger.control.CalendarR\ttimestamp\x12 \n" +
+	"\vtemperature\x18\x02 \x01(\x02R\vtemperature\x12\x18\n" +
+	"\avoltage\x18\x03 \x01(\x02R\avoltage\x12\x16\n" +
+	"\x06motion\x18\x04 \x01(\x02R\x06motion\x12)\n" +
+	"\x10motion_threshold\x18\x05 \x01(\x02R\x0fmotionThreshold\x12\x10\n" +
+	"\x03lux\x18\x06 \x01(\x02R\x03lux\x12*\n" +
+	"\x11lux_low_threshold\x18\a \x01(\x02R\x0fluxLowThreshold\x12,\n" +
+	"\x12lux_high_threshold\x18\b \x01(\x02R\x10luxHighThreshold\x12\x1a\n" +
+	"\bcooldown\x18\t \x01(\x02R\bcooldown\x12,\n" +
+	"\x12motion_sensor_type\x18\n" +
+	" \x01(\rR\x10motionSensorType\x12\x1b\n" +
+	"\tled_modes\x18\v \x01(\rR\bledModes\x12\x1f\n" +
+	"\vlog_entries\x18\f \x01(\rR\n" +
+	"logEntries\"\x85\x03\n" +
+	"\vLightStatus\x12=\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1f.cameratrigger.control.CalendarR\ttimestamp\x12 \n" +
+	"\vtemperature\x18\x02 \x01(\x02R\vtemperature\x12\x18\n" +
+	"\avoltage\x18\x03 \x01(\x02R\avoltage\x12\x14\n" +
+	"\x05level\x18\x04 \x01(\x02R\x05level\x12\x14\n" +
+	"\x05delay\x18\x05 \x01(\x02R\x05delay\x12\x16\n" +
+	"\x06attack\x18\x06 \x01(\x02R\x06attack\x12\x18\n" +
+	"\asustain\x18\a \x01(\x02R\asustain\x12\x18\n" +
+	"\arelease\x18\b \x01(\x02R\arelease\x12+\n" +
+	"\x11light_temperature\x18\t \x01(\x02R\x10lightTemperature\x12\x18\n" +
+	"\acurrent\x18\n" +
+	" \x01(\x02R\acurrent\x12\x1b\n" +
+	"\tled_modes\x18\v \x01(\rR\bledModes\x12\x1f\n" +
+	"\vlog_entries\x18\f \x01(\rR\n" +
+	"logEntries\"\x90\x02\n" +
+	"\x06Status\x12\x16\n" +
+	"\x06device\x18\x01 \x01(\tR\x06device\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\x04R\bsequence\x12\x14\n" +
+	"\x05board\x18\x03 \x01(\tR\x05board\x126\n" +
+	"\breceived\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\breceived\x12=\n" +
+	"\x06motion\x18\x05 \x01(\v2#.cameratrigger.control.MotionStatusH\x00R\x06motion\x12:\n" +
+	"\x05light\x18\x06 \x01(\v2\".cameratrigger.control.LightStatusH\x00R\x05lightB\t\n" +
+	"\amessage\"M\n" +
+	"\vLogsRequest\x12\x16\n" +
+	"\x06device\x18\x01 \x01(\tR\x06device\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\rR\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\rR\x03end\"\x94\x01\n" +
+	"\bLogEntry\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\rR\x05index\x12=\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1f.cameratrigger.control.CalendarR\ttimestamp\x12\x19\n" +
+	"\blog_type\x18\x03 \x01(\rR\alogType\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\"c\n" +
+	"\fLogsResponse\x129\n" +
+	"\aentries\x18\x01 \x03(\v2\x1f.cameratrigger.control.LogEntryR\aentries\x12\x18\n" +
+	"\amissing\x18\x02 \x03(\rR\amissing2\xd8\x05\n" +
+	"\aControl\x12O\n" +
+	"\x04Scan\x12\".cameratrigger.control.ScanRequest\x1a#.cameratrigger.control.ScanResponse\x12X\n" +
+	"\aConnect\x12%.cameratrigger.control.ConnectRequest\x1a&.cameratrigger.control.ConnectResponse\x12J\n" +
+	"\n" +
+	"Disconnect\x12$.cameratrigger.control.DeviceRequest\x1a\x16.google.protobuf.Empty\x12C\n" +
+	"\x04List\x12\x16.google.protobuf.Empty\x1a#.cameratrigger.control.ListResponse\x12M\n" +
+	"\bGetParam\x12#.cameratrigger.control.ParamRequest\x1a\x1c.cameratrigger.control.Param\x12P\n" +
+	"\bSetParam\x12&.cameratrigger.control.SetParamRequest\x1a\x1c.cameratrigger.control.Param\x12H\n" +
+	"\aTrigger\x12%.cameratrigger.control.TriggerRequest\x1a\x16.google.protobuf.Empty\x12U\n" +
+	"\fStreamStatus\x12$.cameratrigger.control.DeviceRequest\x1a\x1d.cameratrigger.control.Status0\x01\x12O\n" +
+	"\x04Logs\x12\".cameratrigger.control.LogsRequest\x1a#.cameratrigger.control.LogsResponseB<Z:github.com/phelpsw/camera-trigger-bt-cli/control/controlpbb\x06proto3"
+
+var (
+	file_control_proto_rawDescOnce sync.Once
+	file_control_proto_rawDescData []byte
+)
+
+func file_control_proto_rawDescGZIP() []byte {
+	file_control_proto_rawDescOnce.Do(func() {
+		file_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_control_proto_rawDesc), len(file_control_proto_rawDesc)))
+	})
+	return file_control_proto_rawDescData
+}
+
+var file_control_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_control_proto_goTypes = []any{
+	(*DeviceRequest)(nil),         // 0: cameratrigger.control.DeviceRequest
+	(*ScanRequest)(nil),           // 1: cameratrigger.control.ScanRequest
+	(*Device)(nil),                // 2: cameratrigger.control.Device
+	(*ScanResponse)(nil),          // 3: cameratrigger.control.ScanResponse
+	(*ConnectRequest)(nil),        // 4: cameratrigger.control.ConnectRequest
+	(*ConnectResponse)(nil),       // 5: cameratrigger.control.ConnectResponse
+	(*ListResponse)(nil),          // 6: cameratrigger.control.ListResponse
+	(*ParamRequest)(nil),          // 7: cameratrigger.control.ParamRequest
+	(*SetParamRequest)(nil),       // 8: cameratrigger.control.SetParamRequest
+	(*Param)(nil),                 // 9: cameratrigger.control.Param
+	(*TriggerRequest)(nil),        // 10: cameratrigger.control.TriggerRequest
+	(*Calendar)(nil),              // 11: cameratrigger.control.Calendar
+	(*MotionStatus)(nil),          // 12: cameratrigger.control.MotionStatus
+	(*LightStatus)(nil),           // 13: cameratrigger.control.LightStatus
+	(*Status)(nil),                // 14: cameratrigger.control.Status
+	(*LogsRequest)(nil),           // 15: cameratrigger.control.LogsRequest
+	(*LogEntry)(nil),              // 16: cameratrigger.control.LogEntry
+	(*LogsResponse)(nil),          // 17: cameratrigger.control.LogsResponse
+	(*durationpb.Duration)(nil),   // 18: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil), // 19: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 20: google.protobuf.Empty
+}
+var file_control_proto_depIdxs = []int32{
+	18, // 0: cameratrigger.control.ScanRequest.duration:type_name -> google.protobuf.Duration
+	19, // 1: cameratrigger.control.Device.detected:type_name -> google.protobuf.Timestamp
+	2,  // 2: cameratrigger.control.ScanResponse.devices:type_name -> cameratrigger.control.Device
+	18, // 3: cameratrigger.control.ConnectRequest.timeout:type_name -> google.protobuf.Duration
+	5,  // 4: cameratrigger.control.ListResponse.devices:type_name -> cameratrigger.control.ConnectResponse
+	11, // 5: cameratrigger.control.MotionStatus.timestamp:type_name -> cameratrigger.control.Calendar
+	11, // 6: cameratrigger.control.LightStatus.timestamp:type_name -> cameratrigger.control.Calendar
+	19, // 7: cameratrigger.control.Status.received:type_name -> google.protobuf.Timestamp
+	12, // 8: cameratrigger.control.Status.motion:type_name -> cameratrigger.control.MotionStatus
+	13, // 9: cameratrigger.control.Status.light:type_name -> cameratrigger.control.LightStatus
+	11, // 10: cameratrigger.control.LogEntry.timestamp:type_name -> cameratrigger.control.Calendar
+	16, // 11: cameratrigger.control.LogsResponse.entries:type_name -> cameratrigger.control.LogEntry
+	1,  // 12: cameratrigger.control.Control.Scan:input_type -> cameratrigger.control.ScanRequest
+	4,  // 13: cameratrigger.control.Control.Connect:input_type -> cameratrigger.control.ConnectRequest
+	0,  // 14: cameratrigger.control.Control.Disconnect:input_type -> cameratrigger.control.DeviceRequest
+	20, // 15: cameratrigger.control.Control.List:input_type -> google.protobuf.Empty
+	7,  // 16: cameratrigger.control.Control.GetParam:input_type -> cameratrigger.control.ParamRequest
+	8,  // 17: cameratrigger.control.Control.SetParam:input_type -> cameratrigger.control.SetParamRequest
+	10, // 18: cameratrigger.control.Control.Trigger:input_type -> cameratrigger.control.TriggerRequest
+	0,  // 19: cameratrigger.control.Control.StreamStatus:input_type -> cameratrigger.control.DeviceRequest
+	15, // 20: cameratrigger.control.Control.Logs:input_type -> cameratrigger.control.LogsRequest
+	3,  // 21: cameratrigger.control.Control.Scan:output_type -> cameratrigger.control.ScanResponse
+	5,  // 22: cameratrigger.control.Control.Connect:output_type -> cameratrigger.control.ConnectResponse
+	20, // 23: cameratrigger.control.Control.Disconnect:output_type -> google.protobuf.Empty
+	6,  // 24: cameratrigger.control.Control.List:output_type -> cameratrigger.control.ListResponse
+	9,  // 25: cameratrigger.control.Control.GetParam:output_type -> cameratrigger.control.Param
+	9,  // 26: cameratrigger.control.Control.SetParam:output_type -> cameratrigger.control.Param
+	20, // 27: cameratrigger.control.Control.Trigger:output_type -> google.protobuf.Empty
+	14, // 28: cameratrigger.control.Control.StreamStatus:output_type -> cameratrigger.control.Status
+	17, // 29: cameratrigger.control.Control.Logs:output_type -> cameratrigger.control.LogsResponse
+	21, // [21:30] is the sub-list for method output_type
+	12, // [12:21] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_control_proto_init() }
+func file_control_proto_init() {
+	if File_control_proto != nil {
+		return
+	}
+	file_control_proto_msgTypes[14].OneofWrappers = []any{
+		(*Status_Motion)(nil),
+		(*Status_Light)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_control_proto_rawDesc), len(file_control_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_control_proto_goTypes,
+		DependencyIndexes: file_control_proto_depIdxs,
+		MessageInfos:      file_control_proto_msgTypes,
+	}.Build()
+	File_control_proto = out.File
+	file_control_proto_goTypes = nil
+	file_control_proto_depIdxs = nil
+}
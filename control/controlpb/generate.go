@@ -0,0 +1,6 @@
+// Package controlpb holds the protocol buffer definition of the control
+// service and the code generated from it. Go programs use the client in the
+// control package rather than these stubs directly.
+package controlpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative control.proto
@@ -0,0 +1,240 @@
+package control
+
+import (
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/control/controlpb"
+	"github.com/phelpsw/camera-trigger-bt-cli/messages"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Conversions between the types of this package and those of the service
+
+// Proto converts the device for sending
+func (d Device) Proto() *controlpb.Device {
+	return &controlpb.Device{
+		Name:     d.Name,
+		Address:  d.Address,
+		Rssi:     int32(d.RSSI),
+		Detected: timeProto(d.Detected),
+	}
+}
+
+// DeviceFromProto converts a received device
+func DeviceFromProto(p *controlpb.Device) Device {
+	return Device{
+		Name:     p.GetName(),
+		Address:  p.GetAddress(),
+		RSSI:     int(p.GetRssi()),
+		Detected: timeFromProto(p.GetDetected()),
+	}
+}
+
+// Proto converts the connected device for sending
+func (c Connected) Proto() *controlpb.ConnectResponse {
+	return &controlpb.ConnectResponse{Device: c.Device, Board: c.Board}
+}
+
+// ConnectedFromProto converts a received connected device
+func ConnectedFromProto(p *controlpb.ConnectResponse) Connected {
+	return Connected{Device: p.GetDevice(), Board: p.GetBoard()}
+}
+
+// Proto converts the parameter for sending
+func (p Param) Proto() *controlpb.Param {
+	return &controlpb.Param{
+		Name:    p.Name,
+		Type:    p.Type,
+		Persist: p.Persist,
+		Success: p.Success,
+		Value:   p.Value,
+	}
+}
+
+// ParamFromProto converts a received parameter
+func ParamFromProto(p *controlpb.Param) Param {
+	return Param{
+		Name:    p.GetName(),
+		Type:    p.GetType(),
+		Persist: p.GetPersist(),
+		Success: p.GetSuccess(),
+		Value:   p.GetValue(),
+	}
+}
+
+// Proto converts the status for sending
+func (s Status) Proto() *controlpb.Status {
+	p := &controlpb.Status{
+		Device:   s.Device,
+		Sequence: s.Sequence,
+		Board:    s.Board,
+		Received: timeProto(s.Received),
+	}
+	if m := s.Motion; m != nil {
+		p.Message = &controlpb.Status_Motion{Motion: &controlpb.MotionStatus{
+			Timestamp:        calendarProto(m.Timestamp),
+			Temperature:      m.Temperature,
+			Voltage:          m.Voltage,
+			Motion:           m.Motion,
+			MotionThreshold:  m.MotionThreshold,
+			Lux:              m.Lux,
+			LuxLowThreshold:  m.LuxLowThreshold,
+			LuxHighThreshold: m.LuxHighThreshold,
+			Cooldown:         m.Cooldown,
+			MotionSensorType: uint32(m.MotionSensorType),
+			LedModes:         uint32(m.LedModes),
+			LogEntries:       uint32(m.LogEntries),
+		}}
+	}
+	if l := s.Light; l != nil {
+		p.Message = &controlpb.Status_Light{Light: &controlpb.LightStatus{
+			Timestamp:        calendarProto(l.Timestamp),
+			Temperature:      l.Payload.Temperature,
+			Voltage:          l.Payload.Voltage,
+			Level:            l.Payload.Level,
+			Delay:            l.Payload.Delay,
+			Attack:           l.Payload.Attack,
+			Sustain:          l.Payload.Sustain,
+			Release:          l.Payload.Release,
+			LightTemperature: l.Payload.LightTemperature,
+			Current:          l.Payload.Current,
+			LedModes:         uint32(l.Payload.LedModes),
+			LogEntries:       uint32(l.Payload.LogEntries),
+		}}
+	}
+	return p
+}
+
+// StatusFromProto converts a received status
+func StatusFromProto(p *controlpb.Status) Status {
+	s := Status{
+		Device:   p.GetDevice(),
+		Sequence: p.GetSequence(),
+		Board:    p.GetBoard(),
+		Received: timeFromProto(p.GetReceived()),
+	}
+	if m := p.GetMotion(); m != nil {
+		t, length := header("MotionSensorStatusMessage")
+		s.Motion = &messages.MotionSensorStatusMessage{
+			Type:             t,
+			Length:           length,
+			Timestamp:        calendarFromProto(m.GetTimestamp()),
+			Temperature:      m.GetTemperature(),
+			Voltage:          m.GetVoltage(),
+			Motion:           m.GetMotion(),
+			MotionThreshold:  m.GetMotionThreshold(),
+			Lux:              m.GetLux(),
+			LuxLowThreshold:  m.GetLuxLowThreshold(),
+			LuxHighThreshold: m.GetLuxHighThreshold(),
+			Cooldown:         m.GetCooldown(),
+			MotionSensorType: uint8(m.GetMotionSensorType()),
+			LedModes:         uint8(m.GetLedModes()),
+			LogEntries:       uint16(m.GetLogEntries()),
+		}
+	}
+	if l := p.GetLight(); l != nil {
+		t, length := header("LightStatusMessage")
+		s.Light = &messages.LightStatusMessage{
+			BasicMessage: messages.BasicMessage{Type: t, Length: length},
+			Timestamp:    calendarFromProto(l.GetTimestamp()),
+			Payload: messages.LightStatus{
+				Temperature:      l.GetTemperature(),
+				Voltage:          l.GetVoltage(),
+				Level:            l.GetLevel(),
+				Delay:            l.GetDelay(),
+				Attack:           l.GetAttack(),
+				Sustain:          l.GetSustain(),
+				Release:          l.GetRelease(),
+				LightTemperature: l.GetLightTemperature(),
+				Current:          l.GetCurrent(),
+				LedModes:         uint8(l.GetLedModes()),
+				LogEntries:       uint16(l.GetLogEntries()),
+			},
+		}
+	}
+	return s
+}
+
+// Proto converts the log entries for sending
+func (l Logs) Proto() *controlpb.LogsResponse {
+	p := &controlpb.LogsResponse{}
+	for _, entry := range l.Entries {
+		p.Entries = append(p.Entries, &controlpb.LogEntry{
+			Index:     uint32(entry.Index),
+			Timestamp: calendarProto(entry.Timestamp),
+			LogType:   uint32(entry.LogType),
+			Payload:   entry.Payload[:],
+		})
+	}
+	for _, index := range l.Missing {
+		p.Missing = append(p.Missing, uint32(index))
+	}
+	return p
+}
+
+// LogsFromProto converts received log entries
+func LogsFromProto(p *controlpb.LogsResponse) Logs {
+	var l Logs
+	t, length := header("LogResponseMessage")
+	for _, entry := range p.GetEntries() {
+		msg := messages.LogResponseMessage{
+			Type:      t,
+			Length:    length,
+			Index:     uint16(entry.GetIndex()),
+			Timestamp: calendarFromProto(entry.GetTimestamp()),
+			LogType:   uint8(entry.GetLogType()),
+		}
+		copy(msg.Payload[:], entry.GetPayload())
+		l.Entries = append(l.Entries, msg)
+	}
+	for _, index := range p.GetMissing() {
+		l.Missing = append(l.Missing, uint16(index))
+	}
+	return l
+}
+
+// header returns the type code and length of the named message
+func header(name string) (uint8, uint8) {
+	info, _ := messages.TypeByName(name)
+	return info.Code, uint8(info.Size())
+}
+
+func calendarProto(c messages.Calendar) *controlpb.Calendar {
+	return &controlpb.Calendar{
+		Seconds:    uint32(c.Seconds),
+		Minutes:    uint32(c.Minutes),
+		Hours:      uint32(c.Hours),
+		DayOfWeek:  uint32(c.DayOfWeek),
+		DayOfMonth: uint32(c.DayOfMonth),
+		Month:      uint32(c.Month),
+		Year:       uint32(c.Year),
+	}
+}
+
+func calendarFromProto(p *controlpb.Calendar) messages.Calendar {
+	return messages.Calendar{
+		Seconds:    uint8(p.GetSeconds()),
+		Minutes:    uint8(p.GetMinutes()),
+		Hours:      uint8(p.GetHours()),
+		DayOfWeek:  uint8(p.GetDayOfWeek()),
+		DayOfMonth: uint8(p.GetDayOfMonth()),
+		Month:      uint8(p.GetMonth()),
+		Year:       uint16(p.GetYear()),
+	}
+}
+
+// timeProto converts a time, leaving the zero time unset
+func timeProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// timeFromProto converts a timestamp, an unset one to the zero time
+func timeFromProto(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime().Local()
+}
@@ -1,15 +1,34 @@
 module github.com/phelpsw/camera-trigger-bt-cli
 
-go 1.13
+go 1.24.0
 
 require (
 	github.com/JuulLabs-OSS/ble v0.0.0-20200716215611-d4fcc9d598bb
-	github.com/JuulLabs-OSS/cbgo v0.0.2 // indirect
 	github.com/c-bata/go-prompt v0.2.5
-	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d // indirect
+	github.com/eclipse/paho.mqtt.golang v1.2.0
+	github.com/mattn/go-isatty v0.0.12
 	github.com/pkg/errors v0.9.1
+	github.com/spf13/cobra v0.0.7
+	github.com/spf13/pflag v1.0.5
+	google.golang.org/grpc v1.79.3
+	google.golang.org/protobuf v1.36.10
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
+	github.com/JuulLabs-OSS/cbgo v0.0.2 // indirect
+	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
+	github.com/mattn/go-colorable v0.1.7 // indirect
+	github.com/mattn/go-runewidth v0.0.9 // indirect
+	github.com/mattn/go-tty v0.0.3 // indirect
+	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d // indirect
+	github.com/mgutz/logxi v0.0.0-20161027140823-aebf8a7d67ab // indirect
+	github.com/pkg/term v1.1.0 // indirect
 	github.com/raff/goble v0.0.0-20200327175727-d63360dcfd80 // indirect
 	github.com/sirupsen/logrus v1.6.0 // indirect
-	github.com/spf13/cobra v0.0.7
-	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/net v0.48.0 // indirect
+	golang.org/x/sys v0.39.0 // indirect
+	golang.org/x/text v0.32.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20251202230838-ff82c1b0f217 // indirect
 )
@@ -0,0 +1,221 @@
+package inventory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Role is the kind of board a device is deployed as
+type Role string
+
+const (
+	Motion Role = "motion"
+	Light  Role = "light"
+)
+
+// Inventory lists the devices deployed at each site
+//
+//	devices:
+//	  - name: camera-trigger-001
+//	    address: c4:7c:8d:6a:12:01
+//	    site: north-ridge
+//	    gps: {lat: 47.6097, lon: -122.3331}
+//	    role: motion
+//	    paired: camera-trigger-002
+//	    profile: night-trail
+//	    firmware: 1.4.2
+//	    tags: [trail, solar]
+type Inventory struct {
+	Devices []Device `yaml:"devices"`
+}
+
+// Device is a deployed board
+type Device struct {
+	// Name is the bluetooth name used to connect to the device
+	Name    string `yaml:"name"`
+	Address string `yaml:"address,omitempty"`
+	Site    string `yaml:"site,omitempty"`
+	GPS     *GPS   `yaml:"gps,omitempty"`
+	Role    Role   `yaml:"role,omitempty"`
+	// Paired is the name of the device working with this one, the light a
+	// motion sensor triggers or the reverse
+	Paired string `yaml:"paired,omitempty"`
+	// Profile is the name of the profile the device should have
+	Profile string `yaml:"profile,omitempty"`
+	// Firmware is the version, MAJOR.MINOR.PATCH, the device should run
+	Firmware string   `yaml:"firmware,omitempty"`
+	Tags     []string `yaml:"tags,omitempty"`
+}
+
+// GPS is the location of a device in decimal degrees
+type GPS struct {
+	Lat float64 `yaml:"lat"`
+	Lon float64 `yaml:"lon"`
+}
+
+func (g GPS) String() string {
+	return fmt.Sprintf("%.5f,%.5f", g.Lat, g.Lon)
+}
+
+// DefaultPath returns the default inventory file,
+// ~/.camera-trigger/inventory.yaml
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".camera-trigger", "inventory.yaml"), nil
+}
+
+// Load reads and validates the inventory file at path. The error of a
+// missing file satisfies os.IsNotExist.
+func Load(path string) (*Inventory, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var inv Inventory
+	err = yaml.UnmarshalStrict(data, &inv)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	err = inv.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return &inv, nil
+}
+
+// Validate checks every device is named once, has a known role, a location on
+// the globe and a valid firmware version, and is paired with another device
+// of the inventory
+func (inv *Inventory) Validate() error {
+	names := make(map[string]bool)
+	for _, d := range inv.Devices {
+		if d.Name == "" {
+			return fmt.Errorf("device without a name")
+		}
+		if names[strings.ToLower(d.Name)] {
+			return fmt.Errorf("%s is listed twice", d.Name)
+		}
+		names[strings.ToLower(d.Name)] = true
+	}
+
+	for _, d := range inv.Devices {
+		switch d.Role {
+		case "", Motion, Light:
+		default:
+			return fmt.Errorf("%s: unknown role %s, expected motion or light", d.Name, d.Role)
+		}
+		if d.GPS != nil && (d.GPS.Lat < -90 || d.GPS.Lat > 90 || d.GPS.Lon < -180 || d.GPS.Lon > 180) {
+			return fmt.Errorf("%s: gps %s is not a valid location", d.Name, d.GPS)
+		}
+		if d.Firmware != "" {
+			if _, err := ParseVersion(d.Firmware); err != nil {
+				return fmt.Errorf("%s: %s", d.Name, err)
+			}
+		}
+		if d.Paired != "" {
+			if strings.EqualFold(d.Paired, d.Name) {
+				return fmt.Errorf("%s: paired with itself", d.Name)
+			}
+			if !names[strings.ToLower(d.Paired)] {
+				return fmt.Errorf("%s: paired device %s is not in the inventory", d.Name, d.Paired)
+			}
+		}
+	}
+	return nil
+}
+
+// Find returns the device with the given name or address
+func (inv *Inventory) Find(nameOrAddress string) (Device, bool) {
+	for _, d := range inv.Devices {
+		if strings.EqualFold(d.Name, nameOrAddress) ||
+			(d.Address != "" && strings.EqualFold(d.Address, nameOrAddress)) {
+			return d, true
+		}
+	}
+	return Device{}, false
+}
+
+// Select returns the devices at any of sites which have every one of tags,
+// in inventory order. No sites selects every site.
+func (inv *Inventory) Select(sites []string, tags []string) []Device {
+	var selected []Device
+	for _, d := range inv.Devices {
+		if len(sites) > 0 && !contains(sites, d.Site) {
+			continue
+		}
+		matches := true
+		for _, tag := range tags {
+			if !contains(d.Tags, tag) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			selected = append(selected, d)
+		}
+	}
+	return selected
+}
+
+// Version is a firmware version, as reported by the version_major,
+// version_minor and version_patch parameters
+type Version struct {
+	Major, Minor, Patch uint16
+}
+
+// ParseVersion parses a version of the form MAJOR.MINOR.PATCH
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("firmware %s is not of the form MAJOR.MINOR.PATCH", s)
+	}
+	fields := []*uint16{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 16)
+		if err != nil {
+			return v, fmt.Errorf("firmware %s is not of the form MAJOR.MINOR.PATCH", s)
+		}
+		*fields[i] = uint16(n)
+	}
+	return v, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe returns the site and role of the device, e.g. "north-ridge motion"
+func (d Device) Describe() string {
+	var parts []string
+	if d.Site != "" {
+		parts = append(parts, d.Site)
+	}
+	if d.Role != "" {
+		parts = append(parts, string(d.Role))
+	}
+	if d.Paired != "" {
+		parts = append(parts, "paired with "+d.Paired)
+	}
+	return strings.Join(parts, " ")
+}
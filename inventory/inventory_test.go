@@ -0,0 +1,146 @@
+package inventory
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const site = `
+devices:
+  - name: camera-trigger-001
+    address: c4:7c:8d:6a:12:01
+    site: north-ridge
+    gps: {lat: 47.6097, lon: -122.3331}
+    role: motion
+    paired: camera-trigger-002
+    profile: night-trail
+    firmware: 1.4.2
+    tags: [trail, solar]
+  - name: camera-trigger-002
+    site: north-ridge
+    role: light
+    paired: camera-trigger-001
+    tags: [trail]
+  - name: camera-trigger-003
+    site: Creek
+    role: motion
+    tags: [solar]
+`
+
+func load(t *testing.T, data string) (*Inventory, error) {
+	dir, err := ioutil.TempDir("", "inventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "inventory.yaml")
+	err = ioutil.WriteFile(path, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+func names(devices []Device) []string {
+	var names []string
+	for _, d := range devices {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+func TestLoad(t *testing.T) {
+	inv, err := load(t, site)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Device{
+		Name:     "camera-trigger-001",
+		Address:  "c4:7c:8d:6a:12:01",
+		Site:     "north-ridge",
+		GPS:      &GPS{47.6097, -122.3331},
+		Role:     Motion,
+		Paired:   "camera-trigger-002",
+		Profile:  "night-trail",
+		Firmware: "1.4.2",
+		Tags:     []string{"trail", "solar"},
+	}
+	if !reflect.DeepEqual(inv.Devices[0], want) {
+		t.Errorf("Devices[0] = %+v, want %+v", inv.Devices[0], want)
+	}
+	if got := want.Describe(); got != "north-ridge motion paired with camera-trigger-002" {
+		t.Errorf("Describe() = %s", got)
+	}
+
+	for _, key := range []string{"C4:7C:8D:6A:12:01", "Camera-Trigger-001"} {
+		if d, ok := inv.Find(key); !ok || d.Name != want.Name {
+			t.Errorf("Find(%s) = %+v, %v", key, d, ok)
+		}
+	}
+	if _, ok := inv.Find("camera-trigger-004"); ok {
+		t.Error("Find() found a device not in the inventory")
+	}
+
+	errors := map[string]string{
+		"devices:\n  - site: a\n":                             "device without a name",
+		"devices:\n  - name: a\n  - name: A\n":                "A is listed twice",
+		"devices:\n  - name: a\n    role: camera\n":           "a: unknown role camera",
+		"devices:\n  - name: a\n    paired: b\n":              "a: paired device b is not in the inventory",
+		"devices:\n  - name: a\n    paired: a\n":              "a: paired with itself",
+		"devices:\n  - name: a\n    gps: {lat: 91, lon: 0}\n": "a: gps 91.00000,0.00000 is not a valid location",
+		"devices:\n  - name: a\n    location: north\n":        "field location not found",
+		"devices:\n  - name: a\n    firmware: 1.4\n":          "a: firmware 1.4 is not of the form MAJOR.MINOR.PATCH",
+	}
+	for data, want := range errors {
+		_, err = load(t, data)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load(%q) error %v, want %s", data, err, want)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("1.4.12")
+	if err != nil || v != (Version{1, 4, 12}) {
+		t.Errorf("ParseVersion(1.4.12) = %v, %v", v, err)
+	}
+	if v.String() != "1.4.12" {
+		t.Errorf("String() = %s", v)
+	}
+
+	for _, s := range []string{"", "1.4", "1.4.2.0", "1.x.2", "1.4.65536", "v1.4.2"} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Errorf("ParseVersion(%q) succeeded", s)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	inv, err := load(t, site)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		sites []string
+		tags  []string
+		want  []string
+	}{
+		{nil, nil, []string{"camera-trigger-001", "camera-trigger-002", "camera-trigger-003"}},
+		{[]string{"north-ridge"}, nil, []string{"camera-trigger-001", "camera-trigger-002"}},
+		{[]string{"creek", "north-ridge"}, []string{"solar"}, []string{"camera-trigger-001", "camera-trigger-003"}},
+		{nil, []string{"trail", "Solar"}, []string{"camera-trigger-001"}},
+		{[]string{"creek"}, []string{"trail"}, nil},
+	}
+	for _, tt := range tests {
+		if got := names(inv.Select(tt.sites, tt.tags)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Select(%v, %v) = %v, want %v", tt.sites, tt.tags, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package messages
+
+import (
+	"fmt"
+	"time"
+)
+
+// calendarEpoch is the year the device calendar counts from. The device
+// clock keeps a two digit year, so Year 18 is 2018.
+const calendarEpoch = 2000
+
+// The device clock carries no time zone. Calendars are interpreted in
+// whichever location the caller chose when setting the clock, which is local
+// time unless settime was run with --utc.
+
+// FromTime sets the calendar to t, using the zone t is expressed in
+func (c *Calendar) FromTime(t time.Time) {
+	c.Seconds = uint8(t.Second())
+	c.Minutes = uint8(t.Minute())
+	c.Hours = uint8(t.Hour())
+	c.DayOfWeek = uint8(t.Weekday())
+	c.DayOfMonth = uint8(t.Day())
+	c.Month = uint8(t.Month())
+	c.Year = uint16(t.Year() - calendarEpoch)
+}
+
+// Time converts the calendar to a time in loc
+func (c Calendar) Time(loc *time.Location) time.Time {
+	return time.Date(c.FullYear(), time.Month(c.Month), int(c.DayOfMonth),
+		int(c.Hours), int(c.Minutes), int(c.Seconds), 0, loc)
+}
+
+// FullYear returns the four digit year. Devices set by older versions of this
+// tool hold a four digit year which is returned unchanged.
+func (c Calendar) FullYear() int {
+	if c.Year < 100 {
+		return int(c.Year) + calendarEpoch
+	}
+	return int(c.Year)
+}
+
+// IsSet reports whether the calendar holds a valid date. An unset device
+// clock reports day and month 0.
+func (c Calendar) IsSet() bool {
+	return c.Month >= 1 && c.Month <= 12 &&
+		c.DayOfMonth >= 1 && c.DayOfMonth <= 31 &&
+		c.Hours < 24 && c.Minutes < 60 && c.Seconds < 60
+}
+
+func (c Calendar) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d %02d:%02d:%02d",
+		c.FullYear(), c.Month, c.DayOfMonth, c.Hours, c.Minutes, c.Seconds)
+}
@@ -0,0 +1,61 @@
+package messages
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarTime(t *testing.T) {
+	loc := time.FixedZone("test", -7*60*60)
+	ts := time.Date(2026, time.October, 19, 13, 4, 59, 0, loc)
+
+	var cal Calendar
+	cal.FromTime(ts)
+
+	want := Calendar{
+		Seconds:    59,
+		Minutes:    4,
+		Hours:      13,
+		DayOfWeek:  uint8(time.Monday),
+		DayOfMonth: 19,
+		Month:      10,
+		Year:       26,
+	}
+	if cal != want {
+		t.Errorf("FromTime() = %+v, want %+v", cal, want)
+	}
+
+	if got := cal.Time(loc); !got.Equal(ts) {
+		t.Errorf("Time() = %v, want %v", got, ts)
+	}
+
+	if got := cal.String(); got != "2026-10-19 13:04:59" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestCalendarFullYear(t *testing.T) {
+	tests := []struct {
+		year uint16
+		want int
+	}{
+		{0, 2000},
+		{18, 2018},
+		{99, 2099},
+		{2020, 2020},
+	}
+	for _, tt := range tests {
+		if got := (Calendar{Year: tt.year}).FullYear(); got != tt.want {
+			t.Errorf("FullYear(%d) = %d, want %d", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestCalendarIsSet(t *testing.T) {
+	if (Calendar{}).IsSet() {
+		t.Errorf("zero Calendar reported as set")
+	}
+	if !(Calendar{Seconds: 11, DayOfMonth: 1, Month: 1, Year: 18}).IsSet() {
+		t.Errorf("valid Calendar reported as unset")
+	}
+}
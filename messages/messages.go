@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"sync/atomic"
 )
 
 /*
@@ -334,11 +335,45 @@ type SetFloatResponse struct {
 	Value   float32
 }
 
-var rxBufArray []byte = make([]byte, 0, 512)
-var rxBuf = bytes.NewBuffer(rxBufArray)
+// discarded counts bytes skipped while searching for a message header
+var discarded uint64
 
-// ReadMessage parses a slice of bytes and returns a message if found
+// DiscardedBytes returns the number of received bytes which were skipped
+// because they did not start a recognised message
+func DiscardedBytes() uint64 {
+	return atomic.LoadUint64(&discarded)
+}
+
+// Decoder reassembles messages from the bytes received on one connection
+type Decoder struct {
+	buf       bytes.Buffer
+	discarded uint64
+}
+
+// Buffered returns the bytes held waiting for the rest of a message
+func (d *Decoder) Buffered() []byte {
+	return d.buf.Bytes()
+}
+
+// Discarded returns the number of bytes this decoder has skipped while
+// searching for a message header
+func (d *Decoder) Discarded() uint64 {
+	return atomic.LoadUint64(&d.discarded)
+}
+
+var defaultDecoder Decoder
+
+// ReadMessage parses a slice of bytes and returns a message if found, using
+// a decoder shared by every caller
 func ReadMessage(b []byte) (interface{}, error) {
+	return defaultDecoder.ReadMessage(b)
+}
+
+// ReadMessage adds a slice of received bytes to the buffer and returns a
+// message if one is complete
+func (d *Decoder) ReadMessage(b []byte) (interface{}, error) {
+	rxBuf := &d.buf
+
 	_, err := rxBuf.Write(b)
 	if err != nil {
 		return nil, err
@@ -349,20 +384,22 @@ func ReadMessage(b []byte) (interface{}, error) {
 		return nil, nil
 	}
 
-	for rxBuf.Len() > 2 {
+	for rxBuf.Len() >= 2 {
 		if int(rxBuf.Bytes()[1]) != getMessageTypeLength(rxBuf.Bytes()[0]) {
 			_, _ = rxBuf.ReadByte()
-
-			// Not strictly an error
-			if rxBuf.Len() <= 2 {
-				return nil, nil
-			}
+			atomic.AddUint64(&discarded, 1)
+			atomic.AddUint64(&d.discarded, 1)
 		} else {
 			// A message has potentially been identified
 			break
 		}
 	}
 
+	// Not strictly an error, the rest of a header may follow
+	if rxBuf.Len() < 2 {
+		return nil, nil
+	}
+
 	// TODO: Maybe add a checksum and validate that here
 
 	header := BasicMessage{rxBuf.Bytes()[0], rxBuf.Bytes()[1]}
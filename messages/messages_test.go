@@ -1,98 +1,80 @@
 package messages
 
 import (
+	"bytes"
+	"math/rand"
 	"reflect"
 	"testing"
+	"testing/quick"
 )
 
+var motionStatusFrame = []byte{0x11, 0x2e,
+	0x0b, 0x00, 0x00, 0x01, 0x01, 0x06, 0x00, 0x14,
+	0x41, 0xcc, 0x00, 0x00,
+	0x40, 0x6c, 0xcc, 0xcd,
+	0x3e, 0x80, 0x00, 0x00,
+	0x3e, 0x99, 0x99, 0x9a,
+	0x42, 0x8e, 0x99, 0x9a,
+	0x40, 0xa0, 0x00, 0x00,
+	0x42, 0xc8, 0x00, 0x00,
+	0x41, 0x20, 0x00, 0x00,
+	0x01,
+	0x02,
+	0x00, 0x0a}
+
+var motionStatus = MotionSensorStatusMessage{
+	Type:   0x11,
+	Length: 46,
+	Timestamp: Calendar{
+		Seconds:    11,
+		DayOfWeek:  1,
+		DayOfMonth: 1,
+		Month:      6,
+		Year:       20},
+	Temperature:      25.5,
+	Voltage:          3.7,
+	Motion:           0.25,
+	MotionThreshold:  0.3,
+	Lux:              71.3,
+	LuxLowThreshold:  5,
+	LuxHighThreshold: 100,
+	Cooldown:         10,
+	MotionSensorType: 1,
+	LedModes:         2,
+	LogEntries:       10,
+}
+
 func TestReadMessage(t *testing.T) {
 	type args struct {
 		b []byte
 	}
+	// The cases run in order against one decoder, as chunks arrive
 	tests := []struct {
 		name    string
 		args    args
 		wantMsg interface{}
 		wantErr bool
 	}{
-		// TODO: Add test cases.
-
 		{"Basic Parse",
-			args{[]byte{0x03, 0x24,
-				0x0b, 0x00, 0x00, 0x01, 0x00, 0x12,
-				0x42, 0x8e, 0x99, 0x9a,
-				0x42, 0xc8, 0x00, 0x00,
-				0x00, 0x00, 0x00, 0x00,
-				0x08, 0x05,
-				0x00, 0x00,
-				0x00, 0x00, 0x00, 0x00,
-				0x00,
-				0x00,
-				0x00, 0x0a,
-				0x00, 0x00, 0x00, 0x00}},
-			MotionSensorStatusMessage{
-				Type:   3,
-				Length: 36,
-				Timestamp: Calendar{
-					Seconds: 11,
-					Minutes: 0,
-					Hours:   0,
-					Month:   1,
-					Year:    18},
-				Lux:              71.3,
-				LuxThreshold:     100.0,
-				Temperature:      0.0,
-				Motion:           2053,
-				MotionThreshold:  0,
-				Cooldown:         0.0,
-				MotionSensorType: 0,
-				LedModes:         0,
-				LogEntries:       10,
-				BtSleepDelay:     0.0,
-			},
+			args{motionStatusFrame},
+			motionStatus,
 			false},
 		{"Partial Parse Step 1",
-			args{[]byte{0x03, 0x24,
-				0x0b, 0x00, 0x00, 0x01, 0x00, 0x12,
-				0x42, 0x8e, 0x99, 0x9a,
-				0x42, 0xc8, 0x00, 0x00}},
+			args{motionStatusFrame[:16]},
 			nil,
 			false},
 		{"Partial Parse Step 2",
-			args{[]byte{
-				0x00, 0x00, 0x00, 0x00,
-				0x08, 0x05,
-				0x00, 0x00,
-				0x00, 0x00, 0x00, 0x00,
-				0x00,
-				0x00,
-				0x00, 0x0a,
-				0x00, 0x00, 0x00, 0x00}},
-			MotionSensorStatusMessage{
-				Type:   3,
-				Length: 36,
-				Timestamp: Calendar{
-					Seconds: 11,
-					Minutes: 0,
-					Hours:   0,
-					Month:   1,
-					Year:    18},
-				Lux:              71.3,
-				LuxThreshold:     100.0,
-				Temperature:      0.0,
-				Motion:           2053,
-				MotionThreshold:  0,
-				Cooldown:         0.0,
-				MotionSensorType: 0,
-				LedModes:         0,
-				LogEntries:       10,
-				BtSleepDelay:     0.0,
-			},
+			args{motionStatusFrame[16:]},
+			motionStatus,
+			false},
+		{"Garbage Before Message",
+			args{append([]byte{0xff, 0x00, 0x11}, motionStatusFrame...)},
+			motionStatus,
 			false},
 		{"Unknown Type",
 			args{[]byte{0xFF, 0x02}},
 			nil,
-			true},
+			false},
 		{"Stub with invalid type part 1",
 			args{[]byte{0xFF}},
 			nil,
@@ -100,50 +82,70 @@ func TestReadMessage(t *testing.T) {
 		{"Stub with invalid type part 2",
 			args{[]byte{0xFF}},
 			nil,
-			true},
+			false},
 		{"Stub with valid type but invalid length",
-			args{[]byte{0x03, 0xff}},
+			args{[]byte{0x11, 0x01}},
 			nil,
-			true},
+			false},
 		{"Light Status Message",
 			args{[]byte{
-				0x0b, 0x1c,
-				0x0b, 0x00, 0x00, 0x01, 0x00, 0x12,
-				0x00, 0x00, 0x00, 0x00,
-				0x00, 0x00, 0x00, 0x00,
-				0x00, 0x00, 0x00, 0x00,
-				0x00, 0x00, 0x00, 0x00,
-				0x00, 0x00, 0x00, 0x00}},
+				0x21, 0x31,
+				0x0b, 0x00, 0x00, 0x01, 0x01, 0x06, 0x00, 0x14,
+				0x41, 0xcc, 0x00, 0x00,
+				0x40, 0x6c, 0xcc, 0xcd,
+				0x3f, 0x4c, 0xcc, 0xcd,
+				0x3f, 0x80, 0x00, 0x00,
+				0x40, 0x00, 0x00, 0x00,
+				0x41, 0x20, 0x00, 0x00,
+				0x40, 0x80, 0x00, 0x00,
+				0x42, 0x20, 0x00, 0x00,
+				0x3f, 0x00, 0x00, 0x00,
+				0x02,
+				0x01, 0x00}},
 			LightStatusMessage{
 				BasicMessage: BasicMessage{
-					Type:   11,
-					Length: 28},
+					Type:   0x21,
+					Length: 49},
 				Timestamp: Calendar{
-					Seconds: 11,
-					Minutes: 0,
-					Hours:   0,
-					Month:   1,
-					Year:    18},
+					Seconds:    11,
+					DayOfWeek:  1,
+					DayOfMonth: 1,
+					Month:      6,
+					Year:       20},
 				Payload: LightStatus{
-					Delay:       0.0,
-					Attack:      0.0,
-					Sustain:     0.0,
-					Release:     0.0,
-					Temperature: 0.0}},
+					Temperature:      25.5,
+					Voltage:          3.7,
+					Level:            0.8,
+					Delay:            1,
+					Attack:           2,
+					Sustain:          10,
+					Release:          4,
+					LightTemperature: 40,
+					Current:          0.5,
+					LedModes:         2,
+					LogEntries:       256}},
 			false},
-		{"Motion Sensor Motion",
+		{"Get Uint16 Response",
 			args{[]byte{
-				0x02, 0x04,
-				0x04, 0x00}},
-			MotionSensorMotionMessage{
-				Type:   2,
-				Length: 4,
-				Motion: 1024},
+				0x45, 0x08,
+				0x01,
+				0x00, 0x02,
+				0x00,
+				0x00, 0x09}},
+			GetUint16Response{
+				BasicMessage: BasicMessage{
+					Type:   0x45,
+					Length: 8},
+				Success: 1,
+				Id:      2,
+				Value:   9},
 			false},
 	}
+
+	var d Decoder
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotMsg, err := ReadMessage(tt.args.b)
+			gotMsg, err := d.ReadMessage(tt.args.b)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ReadMessage() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -154,3 +156,270 @@ func TestReadMessage(t *testing.T) {
 		})
 	}
 }
+
+// received lists the message types a device sends, which the decoder
+// recognises
+func received() []TypeInfo {
+	var types []TypeInfo
+	for _, t := range Types {
+		if getMessageTypeLength(t.Code) == t.Size() {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// randomMessage returns a message of type t with random fields and a
+// correct header
+func randomMessage(t *testing.T, info TypeInfo, r *rand.Rand) interface{} {
+	v, ok := quick.Value(info.Type, r)
+	if !ok {
+		t.Fatalf("can't generate %s", info.Name)
+	}
+	v.FieldByName("Type").SetUint(uint64(info.Code))
+	v.FieldByName("Length").SetUint(uint64(info.Size()))
+	return v.Interface()
+}
+
+func encode(t *testing.T, msg interface{}) []byte {
+	buf, err := WriteMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// decodeAll feeds each chunk to d and returns every message decoded,
+// draining messages buffered behind the first of each chunk
+func decodeAll(d *Decoder, chunks ...[]byte) ([]interface{}, []error) {
+	var msgs []interface{}
+	var errs []error
+	for _, chunk := range chunks {
+		for {
+			before := len(d.Buffered())
+			msg, err := d.ReadMessage(chunk)
+			chunk = nil
+			if err != nil {
+				errs = append(errs, err)
+				if len(d.Buffered()) < before {
+					continue
+				}
+				break
+			}
+			if msg == nil {
+				break
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, errs
+}
+
+// sameBytes compares messages by their encoding, as a float field may hold NaN
+func sameBytes(t *testing.T, a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(encode(t, a[i]), encode(t, b[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, info := range Types {
+		for i := 0; i < 100; i++ {
+			msg := randomMessage(t, info, r)
+			b := encode(t, msg)
+			if len(b) != info.Size() || b[0] != info.Code || int(b[1]) != info.Size() {
+				t.Fatalf("%s encoded as % x", info.Name, b)
+			}
+
+			got, err := info.Decode(b)
+			if err != nil || !reflect.DeepEqual(got, msg) {
+				t.Fatalf("Decode(% x) = %+v, %v, want %+v", b, got, err, msg)
+			}
+		}
+	}
+
+	types := received()
+	if len(types) != 7 {
+		t.Errorf("decoder recognises %d types, want 7", len(types))
+	}
+	for _, info := range types {
+		for i := 0; i < 100; i++ {
+			msg := randomMessage(t, info, r)
+			var d Decoder
+			got, err := d.ReadMessage(encode(t, msg))
+			if err != nil || !reflect.DeepEqual(got, msg) {
+				t.Fatalf("ReadMessage() = %+v, %v, want %+v", got, err, msg)
+			}
+			if len(d.Buffered()) != 0 {
+				t.Fatalf("%s: % x left buffered", info.Name, d.Buffered())
+			}
+		}
+	}
+}
+
+// TestSplit checks every received message type split at every point
+func TestSplit(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+
+	for _, info := range received() {
+		msg := randomMessage(t, info, r)
+		b := encode(t, msg)
+		for i := 1; i < len(b); i++ {
+			var d Decoder
+			got, err := d.ReadMessage(b[:i])
+			if got != nil || err != nil {
+				t.Fatalf("%s split at %d: first chunk gave %+v, %v", info.Name, i, got, err)
+			}
+			got, err = d.ReadMessage(b[i:])
+			if err != nil || !reflect.DeepEqual(got, msg) {
+				t.Fatalf("%s split at %d: ReadMessage() = %+v, %v", info.Name, i, got, err)
+			}
+		}
+	}
+}
+
+// seedFrames adds a frame of each received type to the corpus
+func seedFrames(f *testing.F, add func(frame []byte)) {
+	r := rand.New(rand.NewSource(3))
+	for _, info := range received() {
+		v, _ := quick.Value(info.Type, r)
+		v.FieldByName("Type").SetUint(uint64(info.Code))
+		v.FieldByName("Length").SetUint(uint64(info.Size()))
+		buf, err := WriteMessage(v.Interface())
+		if err != nil {
+			f.Fatal(err)
+		}
+		add(buf.Bytes())
+	}
+}
+
+// FuzzReadMessage checks that however a stream is split into chunks the
+// same messages are decoded, and that every message decoded was present in
+// the stream
+func FuzzReadMessage(f *testing.F) {
+	seedFrames(f, func(frame []byte) {
+		f.Add(frame, uint8(1))
+		f.Add(append(append([]byte{0xff, 0x45}, frame...), frame[:5]...), uint8(7))
+	})
+	f.Add([]byte{0x45, 0x01, 0x45, 0x08}, uint8(2))
+
+	f.Fuzz(func(t *testing.T, data []byte, size uint8) {
+		var whole Decoder
+		want, _ := decodeAll(&whole, data)
+
+		var chunks [][]byte
+		for n := int(size)%16 + 1; len(data) > 0; {
+			if n > len(data) {
+				n = len(data)
+			}
+			chunks = append(chunks, data[:n])
+			data = data[n:]
+		}
+		var split Decoder
+		got, _ := decodeAll(&split, chunks...)
+
+		if !sameBytes(t, got, want) {
+			t.Errorf("chunks of %d decoded %+v, whole stream %+v", int(size)%16+1, got, want)
+		}
+		stream := bytes.Join(chunks, nil)
+		for _, msg := range got {
+			if !inStream(t, stream, msg) {
+				t.Errorf("decoded %+v which is not in the stream", msg)
+			}
+		}
+	})
+}
+
+// inStream reports whether msg decodes from some frame in stream. Frames are
+// decoded rather than msg encoded as decoding quiets a signalling NaN.
+func inStream(t *testing.T, stream []byte, msg interface{}) bool {
+	want := encode(t, msg)
+	info, _ := TypeByCode(want[0])
+	for i := 0; i+len(want) <= len(stream); i++ {
+		frame, err := info.Decode(stream[i:])
+		if err == nil && bytes.Equal(encode(t, frame), want) {
+			return true
+		}
+	}
+	return false
+}
+
+// FuzzResync checks that a frame is recovered after any garbage which
+// cannot start a message
+func FuzzResync(f *testing.F) {
+	seedFrames(f, func(frame []byte) {
+		f.Add([]byte{0x00, 0xff, 0x2e}, frame)
+	})
+
+	f.Fuzz(func(t *testing.T, garbage []byte, frame []byte) {
+		if len(frame) < 2 {
+			return
+		}
+		info, ok := TypeByCode(frame[0])
+		if !ok || getMessageTypeLength(info.Code) != info.Size() || len(frame) < info.Size() {
+			return
+		}
+		frame = frame[:info.Size()]
+		frame[1] = byte(info.Size())
+
+		// Without a checksum a garbage byte equal to a type code followed by
+		// its length is indistinguishable from a header
+		var skip []byte
+		for _, b := range garbage {
+			if getMessageTypeLength(b) < 0 {
+				skip = append(skip, b)
+			}
+		}
+
+		want, err := info.Decode(frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var d Decoder
+		got, errs := decodeAll(&d, skip, frame)
+		if len(errs) > 0 || !sameBytes(t, got, []interface{}{want}) {
+			t.Errorf("after % x decoded %+v, %v, want %+v", skip, got, errs, want)
+		}
+		if d.Discarded() != uint64(len(skip)) {
+			t.Errorf("discarded %d bytes, want %d", d.Discarded(), len(skip))
+		}
+	})
+}
+
+// FuzzTruncated checks a truncated frame is held until it is complete
+func FuzzTruncated(f *testing.F) {
+	seedFrames(f, func(frame []byte) {
+		f.Add(frame, uint8(len(frame)/2))
+	})
+
+	f.Fuzz(func(t *testing.T, frame []byte, cut uint8) {
+		if len(frame) < 2 {
+			return
+		}
+		info, ok := TypeByCode(frame[0])
+		if !ok || getMessageTypeLength(info.Code) != info.Size() || len(frame) < info.Size() {
+			return
+		}
+		frame = frame[:info.Size()]
+		frame[1] = byte(info.Size())
+		n := int(cut) % len(frame)
+
+		var d Decoder
+		got, errs := decodeAll(&d, frame[:n])
+		if len(got) != 0 || len(errs) != 0 || !bytes.Equal(d.Buffered(), frame[:n]) {
+			t.Errorf("first %d bytes decoded %+v, %v, buffered % x", n, got, errs, d.Buffered())
+		}
+		got, errs = decodeAll(&d, frame[n:])
+		if len(got) != 1 || len(errs) != 0 {
+			t.Errorf("rest decoded %+v, %v", got, errs)
+		}
+	})
+}
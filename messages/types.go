@@ -0,0 +1,158 @@
+package messages
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TypeInfo describes a message type
+type TypeInfo struct {
+	Code uint8
+	Name string
+	Type reflect.Type
+}
+
+// Size returns the length of the message in bytes
+func (t TypeInfo) Size() int {
+	return binary.Size(reflect.New(t.Type).Elem().Interface())
+}
+
+// Types lists every message type, ordered by code
+var Types = []TypeInfo{
+	{logRequest, "LogRequestMessage", reflect.TypeOf(LogRequestMessage{})},
+	{logResponse, "LogResponseMessage", reflect.TypeOf(LogResponseMessage{})},
+	{logReset, "LogResetMessage", reflect.TypeOf(LogResetMessage{})},
+	{setTime, "SetTimeMessage", reflect.TypeOf(SetTimeMessage{})},
+	{motionSensorConfiguration, "MotionSensorConfigMessage", reflect.TypeOf(MotionSensorConfigMessage{})},
+	{motionSensorStatus, "MotionSensorStatusMessage", reflect.TypeOf(MotionSensorStatusMessage{})},
+	{motionSensorTrigger, "MotionSensorTriggerMessage", reflect.TypeOf(MotionSensorTriggerMessage{})},
+	{lightConfiguration, "LightConfigMessage", reflect.TypeOf(LightConfigMessage{})},
+	{lightStatus, "LightStatusMessage", reflect.TypeOf(LightStatusMessage{})},
+	{getFloatRequest, "GetFloatRequest", reflect.TypeOf(GetFloatRequest{})},
+	{getFloatResponse, "GetFloatResponse", reflect.TypeOf(GetFloatResponse{})},
+	{setFloatRequest, "SetFloatRequest", reflect.TypeOf(SetFloatRequest{})},
+	{setFloatResponse, "SetFloatResponse", reflect.TypeOf(SetFloatResponse{})},
+	{getUint16Request, "GetUint16Request", reflect.TypeOf(GetUint16Request{})},
+	{getUint16Response, "GetUint16Response", reflect.TypeOf(GetUint16Response{})},
+	{setUint16Request, "SetUint16Request", reflect.TypeOf(SetUint16Request{})},
+	{setUint16Response, "SetUint16Response", reflect.TypeOf(SetUint16Response{})},
+}
+
+// TypeByCode returns the message type with the given code
+func TypeByCode(code uint8) (TypeInfo, bool) {
+	for _, t := range Types {
+		if t.Code == code {
+			return t, true
+		}
+	}
+	return TypeInfo{}, false
+}
+
+// TypeByName returns the message type with the given name, ignoring case
+// and an optional Message suffix
+func TypeByName(name string) (TypeInfo, bool) {
+	name = strings.TrimSuffix(strings.ToLower(name), "message")
+	for _, t := range Types {
+		if strings.TrimSuffix(strings.ToLower(t.Name), "message") == name {
+			return t, true
+		}
+	}
+	return TypeInfo{}, false
+}
+
+// Decode parses a message of this type from the start of b
+func (t TypeInfo) Decode(b []byte) (interface{}, error) {
+	if len(b) < t.Size() {
+		return nil, fmt.Errorf("%s needs %d bytes, have %d", t.Name, t.Size(), len(b))
+	}
+
+	msg := reflect.New(t.Type)
+	err := binary.Read(bytes.NewReader(b), binary.BigEndian, msg.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return msg.Elem().Interface(), nil
+}
+
+// Build returns a message of this type with Type and Length set and the
+// fields in assignments, each NAME=VALUE, set. Nested fields are named with
+// dots, e.g. Timestamp.Year=20, and names ignore case. The year of a
+// Calendar is an offset from 2000. Integers may be given in hex with a 0x
+// prefix and byte arrays are given as hex. Type and Length may be assigned
+// to build malformed messages.
+func (t TypeInfo) Build(assignments []string) (interface{}, error) {
+	msg := reflect.New(t.Type).Elem()
+	msg.FieldByName("Type").SetUint(uint64(t.Code))
+	msg.FieldByName("Length").SetUint(uint64(t.Size()))
+
+	for _, a := range assignments {
+		parts := strings.SplitN(a, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%q is not NAME=VALUE", a)
+		}
+
+		field := msg
+		for _, name := range strings.Split(parts[0], ".") {
+			if field.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("%s: %s has no fields", parts[0], field.Type())
+			}
+			field = field.FieldByNameFunc(func(n string) bool {
+				return strings.EqualFold(n, name)
+			})
+			if !field.IsValid() {
+				return nil, fmt.Errorf("%s has no field %s", t.Name, parts[0])
+			}
+		}
+
+		err := setValue(field, parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", parts[0], err)
+		}
+	}
+
+	return msg.Interface(), nil
+}
+
+func setValue(v reflect.Value, s string) error {
+	bits := int(v.Type().Size()) * 8
+	switch v.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, bits)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, bits)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, bits)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Array:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			return err
+		}
+		if len(b) > v.Len() {
+			return fmt.Errorf("%d bytes given, at most %d fit", len(b), v.Len())
+		}
+		reflect.Copy(v, reflect.ValueOf(b))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
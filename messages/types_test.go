@@ -0,0 +1,93 @@
+package messages
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypes(t *testing.T) {
+	for i, info := range Types {
+		if i > 0 && info.Code <= Types[i-1].Code {
+			t.Errorf("%s out of order", info.Name)
+		}
+		if info.Type.Name() != info.Name {
+			t.Errorf("%s has type %s", info.Name, info.Type)
+		}
+		if got, ok := TypeByCode(info.Code); !ok || got.Name != info.Name {
+			t.Errorf("TypeByCode(0x%02x) = %s", info.Code, got.Name)
+		}
+		if got, ok := TypeByName(strings.ToLower(info.Name)); !ok || got.Name != info.Name {
+			t.Errorf("TypeByName(%s) = %s", info.Name, got.Name)
+		}
+	}
+
+	if got, ok := TypeByName("LogReset"); !ok || got.Code != logReset {
+		t.Errorf("TypeByName(LogReset) = %v", got)
+	}
+	if _, ok := TypeByCode(0x30); ok {
+		t.Error("TypeByCode(0x30) found a type")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	info, _ := TypeByName("SetTime")
+	msg, err := info.Build([]string{"timestamp.year=20", "Timestamp.Month=0x06", "timestamp.dayofmonth=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SetTimeMessage{Type: setTime, Length: 10, Timestamp: Calendar{DayOfMonth: 1, Month: 6, Year: 20}}
+	if msg != want {
+		t.Errorf("Build() = %+v, want %+v", msg, want)
+	}
+
+	info, _ = TypeByName("SetFloatRequest")
+	msg, err = info.Build([]string{"id=1", "persist=1", "value=0.35", "length=3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFloat := SetFloatRequest{BasicMessage{setFloatRequest, 3}, 1, 1, 0.35}
+	if msg != wantFloat {
+		t.Errorf("Build() = %+v, want %+v", msg, wantFloat)
+	}
+
+	errors := map[string]string{
+		"id":             `"id" is not NAME=VALUE`,
+		"missing=1":      "SetFloatRequest has no field missing",
+		"id=70000":       `id: strconv.ParseUint: parsing "70000": value out of range`,
+		"value=x":        `value: strconv.ParseFloat: parsing "x": invalid syntax`,
+		"BasicMessage=1": "BasicMessage: unsupported type messages.BasicMessage",
+		"id.low=1":       "id.low: uint16 has no fields",
+	}
+	for a, want := range errors {
+		_, err = info.Build([]string{a})
+		if err == nil || err.Error() != want {
+			t.Errorf("Build(%s) error %v, want %s", a, err, want)
+		}
+	}
+
+	info, _ = TypeByName("LogResponse")
+	msg, err = info.Build([]string{"payload=0102"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := msg.(LogResponseMessage).Payload; p[0] != 1 || p[1] != 2 || p[2] != 0 {
+		t.Errorf("Payload = % x", p)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	info, _ := TypeByName("GetUint16Response")
+	msg, err := info.Decode([]byte{0x45, 0x08, 0x01, 0x00, 0x02, 0x00, 0x00, 0x09, 0xff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := GetUint16Response{BasicMessage{getUint16Response, 8}, 1, 2, 0, 9}
+	if msg != want {
+		t.Errorf("Decode() = %+v, want %+v", msg, want)
+	}
+
+	_, err = info.Decode([]byte{0x45, 0x08})
+	if err == nil {
+		t.Error("Decode() of a partial message succeeded")
+	}
+}
@@ -0,0 +1,149 @@
+// Package metrics writes metrics in the Prometheus text exposition format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metric types
+const (
+	Gauge   = "gauge"
+	Counter = "counter"
+)
+
+// Label is a name and value pair identifying a sample
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single value of a metric
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Family is a named metric and its samples
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Set collects families by name so samples from several devices are written
+// under one header
+type Set struct {
+	families map[string]*Family
+}
+
+// NewSet creates an empty set
+func NewSet() *Set {
+	return &Set{families: make(map[string]*Family)}
+}
+
+func (s *Set) add(name, help, kind string, value float64, labels []Label) {
+	f, ok := s.families[name]
+	if !ok {
+		f = &Family{Name: name, Help: help, Type: kind}
+		s.families[name] = f
+	}
+	f.Samples = append(f.Samples, Sample{Labels: labels, Value: value})
+}
+
+// Gauge adds a gauge sample
+func (s *Set) Gauge(name, help string, value float64, labels ...Label) {
+	s.add(name, help, Gauge, value, labels)
+}
+
+// Counter adds a counter sample
+func (s *Set) Counter(name, help string, value float64, labels ...Label) {
+	s.add(name, help, Counter, value, labels)
+}
+
+// Families returns the families ordered by name
+func (s *Set) Families() []Family {
+	var families []Family
+	for _, f := range s.families {
+		families = append(families, *f)
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].Name < families[j].Name
+	})
+	return families
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Write writes the families in the text exposition format
+func Write(w io.Writer, families []Family) error {
+	for _, f := range families {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n",
+			f.Name, helpEscaper.Replace(f.Help), f.Name, f.Type)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range f.Samples {
+			labels := ""
+			if len(s.Labels) > 0 {
+				var pairs []string
+				for _, l := range s.Labels {
+					pairs = append(pairs, fmt.Sprintf(`%s="%s"`, l.Name, labelEscaper.Replace(l.Value)))
+				}
+				labels = "{" + strings.Join(pairs, ",") + "}"
+			}
+
+			_, err = fmt.Fprintf(w, "%s%s %s\n", f.Name, labels, formatValue(s.Value))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteFile writes the families to path for the node exporter textfile
+// collector. The file is replaced atomically so a partial file is never
+// collected.
+func WriteFile(path string, families []Family) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	err = Write(tmp, families)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	s := NewSet()
+	s.Gauge("voltage", "Battery voltage", 3.7, Label{"device", "a"})
+	s.Counter("frames_total", "Frames received", 10, Label{"device", "a"})
+	s.Gauge("voltage", "Battery voltage", 3.25, Label{"device", `b"\`})
+	s.Gauge("lux", "Light\nlevel", math.NaN())
+
+	var b bytes.Buffer
+	err := Write(&b, s.Families())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP frames_total Frames received
+# TYPE frames_total counter
+frames_total{device="a"} 10
+# HELP lux Light\nlevel
+# TYPE lux gauge
+lux NaN
+# HELP voltage Battery voltage
+# TYPE voltage gauge
+voltage{device="a"} 3.7
+voltage{device="b\"\\"} 3.25
+`
+	if b.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewSet()
+	s.Gauge("up", "Up", 1)
+	path := filepath.Join(dir, "camera.prom")
+
+	err = WriteFile(path, s.Families())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "# HELP up Up\n# TYPE up gauge\nup 1\n" {
+		t.Errorf("file contents %q", contents)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("%d files left in directory, want 1", len(files))
+	}
+}
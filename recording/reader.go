@@ -0,0 +1,184 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadFiles reads the samples from each file, in either format, and returns
+// them ordered by host time
+func ReadFiles(paths []string) ([]Sample, error) {
+	var samples []Sample
+
+	for _, path := range paths {
+		s, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, s...)
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].HostTime.Before(samples[j].HostTime)
+	})
+
+	return samples, nil
+}
+
+// ReadFile reads the samples from a csv or jsonl recording. The format is
+// taken from the content rather than the name, as the file may have been
+// renamed or written with --format.
+func ReadFile(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var samples []Sample
+	switch sniffFormat(r) {
+	case FormatJSON:
+		samples, err = readJSON(r)
+	default:
+		samples, err = readCSV(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	return samples, nil
+}
+
+// sniffFormat returns FormatJSON if the first non-blank byte begins a JSON
+// object, otherwise FormatCSV, the header of a csv recording
+func sniffFormat(r *bufio.Reader) string {
+	for n := 1; ; n++ {
+		b, err := r.Peek(n)
+		if len(b) < n || err != nil {
+			return FormatCSV
+		}
+		switch b[n-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{':
+			return FormatJSON
+		}
+		return FormatCSV
+	}
+}
+
+func readJSON(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var s Sample
+		err := json.Unmarshal(scanner.Bytes(), &s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		samples = append(samples, s)
+	}
+
+	return samples, scanner.Err()
+}
+
+func readCSV(r io.Reader) ([]Sample, error) {
+	cr := csv.NewReader(r)
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	column := make(map[string]int)
+	for i, name := range header {
+		column[name] = i
+	}
+
+	var samples []Sample
+	line := 1
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line++
+
+		field := func(name string) string {
+			i, ok := column[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return record[i]
+		}
+
+		var parseErr error
+		float := func(name string) float32 {
+			v := field(name)
+			if v == "" {
+				return 0
+			}
+			f, err := strconv.ParseFloat(v, 32)
+			if err != nil && parseErr == nil {
+				parseErr = fmt.Errorf("line %d: %s: %s", line, name, err)
+			}
+			return float32(f)
+		}
+
+		s := Sample{
+			DeviceTime:       field("device_time"),
+			Device:           field("device"),
+			Board:            field("board"),
+			Motion:           float("motion"),
+			MotionThreshold:  float("motion_threshold"),
+			Lux:              float("lux"),
+			LuxLowThreshold:  float("lux_low_threshold"),
+			LuxHighThreshold: float("lux_high_threshold"),
+			Level:            float("level"),
+			Current:          float("current"),
+			LightTemperature: float("light_temperature"),
+			Temperature:      float("temperature"),
+			Voltage:          float("voltage"),
+		}
+		if parseErr != nil {
+			return nil, parseErr
+		}
+
+		s.HostTime, err = time.Parse(time.RFC3339Nano, field("host_time"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: host_time: %s", line, err)
+		}
+
+		if v := field("log_entries"); v != "" {
+			n, err := strconv.ParseUint(v, 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: log_entries: %s", line, err)
+			}
+			s.LogEntries = uint16(n)
+		}
+
+		samples = append(samples, s)
+	}
+
+	return samples, nil
+}
@@ -0,0 +1,127 @@
+package recording
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2026, 10, 19, 13, 4, 59, 500, time.UTC)
+	samples := []Sample{
+		{HostTime: now, DeviceTime: "2026-10-19 13:04:59", Device: "camera-trigger-001",
+			Board: BoardMotion, Motion: 0.125, MotionThreshold: 0.3, Lux: 71.3,
+			LuxLowThreshold: 5, LuxHighThreshold: 100, Temperature: 21.5,
+			Voltage: 3.7, LogEntries: 10},
+		{HostTime: now.Add(time.Second), DeviceTime: "2026-10-19 13:05:00", Device: "camera-trigger-002",
+			Board: BoardLight, Level: 0.8, Current: 1.25, LightTemperature: 40,
+			Temperature: 22, Voltage: 3.6, LogEntries: 3},
+	}
+
+	for _, name := range []string{"status.csv", "status.jsonl"} {
+		path := filepath.Join(dir, name)
+
+		w, err := NewWriter(path, "", 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range samples {
+			if err = w.Write(s); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ReadFiles([]string{path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, samples) {
+			t.Errorf("%s: ReadFiles() = %+v, want %+v", name, got, samples)
+		}
+	}
+}
+
+func TestWriterRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w, err := NewWriter(filepath.Join(dir, "status.csv"), "", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w.Write(Sample{HostTime: time.Now(), Board: BoardMotion}); err != nil {
+		t.Fatal(err)
+	}
+	first := w.Filename()
+
+	// Files started within the same second are kept apart
+	for i := 0; i < 2; i++ {
+		if err = w.Write(Sample{HostTime: time.Now(), Board: BoardMotion}); err != nil {
+			t.Fatal(err)
+		}
+		if w.Filename() == first {
+			t.Errorf("Write() did not rotate past size limit")
+		}
+	}
+	w.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "status-*.csv"))
+	if len(files) != 3 {
+		t.Errorf("found %d rotated files, want 3", len(files))
+	}
+	samples, err := ReadFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 3 {
+		t.Errorf("read %d samples from rotated files, want 3", len(samples))
+	}
+}
+
+func TestReadFileFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sample := Sample{HostTime: time.Date(2026, 10, 19, 13, 4, 59, 0, time.UTC),
+		Board: BoardMotion, Motion: 0.25}
+
+	// The extension does not match the format written
+	for _, format := range []string{FormatCSV, FormatJSON} {
+		path := filepath.Join(dir, "status-"+format+".log")
+		w, err := NewWriter(path, format, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Write(sample); err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+
+		got, err := ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if len(got) != 1 || got[0] != sample {
+			t.Errorf("%s: ReadFile() = %+v, want %+v", format, got, sample)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package recording
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/phelpsw/camera-trigger-bt-cli/boards"
+)
+
+const (
+	BoardMotion = "motion"
+	BoardLight  = "light"
+)
+
+// Sample is a single recorded status message
+type Sample struct {
+	HostTime   time.Time `json:"host_time"`
+	DeviceTime string    `json:"device_time"`
+	Device     string    `json:"device"`
+	Board      string    `json:"board"`
+
+	// Motion sensor fields
+	Motion           float32 `json:"motion,omitempty"`
+	MotionThreshold  float32 `json:"motion_threshold,omitempty"`
+	Lux              float32 `json:"lux,omitempty"`
+	LuxLowThreshold  float32 `json:"lux_low_threshold,omitempty"`
+	LuxHighThreshold float32 `json:"lux_high_threshold,omitempty"`
+
+	// Light controller fields
+	Level            float32 `json:"level,omitempty"`
+	Current          float32 `json:"current,omitempty"`
+	LightTemperature float32 `json:"light_temperature,omitempty"`
+
+	Temperature float32 `json:"temperature"`
+	Voltage     float32 `json:"voltage"`
+	LogEntries  uint16  `json:"log_entries"`
+}
+
+// MotionSample captures the current status of a motion sensor
+func MotionSample(device string, m *boards.Motion, now time.Time) Sample {
+	return Sample{
+		HostTime:         now,
+		DeviceTime:       m.Timestamp().String(),
+		Device:           device,
+		Board:            BoardMotion,
+		Motion:           m.Motion(),
+		MotionThreshold:  m.MotionThreshold(),
+		Lux:              m.Lux(),
+		LuxLowThreshold:  m.LuxLowThreshold(),
+		LuxHighThreshold: m.LuxHighThreshold(),
+		Temperature:      m.Temperature(),
+		Voltage:          m.Voltage(),
+		LogEntries:       m.LogEntries(),
+	}
+}
+
+// LightSample captures the current status of a light controller
+func LightSample(device string, l *boards.Light, now time.Time) Sample {
+	return Sample{
+		HostTime:         now,
+		DeviceTime:       l.Timestamp().String(),
+		Device:           device,
+		Board:            BoardLight,
+		Level:            l.Level(),
+		Current:          l.Current(),
+		LightTemperature: l.LightTemperature(),
+		Temperature:      l.Temperature(),
+		Voltage:          l.Voltage(),
+		LogEntries:       l.LogEntries(),
+	}
+}
+
+var csvHeader = []string{
+	"host_time", "device_time", "device", "board",
+	"motion", "motion_threshold", "lux", "lux_low_threshold", "lux_high_threshold",
+	"level", "current", "light_temperature",
+	"temperature", "voltage", "log_entries",
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
+
+// record returns the csv columns of the sample. Columns belonging to the
+// other board type are left empty.
+func (s Sample) record() []string {
+	r := []string{
+		s.HostTime.Format(time.RFC3339Nano), s.DeviceTime, s.Device, s.Board,
+		"", "", "", "", "",
+		"", "", "",
+		formatFloat(s.Temperature), formatFloat(s.Voltage), strconv.Itoa(int(s.LogEntries)),
+	}
+
+	switch s.Board {
+	case BoardMotion:
+		r[4] = formatFloat(s.Motion)
+		r[5] = formatFloat(s.MotionThreshold)
+		r[6] = formatFloat(s.Lux)
+		r[7] = formatFloat(s.LuxLowThreshold)
+		r[8] = formatFloat(s.LuxHighThreshold)
+	case BoardLight:
+		r[9] = formatFloat(s.Level)
+		r[10] = formatFloat(s.Current)
+		r[11] = formatFloat(s.LightTemperature)
+	}
+
+	return r
+}
@@ -0,0 +1,184 @@
+package recording
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "jsonl"
+)
+
+// FormatFromPath returns the format implied by the file extension
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".jsonl":
+		return FormatJSON
+	default:
+		return FormatCSV
+	}
+}
+
+// Writer appends samples to a file, optionally starting a new file once the
+// current one reaches a size or age limit
+type Writer struct {
+	path           string
+	format         string
+	rotateSize     int64
+	rotateInterval time.Duration
+
+	file    *os.File
+	csv     *csv.Writer
+	json    *json.Encoder
+	size    int64
+	created time.Time
+}
+
+// NewWriter creates a writer for path in format, csv or jsonl. If either
+// rotation limit is non-zero each file is named after path with the time it
+// was started and a sequence number inserted before the extension, otherwise
+// samples are appended to path itself.
+func NewWriter(path string, format string, rotateSize int64, rotateInterval time.Duration) (*Writer, error) {
+	if format == "" {
+		format = FormatFromPath(path)
+	}
+	if format != FormatCSV && format != FormatJSON {
+		return nil, fmt.Errorf("unknown recording format %q", format)
+	}
+
+	return &Writer{
+		path:           path,
+		format:         format,
+		rotateSize:     rotateSize,
+		rotateInterval: rotateInterval,
+	}, nil
+}
+
+func (w *Writer) rotating() bool {
+	return w.rotateSize > 0 || w.rotateInterval > 0
+}
+
+// create opens the file to write. A rotated file is always new, the sequence
+// number distinguishing files started within the same second.
+func (w *Writer) create(now time.Time) (*os.File, error) {
+	if !w.rotating() {
+		return os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	}
+
+	ext := filepath.Ext(w.path)
+	base := strings.TrimSuffix(w.path, ext)
+	for seq := 1; ; seq++ {
+		name := fmt.Sprintf("%s-%s-%d%s", base, now.Format("20060102-150405"), seq, ext)
+		f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if !os.IsExist(err) {
+			return f, err
+		}
+	}
+}
+
+func (w *Writer) open(now time.Time) error {
+	f, err := w.create(now)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.created = now
+
+	switch w.format {
+	case FormatCSV:
+		w.csv = csv.NewWriter(f)
+		if w.size == 0 {
+			err = w.csv.Write(csvHeader)
+			if err != nil {
+				return err
+			}
+			w.csv.Flush()
+			return w.csv.Error()
+		}
+	case FormatJSON:
+		w.json = json.NewEncoder(f)
+	}
+
+	return nil
+}
+
+// Write appends a sample, rotating to a new file first if needed. Every
+// sample is flushed so nothing is lost if the process is killed.
+func (w *Writer) Write(s Sample) error {
+	now := time.Now()
+
+	if w.file != nil && w.rotating() {
+		if (w.rotateSize > 0 && w.size >= w.rotateSize) ||
+			(w.rotateInterval > 0 && now.Sub(w.created) >= w.rotateInterval) {
+			err := w.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if w.file == nil {
+		err := w.open(now)
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch w.format {
+	case FormatCSV:
+		err = w.csv.Write(s.record())
+		if err == nil {
+			w.csv.Flush()
+			err = w.csv.Error()
+		}
+	case FormatJSON:
+		err = w.json.Encode(s)
+	}
+	if err != nil {
+		return err
+	}
+
+	info, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	w.size = info.Size()
+
+	return nil
+}
+
+// Filename returns the file currently being written
+func (w *Writer) Filename() string {
+	if w.file == nil {
+		return ""
+	}
+	return w.file.Name()
+}
+
+// Close the current file
+func (w *Writer) Close() error {
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.file.Close()
+	w.file = nil
+	w.csv = nil
+	w.json = nil
+	return err
+}